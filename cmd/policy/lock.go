@@ -0,0 +1,112 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Define the `ec policy lock` command
+package policy
+
+import (
+	hd "github.com/MakeNowJust/heredoc"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/conforma/cli/internal/policy"
+	"github.com/conforma/cli/internal/policy/source"
+	"github.com/conforma/cli/internal/utils"
+	validate_utils "github.com/conforma/cli/internal/validate"
+)
+
+func lockCmd() *cobra.Command {
+	var (
+		policyConfiguration string
+		outputFile          string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "lock --policy <policy> --output <file>",
+		Short: "Pin the policy and data sources to immutable revisions",
+
+		Long: hd.Doc(`
+			Pin the policy and data sources to immutable revisions.
+
+			Each policy and data source of the policy configuration is resolved, git
+			sources to the commit and OCI sources to the image digest, and the pinned
+			URLs are written to the lock file.
+
+			Providing the lock file via the --policy-lock flag of the validate commands
+			makes the evaluation use the pinned revisions of the sources, regardless of
+			the changes to the sources since the lock file was created. If the sources
+			of the policy configuration are not in the lock file, the validation fails
+			reporting the drift, and the lock file needs to be updated by running this
+			command again. Sources that currently resolve to a revision other than the
+			pinned one are reported with a warning.
+		`),
+
+		Example: hd.Doc(`
+			Create the lock file for the policy configuration in policy.yaml:
+
+			  ec policy lock --policy policy.yaml --output policy.lock.yaml
+
+			Validate an image using the pinned sources:
+
+			  ec validate image --image registry/name:tag --policy policy.yaml \
+			    --policy-lock policy.lock.yaml
+		`),
+
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			policyConfiguration, err := validate_utils.GetPolicyConfig(ctx, policyConfiguration)
+			if err != nil {
+				return err
+			}
+
+			p, err := policy.NewInertPolicy(ctx, policyConfiguration)
+			if err != nil {
+				return err
+			}
+
+			fs := utils.FS(ctx)
+			workDir, err := utils.CreateWorkDir(fs)
+			if err != nil {
+				log.Debug("Failed to create work dir!")
+				return err
+			}
+			defer utils.CleanupWorkDir(fs, workDir)
+
+			lock, err := source.ResolveLock(ctx, p.Spec().Sources, workDir)
+			if err != nil {
+				return err
+			}
+
+			return lock.Write(ctx, outputFile)
+		},
+	}
+
+	cmd.Flags().StringVarP(&policyConfiguration, "policy", "p", "", hd.Doc(`
+		Policy configuration as:
+		  * Kubernetes reference ([<namespace>/]<name>)
+		  * file (policy.yaml)
+		  * git reference (github.com/user/repo//default?ref=main), or
+		  * inline JSON ('{sources: {...}}')`))
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "policy.lock.yaml", "write the lock to the given file")
+
+	if err := cmd.MarkFlagRequired("policy"); err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
@@ -27,12 +27,14 @@ import (
 	"github.com/conforma/cli/cmd/initialize"
 	"github.com/conforma/cli/cmd/inspect"
 	"github.com/conforma/cli/cmd/opa"
+	"github.com/conforma/cli/cmd/policy"
 	"github.com/conforma/cli/cmd/root"
 	"github.com/conforma/cli/cmd/sigstore"
 	"github.com/conforma/cli/cmd/test"
 	"github.com/conforma/cli/cmd/track"
 	"github.com/conforma/cli/cmd/validate"
 	"github.com/conforma/cli/cmd/version"
+	"github.com/conforma/cli/cmd/vsa"
 	"github.com/conforma/cli/internal/utils"
 )
 
@@ -61,9 +63,11 @@ func AddCommandsTo(cmd *cobra.Command) {
 	cmd.AddCommand(fetch.FetchCmd)
 	cmd.AddCommand(initialize.InitCmd)
 	cmd.AddCommand(inspect.InspectCmd)
+	cmd.AddCommand(policy.PolicyCmd)
 	cmd.AddCommand(track.TrackCmd)
 	cmd.AddCommand(validate.ValidateCmd)
 	cmd.AddCommand(version.VersionCmd)
+	cmd.AddCommand(vsa.VSACmd)
 	cmd.AddCommand(opa.OPACmd)
 	cmd.AddCommand(sigstore.SigstoreCmd)
 	if utils.Experimental() {
@@ -30,11 +30,13 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/conforma/cli/internal/http"
 	"github.com/conforma/cli/internal/kubernetes"
 	"github.com/conforma/cli/internal/logging"
 	"github.com/conforma/cli/internal/tracing"
+	"github.com/conforma/cli/internal/utils/oci"
 )
 
 var (
@@ -52,6 +54,12 @@ var (
 	retryFactor   float64       = 2.0
 	retryJitter   float64       = 0.1
 
+	// Persistent OCI content cache flags
+	ociCacheDir     string
+	ociCacheMaxSize string = "5Gi"
+
+	registriesConfig string
+
 	OnExit func() = func() {}
 )
 
@@ -109,6 +117,21 @@ func NewRootCmd() *cobra.Command {
 			}
 			http.SetRetryConfig(retryConfig)
 
+			// Apply the persistent OCI content cache configuration
+			cacheMaxSize, err := resource.ParseQuantity(ociCacheMaxSize)
+			if err != nil {
+				log.Fatalf("invalid --oci-cache-max-size value %q: %v", ociCacheMaxSize, err)
+			}
+			oci.SetCacheConfig(oci.CacheConfig{
+				Dir:     ociCacheDir,
+				MaxSize: cacheMaxSize.Value(),
+			})
+
+			// Apply the registries configuration
+			if err := oci.LoadRegistriesConfig(registriesConfig); err != nil {
+				log.Fatal(err)
+			}
+
 			// set a custom message for context.DeadlineExceeded error
 			context.DeadlineExceeded = customDeadlineExceededError{}
 
@@ -177,6 +200,9 @@ func NewRootCmd() *cobra.Command {
 						_ = tracefile.Close() // ignore errors
 						cmd.PrintErrf("Wrote performance trace to: %s\n", tracefile.Name())
 					}
+					if stats, ok := oci.GetCacheStats(); ok {
+						cmd.PrintErrf("OCI cache: %d hits (%d bytes), %d misses, %d evictions\n", stats.Hits, stats.HitBytes, stats.Misses, stats.Evictions)
+					}
 				}
 
 				// perform resource cleanup
@@ -218,5 +244,11 @@ func setFlags(rootCmd *cobra.Command) {
 	rootCmd.PersistentFlags().Float64Var(&retryFactor, "retry-factor", retryFactor, "exponential backoff multiplier")
 	rootCmd.PersistentFlags().Float64Var(&retryJitter, "retry-jitter", retryJitter, "randomness factor for backoff calculation (0.0-1.0)")
 
+	// Persistent OCI content cache flags
+	rootCmd.PersistentFlags().StringVar(&ociCacheDir, "oci-cache-dir", ociCacheDir, "directory for the persistent cache of image manifests and blobs fetched by digest, disabled if not set")
+	rootCmd.PersistentFlags().StringVar(&ociCacheMaxSize, "oci-cache-max-size", ociCacheMaxSize, "maximum size of the persistent OCI cache, e.g. 500Mi or 10Gi, least recently used content is evicted when exceeded")
+
+	rootCmd.PersistentFlags().StringVar(&registriesConfig, "registries-config", registriesConfig, "path to the registries configuration file with prefix rewrites and mirrors, in the containers-registries.conf format")
+
 	kubernetes.AddKubeconfigFlag(rootCmd)
 }
@@ -17,12 +17,16 @@
 package root
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/conforma/cli/internal/http"
+	"github.com/conforma/cli/internal/utils/oci"
 )
 
 func TestGlobalTimeout(t *testing.T) {
@@ -149,3 +153,40 @@ func TestRetryConfigurationFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestOCICacheFlags(t *testing.T) {
+	t.Cleanup(func() {
+		ociCacheDir = ""
+		ociCacheMaxSize = "5Gi"
+		oci.SetCacheConfig(oci.CacheConfig{})
+	})
+
+	dir := t.TempDir()
+
+	cmd := NewRootCmd()
+	cmd.Run = func(*cobra.Command, []string) {}
+	cmd.SetArgs([]string{"--oci-cache-dir", dir, "--oci-cache-max-size", "100Mi"})
+
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, oci.CacheConfig{Dir: dir, MaxSize: 100 * 1024 * 1024}, oci.GetCacheConfig())
+}
+
+func TestRegistriesConfigFlag(t *testing.T) {
+	t.Cleanup(func() {
+		registriesConfig = ""
+		assert.NoError(t, oci.LoadRegistriesConfig(""))
+	})
+
+	config := filepath.Join(t.TempDir(), "registries.conf")
+	assert.NoError(t, os.WriteFile(config, []byte("[[registry]]\nprefix = \"quay.io\"\nlocation = \"mirror.io/quay\"\n"), 0o600))
+
+	cmd := NewRootCmd()
+	cmd.Run = func(*cobra.Command, []string) {}
+	cmd.SetArgs([]string{"--registries-config", config})
+
+	assert.NoError(t, cmd.Execute())
+
+	refs, err := oci.RegistryEndpoints("quay.io/org/image:tag")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"mirror.io/quay/org/image:tag"}, refs)
+}
@@ -43,6 +43,7 @@ import (
 	"github.com/conforma/cli/internal/policy"
 	"github.com/conforma/cli/internal/policy/source"
 	regooci "github.com/conforma/cli/internal/rego/oci"
+	regosbom "github.com/conforma/cli/internal/rego/sbom"
 	"github.com/conforma/cli/internal/utils"
 	"github.com/conforma/cli/internal/utils/oci"
 	validate_utils "github.com/conforma/cli/internal/validate"
@@ -104,6 +105,13 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 
 			  ec validate image --images '{"components":[{"containerImage":"<image url>"}]}'
 
+			Validate an image stored in an OCI image layout directory, or in a docker archive,
+			instead of a registry. Signatures and attestations are looked up in the same source:
+
+			  ec validate image --image oci-layout://path/to/layout@sha256:<digest>
+
+			  ec validate image --image docker-archive:path/to/image.tar
+
 			Use a different public key than the one from the EnterpriseContractPolicy resource:
 
 			  ec validate image --image registry/name:tag --public-key <path/to/public/key>
@@ -201,6 +209,16 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 			}
 			data.policyConfiguration = policyConfiguration
 
+			if data.policyLock != "" {
+				lock, err := source.ReadLock(ctx, data.policyLock)
+				if err != nil {
+					allErrors = errors.Join(allErrors, err)
+					return
+				}
+				ctx = source.WithLock(ctx, lock)
+				cmd.SetContext(ctx)
+			}
+
 			policyOptions := policy.Options{
 				EffectiveTime: data.effectiveTime,
 				Identity: cosign.Identity{
@@ -292,6 +310,9 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 				if data.attestationFormat == "predicate" && data.vsaSigningKey != "" {
 					log.Warn("--vsa-signing-key is ignored for --attestation-format=predicate")
 				}
+				if !slices.Contains(vsa.SupportedFormats, data.vsaFormat) {
+					allErrors = errors.Join(allErrors, fmt.Errorf("invalid --vsa-format: %s (valid: %s)", data.vsaFormat, strings.Join(vsa.SupportedFormats, ", ")))
+				}
 			}
 
 			return
@@ -320,7 +341,8 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 				var c evaluator.Evaluator
 				var err error
 				if utils.IsOpaEnabled() {
-					c, err = newOPAEvaluator()
+					c, err = newOPAEvaluator(
+						cmd.Context(), policySources, data.policy, sourceGroup, data.filterType)
 				} else {
 					// Use the unified filtering approach with the specified filter type
 					c, err = evaluator.NewConftestEvaluatorWithFilterType(
@@ -352,11 +374,12 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 						trace.Logf(ctx, "", "workerID=%d", id)
 					}
 
-					// Scope heavy OCI caches (blobs, image files) to this component's
-					// evaluation. Each component has unique image refs, so caching across
-					// components just accumulates dead data. When this iteration ends,
-					// the component-scoped cache is released for GC.
+					// Scope heavy OCI caches (blobs, image files) and the SBOM packages
+					// cache to this component's evaluation. Each component has unique image
+					// refs, so caching across components just accumulates dead data. When
+					// this iteration ends, the component-scoped cache is released for GC.
 					ctx = regooci.WithComponentCache(ctx)
+					ctx = regosbom.WithComponentCache(ctx)
 
 					log.Debugf("Worker %d got a component %q", id, comp.ContainerImage)
 
@@ -484,7 +507,11 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 		  * git reference (github.com/user/repo//default?ref=main), or
 		  * inline JSON ('{sources: {...}, identity: {...}}')")`))
 
-	cmd.Flags().StringVarP(&data.imageRef, "image", "i", data.imageRef, "OCI image reference")
+	cmd.Flags().StringVar(&data.policyLock, "policy-lock", data.policyLock, hd.Doc(`
+		Lock file created by "ec policy lock". The policy and data sources are
+		fetched at the revisions pinned in the lock file`))
+
+	cmd.Flags().StringVarP(&data.imageRef, "image", "i", data.imageRef, "OCI image reference, oci-layout://<path>[@<digest>] or docker-archive:<path>")
 
 	cmd.Flags().StringVarP(&data.publicKey, "public-key", "k", data.publicKey,
 		"path to the public key. Overrides publicKey from EnterpriseContractPolicy")
@@ -571,8 +598,9 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 
 	cmd.Flags().BoolVar(&data.vsaEnabled, "vsa", false, "Generate a Verification Summary Attestation (VSA) for each validated image.")
 	cmd.Flags().StringVar(&data.attestationFormat, "attestation-format", "dsse", "Attestation output format: dsse (signed envelope), predicate (raw JSON)")
+	cmd.Flags().StringVar(&data.vsaFormat, "vsa-format", vsa.FormatConforma, "VSA predicate format: conforma (Conforma verification summary), slsa (SLSA verification_summary/v1)")
 	cmd.Flags().StringVar(&data.vsaSigningKey, "vsa-signing-key", "", "Path to the private key for signing the VSA. Supports file paths and Kubernetes secret references (k8s://namespace/secret-name/key-field).")
-	cmd.Flags().StringSliceVar(&data.vsaUpload, "vsa-upload", nil, "Storage backends for VSA upload. Format: backend@url?param=value. Examples: rekor@https://rekor.sigstore.dev, local@./vsa-dir")
+	cmd.Flags().StringSliceVar(&data.vsaUpload, "vsa-upload", nil, "Storage backends for VSA upload. Format: backend@url?param=value. Examples: rekor@https://rekor.sigstore.dev, local@./vsa-dir, oci@quay.io/org/vsas, s3@https://s3.amazonaws.com/bucket?prefix=policy")
 	cmd.Flags().DurationVar(&data.vsaExpiration, "vsa-expiration", data.vsaExpiration, "Expiration threshold for existing VSAs. If a valid VSA exists and is newer than this threshold, validation will be skipped. (default 168h)")
 	cmd.Flags().StringVar(&data.attestationOutputDir, "attestation-output-dir", "", "Directory for attestation output files. Defaults to a temp directory under /tmp. Must be under /tmp or the current working directory.")
 
@@ -640,6 +668,7 @@ type imageData struct {
 	outputFile                  string
 	policy                      policy.Policy
 	policyConfiguration         string
+	policyLock                  string
 	policySource                string
 	publicKey                   string
 	rekorURL                    string
@@ -653,6 +682,7 @@ type imageData struct {
 	workers                     int
 	vsaEnabled                  bool
 	attestationFormat           string
+	vsaFormat                   string
 	vsaSigningKey               string
 	vsaUpload                   []string
 	vsaExpiration               time.Duration
@@ -669,7 +699,7 @@ func (data *imageData) generateVSAsDSSE(cmd *cobra.Command, report applicationsn
 	}
 
 	// Create VSA service with output directory
-	vsaService := vsa.NewServiceWithFS(signer, utils.FS(cmd.Context()), data.policySource, data.policy, outputDir)
+	vsaService := vsa.NewServiceWithFS(signer, utils.FS(cmd.Context()), data.policySource, data.policy, outputDir).WithFormat(data.vsaFormat)
 
 	// Define helper functions for getting git URL and digest
 	getGitURL := func(comp applicationsnapshot.Component) string {
@@ -680,7 +710,17 @@ func (data *imageData) generateVSAsDSSE(cmd *cobra.Command, report applicationsn
 	}
 
 	getDigest := func(comp applicationsnapshot.Component) (string, error) {
-		imageRef, err := name.ParseReference(comp.ContainerImage)
+		ref := comp.ContainerImage
+		// images from local sources are reported by the local source reference
+		if oci.IsLocalSource(ref) {
+			resolved, err := oci.ResolveLocalSource(ref)
+			if err != nil {
+				return "", fmt.Errorf("failed to resolve local source %s: %v", ref, err)
+			}
+			ref = resolved
+		}
+
+		imageRef, err := name.ParseReference(ref)
 		if err != nil {
 			return "", fmt.Errorf("failed to parse image reference %s: %v", comp.ContainerImage, err)
 		}
@@ -749,7 +789,12 @@ func (data *imageData) generateVSAsPredicates(cmd *cobra.Command, report applica
 			FilePerm:      0o600,
 		}
 
-		predicatePath, err := vsa.GenerateAndWritePredicate(cmd.Context(), generator, writer)
+		generate := vsa.GenerateAndWritePredicate
+		if data.vsaFormat == vsa.FormatSLSA {
+			generate = vsa.GenerateAndWriteSLSAPredicate
+		}
+
+		predicatePath, err := generate(cmd.Context(), generator, writer)
 		if err != nil {
 			log.Errorf("Failed to generate predicate for %s: %v", comp.ContainerImage, err)
 			continue
@@ -1761,6 +1761,87 @@ func TestValidateImageCommand_VSAFormat_InvalidFormat(t *testing.T) {
 	assert.Contains(t, err.Error(), "(valid: dsse, predicate)")
 }
 
+func TestValidateImageCommand_VSAFormat_SLSA(t *testing.T) {
+	// Test that --vsa-format=slsa generates SLSA verification summary predicates
+	validateImageCmd := validateImageCmd(happyValidator())
+	cmd := setUpCobra(validateImageCmd)
+
+	fs := afero.NewMemMapFs()
+	ctx := utils.WithFS(context.Background(), fs)
+
+	client := fake.FakeClient{}
+	commonMockClient(&client)
+
+	ctx = oci.WithClient(ctx, &client)
+	cmd.SetContext(ctx)
+
+	cmd.SetArgs([]string{
+		"validate", "image",
+		"--image", "registry/image:tag",
+		"--policy", fmt.Sprintf(`{"publicKey": %s}`, utils.TestPublicKeyJSON),
+		"--vsa",
+		"--attestation-format", "predicate",
+		"--vsa-format", "slsa",
+		"--attestation-output-dir", "/tmp/vsa-slsa",
+	})
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	utils.SetTestRekorPublicKey(t)
+
+	err := cmd.Execute()
+	require.NoError(t, err)
+
+	files, err := afero.ReadDir(fs, "/tmp/vsa-slsa")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := afero.ReadFile(fs, filepath.Join("/tmp/vsa-slsa", files[0].Name()))
+	require.NoError(t, err)
+
+	var predicate vsa.SLSAPredicate
+	require.NoError(t, json.Unmarshal(data, &predicate))
+	assert.Equal(t, vsa.SLSAVerifierID, predicate.Verifier.ID)
+	assert.Equal(t, "registry/image:tag", predicate.ResourceURI)
+	assert.Equal(t, "PASSED", predicate.VerificationResult)
+}
+
+func TestValidateImageCommand_VSAFormat_InvalidVSAFormat(t *testing.T) {
+	// Test that validation rejects invalid VSA predicate formats
+	validateImageCmd := validateImageCmd(happyValidator())
+	cmd := setUpCobra(validateImageCmd)
+
+	fs := afero.NewMemMapFs()
+	ctx := utils.WithFS(context.Background(), fs)
+
+	client := fake.FakeClient{}
+	commonMockClient(&client)
+
+	ctx = oci.WithClient(ctx, &client)
+	cmd.SetContext(ctx)
+
+	cmd.SetArgs([]string{
+		"validate", "image",
+		"--image", "registry/image:tag",
+		"--policy", fmt.Sprintf(`{"publicKey": %s}`, utils.TestPublicKeyJSON),
+		"--vsa",
+		"--attestation-format", "predicate",
+		"--vsa-format", "in-toto",
+	})
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+
+	utils.SetTestRekorPublicKey(t)
+
+	err := cmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid --vsa-format: in-toto (valid: conforma, slsa)")
+}
+
 func TestValidateImageCommand_VSAFormat_DSSE_RequiresSigningKey(t *testing.T) {
 	// Test that --attestation-format=dsse requires --vsa-signing-key
 	validateImageCmd := validateImageCmd(happyValidator())
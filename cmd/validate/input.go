@@ -32,6 +32,7 @@ import (
 	"github.com/conforma/cli/internal/input"
 	"github.com/conforma/cli/internal/output"
 	"github.com/conforma/cli/internal/policy"
+	"github.com/conforma/cli/internal/policy/source"
 	"github.com/conforma/cli/internal/utils"
 	validate_utils "github.com/conforma/cli/internal/validate"
 )
@@ -50,6 +51,7 @@ func validateInputCmd(validate InputValidationFunc) *cobra.Command {
 		output              []string
 		policy              policy.Policy
 		policyConfiguration string
+		policyLock          string
 		strict              bool
 		workers             int
 	}{
@@ -99,6 +101,16 @@ func validateInputCmd(validate InputValidationFunc) *cobra.Command {
 			}
 			data.policyConfiguration = policyConfiguration
 
+			if data.policyLock != "" {
+				lock, err := source.ReadLock(ctx, data.policyLock)
+				if err != nil {
+					allErrors = errors.Join(allErrors, err)
+					return
+				}
+				ctx = source.WithLock(ctx, lock)
+				cmd.SetContext(ctx)
+			}
+
 			if p, err := policy.NewInputPolicy(cmd.Context(), data.policyConfiguration, data.effectiveTime); err != nil {
 				allErrors = errors.Join(allErrors, err)
 			} else {
@@ -239,7 +251,11 @@ func validateInputCmd(validate InputValidationFunc) *cobra.Command {
 		* git reference (github.com/user/repo//default?ref=main), or
 		* inline JSON ('{sources: {...}}')")`))
 
-	validOutputFormats := []string{input.JSON, input.YAML, input.Text, input.Summary}
+	cmd.Flags().StringVar(&data.policyLock, "policy-lock", data.policyLock, hd.Doc(`
+		Lock file created by "ec policy lock". The policy and data sources are
+		fetched at the revisions pinned in the lock file`))
+
+	validOutputFormats := []string{input.JSON, input.YAML, input.Text, input.Summary, input.SARIF}
 	cmd.Flags().StringSliceVarP(&data.output, "output", "o", data.output, hd.Doc(`
 		Write output to a file in a specific format, e.g. yaml=/tmp/output.yaml. Use empty string
 		path for stdout, e.g. yaml. May be used multiple times. Possible formats are:
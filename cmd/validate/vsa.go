@@ -82,6 +82,7 @@ var reasonCodeToDisplay = map[string]string{
 	"policy_mismatch":  "policy mismatch",
 	"predicate_failed": "predicate failed",
 	"no_vsa":           "no vsa",
+	"revoked":          "revoked",
 	"expired":          "expired",
 	"retrieval_failed": "retrieval failed",
 }
@@ -215,7 +216,7 @@ func addVSAFlags(cmd *cobra.Command, data *validateVSAData) {
 	cmd.Flags().StringVarP(&data.policyConfig, "policy", "p", "", "Policy configuration")
 
 	// VSA retrieval options
-	cmd.Flags().StringSliceVar(&data.vsaRetrieval, "vsa-retrieval", []string{}, "VSA retrieval backends (rekor@, file@)")
+	cmd.Flags().StringSliceVar(&data.vsaRetrieval, "vsa-retrieval", []string{}, "VSA retrieval backends (rekor@, file@, oci@, http@, s3@)")
 
 	// Policy comparison options
 	cmd.Flags().StringVar(&data.effectiveTime, "effective-time", "now", "Effective time for comparison")
@@ -750,6 +751,9 @@ func extractReasonFromMessage(message string) string {
 	if strings.Contains(message, "No VSA found") || strings.Contains(message, "no VSA") {
 		return "no vsa"
 	}
+	if strings.Contains(message, "revoked") {
+		return "revoked"
+	}
 	if strings.Contains(message, "expired") {
 		return "expired"
 	}
@@ -0,0 +1,184 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Define the `ec vsa list` command
+package vsa
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	hd "github.com/MakeNowJust/heredoc"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/conforma/cli/internal/utils/oci"
+	"github.com/conforma/cli/internal/validate/vsa"
+)
+
+func listCmd() *cobra.Command {
+	var (
+		vsaRetrieval []string
+		publicKey    string
+		since        string
+		until        string
+		status       string
+		outputFormat string
+	)
+
+	validFormats := []string{"text", "json", "yaml"}
+
+	cmd := &cobra.Command{
+		Use:   "list <image>",
+		Short: "List the VSAs recorded for an image",
+
+		Long: hd.Doc(`
+			List the Verification Summary Attestations (VSAs) recorded for an image.
+
+			Each of the retrieval backends is queried for the VSAs with a subject
+			matching the digest of the image, image references using a tag are resolved
+			to the digest. The VSAs are listed newest first, along with the digest of
+			the VSA payload, the status of the validation, the verifier, the digest of
+			the policy used, the validity of the VSA signature and whether the VSA has
+			been revoked. The signature is verified only if a public key is provided,
+			otherwise it is reported as unverified. Revocations are honoured only if
+			they are signed with the provided public key, without a public key they
+			are ignored.
+
+			The backends are provided in the same format as the --vsa-upload flag of
+			the 'ec validate image' command. In addition to the rekor, oci and s3
+			backends, the local and file backends list the VSAs saved in a directory.
+		`),
+
+		Example: hd.Doc(`
+			List the VSAs of an image recorded in Rekor:
+
+			  ec vsa list registry/name@sha256:<digest>
+
+			List the passed VSAs of the last week saved locally, verifying the
+			signatures:
+
+			  ec vsa list registry/name:tag --vsa-retrieval local@./vsa-dir \
+			    --public-key cosign.pub --since 168h --status passed
+
+			List the VSAs recorded in Rekor and in an S3 bucket in JSON format:
+
+			  ec vsa list registry/name@sha256:<digest> --vsa-retrieval rekor \
+			    --vsa-retrieval s3@https://s3.amazonaws.com/bucket?prefix=policy -o json
+		`),
+
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(validFormats, outputFormat) {
+				return fmt.Errorf("invalid value for --output '%s'. accepted values: %s", outputFormat, strings.Join(validFormats, ", "))
+			}
+
+			ctx := cmd.Context()
+			now := time.Now()
+
+			opts := vsa.ListOptions{Status: status, PublicKey: publicKey}
+			var err error
+			if opts.Since, err = parseTime(since, now); err != nil {
+				return fmt.Errorf("invalid value for --since: %w", err)
+			}
+			if opts.Until, err = parseTime(until, now); err != nil {
+				return fmt.Errorf("invalid value for --until: %w", err)
+			}
+
+			ref, err := name.ParseReference(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid image reference %q: %w", args[0], err)
+			}
+
+			image := ref.String()
+			if _, ok := ref.(name.Digest); !ok {
+				digest, err := oci.NewClient(ctx).ResolveDigest(ref)
+				if err != nil {
+					return fmt.Errorf("failed to resolve the digest of %s: %w", ref, err)
+				}
+				image = ref.Context().Digest(digest).String()
+			}
+
+			records, err := vsa.ListVSARecords(ctx, vsaRetrieval, image, opts)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			switch outputFormat {
+			case "json":
+				return json.NewEncoder(out).Encode(records)
+			case "yaml":
+				data, err := yaml.Marshal(records)
+				if err != nil {
+					return err
+				}
+				_, err = out.Write(data)
+				return err
+			default:
+				return outputText(out, records)
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&vsaRetrieval, "vsa-retrieval", []string{"rekor"}, "VSA retrieval backends (rekor@, local@, file@, oci@, s3@)")
+	cmd.Flags().StringVar(&publicKey, "public-key", "", "Public key to verify the VSA signatures")
+	cmd.Flags().StringVar(&since, "since", "", "List only the VSAs created since the given time, as RFC3339 timestamp or duration before now (e.g. 24h)")
+	cmd.Flags().StringVar(&until, "until", "", "List only the VSAs created until the given time, as RFC3339 timestamp or duration before now (e.g. 24h)")
+	cmd.Flags().StringVar(&status, "status", "", "List only the VSAs with the given status (passed, failed)")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", fmt.Sprintf("output format. one of: %s", strings.Join(validFormats, ", ")))
+
+	return cmd
+}
+
+// parseTime parses the value as RFC3339 timestamp, or as duration before now
+func parseTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a RFC3339 timestamp nor a duration", value)
+	}
+
+	return now.Add(-d), nil
+}
+
+func outputText(out io.Writer, records []vsa.VSARecord) error {
+	if len(records) == 0 {
+		_, err := fmt.Fprintln(out, "No VSAs found")
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DIGEST\tTIMESTAMP\tSTATUS\tVERIFIER\tPOLICY DIGEST\tSIGNATURE\tREVOKED\tSOURCE")
+	for _, r := range records {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%t\t%s\n", r.Digest, r.Timestamp.Format(time.RFC3339), r.Status, r.Verifier, r.PolicyDigest, r.Signature, r.Revoked, r.Source)
+	}
+
+	return w.Flush()
+}
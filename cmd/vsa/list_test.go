@@ -0,0 +1,128 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package vsa
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conforma/cli/cmd/root"
+	"github.com/conforma/cli/internal/validate/vsa"
+)
+
+const testDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000001"
+
+func setUpCobra(command *cobra.Command) *cobra.Command {
+	vsaCmd := NewVSACmd()
+	vsaCmd.AddCommand(command)
+	cmd := root.NewRootCmd()
+	cmd.AddCommand(vsaCmd)
+	return cmd
+}
+
+func writeVSA(t *testing.T, dir, file string, timestamp time.Time, status string) {
+	t.Helper()
+
+	statement, err := json.Marshal(map[string]any{
+		"_type":         "https://in-toto.io/Statement/v0.1",
+		"predicateType": vsa.PredicateType,
+		"subject": []vsa.Subject{
+			{Name: "registry.io/org/image", Digest: map[string]string{"sha256": strings.TrimPrefix(testDigest, "sha256:")}},
+		},
+		"predicate": vsa.Predicate{
+			Timestamp: timestamp.UTC().Format(time.RFC3339),
+			Status:    status,
+			Verifier:  "ec-cli",
+		},
+	})
+	require.NoError(t, err)
+
+	envelope, err := json.Marshal(ssldsse.Envelope{
+		PayloadType: "application/vnd.in-toto+json",
+		Payload:     base64.StdEncoding.EncodeToString(statement),
+		Signatures:  []ssldsse.Signature{{KeyID: "key", Sig: "c2lnbmF0dXJl"}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, file), envelope, 0600))
+}
+
+func TestListCmd(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().UTC().Truncate(time.Second)
+	writeVSA(t, dir, "vsa-1.json", now.Add(-48*time.Hour), "passed")
+	writeVSA(t, dir, "vsa-2.json", now.Add(-time.Hour), "failed")
+
+	run := func(args ...string) (string, error) {
+		cmd := setUpCobra(listCmd())
+		cmd.SetContext(context.Background())
+		out := bytes.Buffer{}
+		cmd.SetOut(&out)
+		cmd.SetArgs(append([]string{"vsa", "list", "registry.io/org/image@" + testDigest, "--vsa-retrieval", "local@" + dir}, args...))
+
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("-o", "json")
+	require.NoError(t, err)
+
+	var records []vsa.VSARecord
+	require.NoError(t, json.Unmarshal([]byte(out), &records))
+	require.Len(t, records, 2)
+	assert.Equal(t, now.Add(-time.Hour), records[0].Timestamp)
+	assert.Equal(t, "failed", records[0].Status)
+	assert.Equal(t, vsa.SignatureUnverified, records[0].Signature)
+	assert.Equal(t, "local@"+dir, records[0].Source)
+	assert.Equal(t, now.Add(-48*time.Hour), records[1].Timestamp)
+
+	out, err = run("--since", "24h")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+	assert.Regexp(t, `^DIGEST\s+TIMESTAMP\s+STATUS\s+VERIFIER\s+POLICY DIGEST\s+SIGNATURE\s+REVOKED\s+SOURCE$`, lines[0])
+	assert.Regexp(t, `^sha256:[0-9a-f]{64}\s+\S+\s+failed\s+ec-cli\s+sha256:[0-9a-f]{64}\s+unverified\s+false\s+local@`, lines[1])
+
+	out, err = run("--status", "passed", "--until", now.Add(-24*time.Hour).Format(time.RFC3339), "-o", "yaml")
+	require.NoError(t, err)
+	assert.Contains(t, out, "status: passed")
+	assert.NotContains(t, out, "status: failed")
+
+	out, err = run("--status", "passed", "--since", "1h")
+	require.NoError(t, err)
+	assert.Equal(t, "No VSAs found\n", out)
+
+	_, err = run("-o", "xml")
+	assert.EqualError(t, err, "invalid value for --output 'xml'. accepted values: text, json, yaml")
+
+	_, err = run("--since", "yesterday")
+	assert.EqualError(t, err, `invalid value for --since: "yesterday" is neither a RFC3339 timestamp nor a duration`)
+}
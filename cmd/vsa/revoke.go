@@ -0,0 +1,116 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Define the `ec vsa revoke` command
+package vsa
+
+import (
+	"fmt"
+
+	hd "github.com/MakeNowJust/heredoc"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+
+	"github.com/conforma/cli/internal/utils"
+	"github.com/conforma/cli/internal/utils/oci"
+	"github.com/conforma/cli/internal/validate/vsa"
+)
+
+func revokeCmd() *cobra.Command {
+	var (
+		vsaDigest  string
+		reason     string
+		signingKey string
+		vsaUpload  []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "revoke <image>",
+		Short: "Revoke the VSAs recorded for an image",
+
+		Long: hd.Doc(`
+			Revoke the Verification Summary Attestations (VSAs) recorded for an image.
+
+			A signed revocation record is created for the image and uploaded to the
+			storage backends, only the local and rekor backends can store revocations.
+			The revocation applies to the VSA with the given digest, as reported by the
+			'ec vsa list' command, or if no digest is provided to all the VSAs of the
+			image created up to now.
+
+			The 'ec validate vsa' command, and the 'ec validate image' command when
+			checking for existing VSAs, treat a revoked VSA as if there was no VSA for
+			the image. Only the revocations signed with the key that verifies the VSA
+			signature are honoured, so the revocation must be signed with the key used
+			to sign the VSAs. Revocations are ignored when the VSA signature is not
+			verified.
+		`),
+
+		Example: hd.Doc(`
+			Revoke a single VSA recorded in Rekor:
+
+			  ec vsa revoke registry/name@sha256:<digest> --vsa sha256:<vsa digest> \
+			    --reason "policy exception withdrawn" --vsa-signing-key cosign.key \
+			    --vsa-upload rekor@https://rekor.sigstore.dev
+
+			Revoke all VSAs of an image saved locally:
+
+			  ec vsa revoke registry/name:tag --vsa-signing-key cosign.key \
+			    --vsa-upload local@./vsa-dir
+		`),
+
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			ref, err := name.ParseReference(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid image reference %q: %w", args[0], err)
+			}
+
+			digest := ref.Identifier()
+			if _, ok := ref.(name.Digest); !ok {
+				if digest, err = oci.NewClient(ctx).ResolveDigest(ref); err != nil {
+					return fmt.Errorf("failed to resolve the digest of %s: %w", ref, err)
+				}
+			}
+
+			signer, err := vsa.NewSigner(ctx, signingKey, utils.FS(ctx))
+			if err != nil {
+				return err
+			}
+
+			envelope, err := vsa.NewRevocation(vsaDigest, reason).Sign(ctx, ref.Context().String(), digest, signer)
+			if err != nil {
+				return err
+			}
+
+			return vsa.UploadRevocation(ctx, envelope, vsaUpload, signer)
+		},
+	}
+
+	cmd.Flags().StringVar(&vsaDigest, "vsa", "", "Digest of the VSA to revoke, all VSAs of the image are revoked if not provided")
+	cmd.Flags().StringVar(&reason, "reason", "", "Reason for the revocation")
+	cmd.Flags().StringVar(&signingKey, "vsa-signing-key", "", "Path to the private key for signing the revocation. Supports file paths and Kubernetes secret references (k8s://namespace/secret-name/key-field).")
+	cmd.Flags().StringSliceVar(&vsaUpload, "vsa-upload", nil, "Storage backends for the revocation upload. Format: backend@url?param=value. Examples: rekor@https://rekor.sigstore.dev, local@./vsa-dir")
+
+	for _, f := range []string{"vsa-signing-key", "vsa-upload"} {
+		if err := cmd.MarkFlagRequired(f); err != nil {
+			panic(err)
+		}
+	}
+
+	return cmd
+}
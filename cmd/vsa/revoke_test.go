@@ -0,0 +1,95 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package vsa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sigstore/cosign/v3/pkg/cosign"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conforma/cli/internal/validate/vsa"
+)
+
+func TestRevokeCmd(t *testing.T) {
+	t.Setenv("COSIGN_PASSWORD", "")
+
+	keys, err := cosign.GenerateKeyPair(func(bool) ([]byte, error) { return []byte{}, nil })
+	require.NoError(t, err)
+	key := filepath.Join(t.TempDir(), "cosign.key")
+	require.NoError(t, os.WriteFile(key, keys.PrivateBytes, 0600))
+	publicKey := filepath.Join(t.TempDir(), "cosign.pub")
+	require.NoError(t, os.WriteFile(publicKey, keys.PublicBytes, 0600))
+
+	dir := t.TempDir()
+	writeVSA(t, dir, "vsa.json", time.Now().Add(-time.Hour), "passed")
+
+	run := func(args ...string) (string, error) {
+		cmd := setUpCobra(revokeCmd())
+		cmd.SetContext(context.Background())
+		out := bytes.Buffer{}
+		cmd.SetOut(&out)
+		cmd.SetArgs(args)
+
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	_, err = run("vsa", "revoke", "registry.io/org/image@"+testDigest, "--reason", "withdrawn", "--vsa-signing-key", key, "--vsa-upload", "local@"+dir)
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	list := func(args ...string) []vsa.VSARecord {
+		cmd := setUpCobra(listCmd())
+		cmd.SetContext(context.Background())
+		out := bytes.Buffer{}
+		cmd.SetOut(&out)
+		cmd.SetArgs(append([]string{"vsa", "list", "registry.io/org/image@" + testDigest, "--vsa-retrieval", "local@" + dir, "-o", "json"}, args...))
+		require.NoError(t, cmd.Execute())
+
+		var records []vsa.VSARecord
+		require.NoError(t, json.Unmarshal(out.Bytes(), &records))
+		return records
+	}
+
+	records := list("--public-key", publicKey)
+	require.Len(t, records, 1)
+	assert.True(t, records[0].Revoked)
+
+	// the revocation cannot be verified without the public key
+	records = list()
+	require.Len(t, records, 1)
+	assert.False(t, records[0].Revoked)
+
+	_, err = run("vsa", "revoke", "registry.io/org/image@"+testDigest, "--vsa-signing-key", key, "--vsa-upload", "oci@registry.io/org/vsas")
+	assert.EqualError(t, err, "the oci backend cannot store VSA revocations, supported backends: local, rekor")
+
+	_, err = run("vsa", "revoke", "registry.io/org/image@"+testDigest, "--vsa", "latest", "--vsa-signing-key", key, "--vsa-upload", "local@"+dir)
+	assert.EqualError(t, err, "invalid VSA digest: latest")
+}
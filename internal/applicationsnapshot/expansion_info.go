@@ -100,3 +100,31 @@ func (e *ExpansionInfo) GetParentByChild(child string) (string, bool) {
 	parent, ok := e.parentByChild[child]
 	return parent, ok
 }
+
+// mapReferences returns a copy of the expansion info with all image references
+// mapped by the given function
+func (e *ExpansionInfo) mapReferences(f func(string) string) *ExpansionInfo {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	mapped := NewExpansionInfo()
+	for index, children := range e.childrenByIndex {
+		mappedChildren := make([]string, 0, len(children))
+		for _, child := range children {
+			mappedChildren = append(mappedChildren, f(child))
+		}
+		mapped.childrenByIndex[f(index)] = mappedChildren
+	}
+	for child, parent := range e.parentByChild {
+		mapped.parentByChild[f(child)] = f(parent)
+	}
+	for key, value := range e.indexAliases {
+		mapped.indexAliases[f(key)] = f(value)
+	}
+
+	return mapped
+}
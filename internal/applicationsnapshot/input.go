@@ -173,6 +173,10 @@ func DetermineInputSpec(ctx context.Context, input Input) (*app.SnapshotSpec, *E
 		log.Debug("No application snapshot available")
 		return nil, nil, errors.New("neither Snapshot nor image reference provided to validate")
 	}
+	if err := resolveLocalSources(&snapshot.SnapshotSpec); err != nil {
+		return nil, nil, err
+	}
+
 	exp := expandImageIndex(ctx, &snapshot.SnapshotSpec)
 
 	// Store expansion info in the snapshot for later use
@@ -182,6 +186,27 @@ func DetermineInputSpec(ctx context.Context, input Input) (*app.SnapshotSpec, *E
 	return &snapshot.SnapshotSpec, exp, nil
 }
 
+// resolveLocalSources replaces the references to images in local sources, i.e.
+// OCI image layouts and docker archives, with the references to those images
+// as served by the in-process registry.
+func resolveLocalSources(snap *app.SnapshotSpec) error {
+	for i, c := range snap.Components {
+		if !oci.IsLocalSource(c.ContainerImage) {
+			continue
+		}
+
+		ref, err := oci.ResolveLocalSource(c.ContainerImage)
+		if err != nil {
+			return fmt.Errorf("unable to load container image %s: %w", c.ContainerImage, err)
+		}
+
+		log.Debugf("Using %s for container image %s", ref, c.ContainerImage)
+		snap.Components[i].ContainerImage = ref
+	}
+
+	return nil
+}
+
 func readSnapshotSource(input []byte) (app.SnapshotSpec, error) {
 	// Define a temporary struct to capture the wrapped spec so we
 	// can read snapshot data correctly from a cluster record
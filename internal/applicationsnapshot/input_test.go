@@ -23,12 +23,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	gcrfake "github.com/google/go-containerregistry/pkg/v1/fake"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	app "github.com/konflux-ci/application-api/api/v1alpha1"
 	log "github.com/sirupsen/logrus"
@@ -469,3 +472,46 @@ func TestExpandImageImage_Errors(t *testing.T) {
 		log.StandardLogger().ReplaceHooks(make(log.LevelHooks))
 	}
 }
+
+func TestResolveLocalSources(t *testing.T) {
+	img, err := random.Image(256, 1)
+	assert.NoError(t, err)
+	digest, err := img.Digest()
+	assert.NoError(t, err)
+
+	archive := filepath.Join(t.TempDir(), "image.tar")
+	assert.NoError(t, tarball.WriteToFile(archive, name.MustParseReference("registry.io/repository/image:tag"), img))
+
+	snapshot := &app.SnapshotSpec{
+		Components: []app.SnapshotComponent{
+			{Name: "remote", ContainerImage: "registry.io/repository/image:tag"},
+			{Name: "local", ContainerImage: "docker-archive:" + archive},
+		},
+	}
+
+	assert.NoError(t, resolveLocalSources(snapshot))
+	assert.Equal(t, "registry.io/repository/image:tag", snapshot.Components[0].ContainerImage)
+	assert.True(t, strings.HasPrefix(snapshot.Components[1].ContainerImage, oci.LocalRegistry+"/"))
+	assert.True(t, strings.HasSuffix(snapshot.Components[1].ContainerImage, "@"+digest.String()))
+
+	// reported by the reference to the local source
+	expansion := NewExpansionInfo()
+	expansion.SetIndexAlias(snapshot.Components[1].ContainerImage, snapshot.Components[1].ContainerImage)
+	report, err := NewReport("snappy", []Component{
+		{SnapshotComponent: snapshot.Components[0]},
+		{SnapshotComponent: snapshot.Components[1]},
+	}, createTestPolicy(t, context.Background()), nil, true, true, true, expansion)
+	assert.NoError(t, err)
+	assert.Equal(t, "registry.io/repository/image:tag", report.Components[0].ContainerImage)
+	assert.Equal(t, "docker-archive:"+archive, report.Components[1].ContainerImage)
+	alias, ok := report.Expansion.GetIndexAlias("docker-archive:" + archive)
+	assert.True(t, ok)
+	assert.Equal(t, "docker-archive:"+archive, alias)
+
+	missing := &app.SnapshotSpec{
+		Components: []app.SnapshotComponent{
+			{Name: "missing", ContainerImage: "docker-archive:" + filepath.Join(t.TempDir(), "missing.tar")},
+		},
+	}
+	assert.ErrorContains(t, resolveLocalSources(missing), "unable to load container image docker-archive:")
+}
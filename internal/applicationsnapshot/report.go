@@ -33,8 +33,10 @@ import (
 	"github.com/conforma/cli/internal/evaluator"
 	"github.com/conforma/cli/internal/format"
 	"github.com/conforma/cli/internal/policy"
+	"github.com/conforma/cli/internal/report/sarif"
 	"github.com/conforma/cli/internal/signature"
 	"github.com/conforma/cli/internal/utils"
+	"github.com/conforma/cli/internal/utils/oci"
 	"github.com/conforma/cli/internal/version"
 )
 
@@ -110,6 +112,8 @@ const (
 	Attestation     = "attestation"
 	PolicyInput     = "policy-input"
 	VSA             = "vsa"
+	SARIF           = "sarif"
+	HTML            = "html"
 	// Deprecated old version of appstudio. Remove some day.
 	HACBS = "hacbs"
 )
@@ -125,6 +129,8 @@ var OutputFormats = []string{
 	Attestation,
 	PolicyInput,
 	VSA,
+	SARIF,
+	HTML,
 }
 
 // WriteReport returns a new instance of Report representing the state of
@@ -147,12 +153,20 @@ func NewReport(snapshot string, components []Component, policy policy.Policy, po
 
 	// TODO: Add some keyless information to the report.
 
+	// images from local sources are reported by the references to the local
+	// sources, not to the in-process registry serving them
+	reported := make([]Component, len(components))
+	for i, c := range components {
+		c.ContainerImage = oci.OriginalReference(c.ContainerImage)
+		reported[i] = c
+	}
+
 	info, _ := version.ComputeInfo()
 
 	return Report{
 		Snapshot:           snapshot,
 		Success:            success,
-		Components:         components,
+		Components:         reported,
 		created:            time.Now().UTC(),
 		Key:                string(key),
 		Policy:             policy.Spec(),
@@ -162,7 +176,7 @@ func NewReport(snapshot string, components []Component, policy policy.Policy, po
 		ShowSuccesses:      showSuccesses,
 		ShowWarnings:       showWarnings,
 		ShowPolicyDocsLink: showPolicyDocsLink,
-		Expansion:          expansion,
+		Expansion:          expansion.mapReferences(oci.OriginalReference),
 	}, nil
 }
 
@@ -219,6 +233,10 @@ func (r *Report) toFormat(format string) (data []byte, err error) {
 		data = bytes.Join(r.PolicyInput, []byte("\n"))
 	case VSA:
 		data, err = r.toVSA()
+	case SARIF:
+		data, err = r.toSARIF()
+	case HTML:
+		data, err = generateHTMLReport(r)
 	default:
 		return nil, fmt.Errorf("%q is not a valid report format", format)
 	}
@@ -234,6 +252,20 @@ func (r *Report) toVSA() ([]byte, error) {
 	return json.Marshal(predicate)
 }
 
+// toSARIF returns the violations and warnings of the report in SARIF format.
+func (r *Report) toSARIF() ([]byte, error) {
+	artifacts := make([]sarif.Artifact, 0, len(r.Components))
+	for _, c := range r.Components {
+		artifacts = append(artifacts, sarif.Artifact{
+			URI:        c.ContainerImage,
+			Violations: c.Violations,
+			Warnings:   c.Warnings,
+		})
+	}
+
+	return sarif.Marshal(r.EcVersion, artifacts)
+}
+
 // toSummary returns a condensed version of the report.
 func (r *Report) toSummary() summary {
 	pr := summary{
@@ -321,23 +353,34 @@ func generateMarkdownSummary(r *Report) ([]byte, error) {
 	return markdownBuffer.Bytes(), nil
 }
 
-//go:embed templates/*.tmpl
+//go:embed templates/*.tmpl templates/html/*.tmpl
 var efs embed.FS
 
-func generateTextReport(r *Report) ([]byte, error) {
-	// Prepare some template input
-	input := struct {
-		Report     *Report
-		TestReport TestReport
-	}{
+// templateInput is the input given to the text and HTML report templates
+type templateInput struct {
+	Report     *Report
+	TestReport TestReport
+}
+
+func newTemplateInput(r *Report) templateInput {
+	return templateInput{
 		// This includes everything in the yaml/json output
 		Report: r,
 		// This has useful stuff we want to output, so let's reuse it
 		// even though this is not what it was originally designed for
 		TestReport: r.toAppstudioReport(),
 	}
+}
+
+func generateTextReport(r *Report) ([]byte, error) {
+	return utils.RenderFromTemplatesWithMain(newTemplateInput(r), "text_report.tmpl", efs)
+}
 
-	return utils.RenderFromTemplatesWithMain(input, "text_report.tmpl", efs)
+// generateHTMLReport renders the report as a single self-contained HTML
+// document. The templates are plain text templates, so every value taken from
+// the report needs to be escaped using the html function in the templates.
+func generateHTMLReport(r *Report) ([]byte, error) {
+	return utils.RenderFromTemplatesWithGlob(newTemplateInput(r), "html_report.tmpl", []string{"templates/html/*.tmpl"}, efs)
 }
 
 func writeMarkdownField(buffer *bytes.Buffer, name string, value any, icon string) {
@@ -30,6 +30,7 @@ import (
 
 	"github.com/gkampitakis/go-snaps/snaps"
 	app "github.com/konflux-ci/application-api/api/v1alpha1"
+	"github.com/owenrumney/go-sarif/v2/sarif"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -37,6 +38,7 @@ import (
 	"github.com/conforma/cli/internal/evaluator"
 	"github.com/conforma/cli/internal/format"
 	"github.com/conforma/cli/internal/policy"
+	"github.com/conforma/cli/internal/signature"
 	"github.com/conforma/cli/internal/utils"
 )
 
@@ -831,6 +833,58 @@ func Test_ReportPolicyInput(t *testing.T) {
 	matchesJSONLFile(t, fs, policyInput, "default")
 }
 
+func Test_ReportSARIF(t *testing.T) {
+	var snapshot app.SnapshotSpec
+	err := json.Unmarshal([]byte(testSnapshot), &snapshot)
+	require.NoError(t, err)
+
+	components := testComponentsFor(snapshot)
+	components[0].Violations[0].Metadata = map[string]any{
+		"code":  "release.pkg.rule",
+		"title": "Rule title",
+	}
+
+	ctx := context.Background()
+	report, err := NewReport("snappy", components, createTestPolicy(t, ctx), nil, true, true, true, nil)
+	require.NoError(t, err)
+
+	data, err := report.toFormat(SARIF)
+	require.NoError(t, err)
+
+	log, err := sarif.FromBytes(data)
+	require.NoError(t, err)
+	require.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+	assert.Equal(t, "Conforma", run.Tool.Driver.Name)
+	assert.Equal(t, "development", *run.Tool.Driver.Version)
+
+	rule, err := run.GetRuleById("release.pkg.rule")
+	require.NoError(t, err)
+	assert.Equal(t, "Rule title", *rule.ShortDescription.Text)
+	assert.Equal(t, "https://conforma.dev/docs/policy/packages/release_pkg.html#pkg__rule", *rule.HelpURI)
+
+	type result struct {
+		rule, level, message, uri string
+	}
+	results := make([]result, 0, len(run.Results))
+	for _, r := range run.Results {
+		results = append(results, result{
+			rule:    *r.RuleID,
+			level:   *r.Level,
+			message: *r.Message.Text,
+			uri:     *r.Locations[0].PhysicalLocation.ArtifactLocation.URI,
+		})
+	}
+
+	// successes are not reported
+	assert.Equal(t, []result{
+		{rule: "release.pkg.rule", level: "error", message: "violation1", uri: "quay.io/caf/spam@sha256:123…"},
+		{rule: "unknown", level: "warning", message: "warning1", uri: "quay.io/caf/spam@sha256:123…"},
+		{rule: "unknown", level: "error", message: "violation2", uri: "quay.io/caf/bacon@sha256:234…"},
+	}, results)
+}
+
 func Test_TextReport(t *testing.T) {
 	warnings := []evaluator.Result{
 		{
@@ -937,6 +991,134 @@ func Test_TextReport(t *testing.T) {
 	}
 }
 
+func Test_HTMLReport(t *testing.T) {
+	violations := []evaluator.Result{
+		{
+			Metadata: map[string]interface{}{
+				"code":         "violation-1",
+				"title":        "Violation 1 title",
+				"description":  "Violation 1 description",
+				"solution":     "Violation 1 solution",
+				"effective_on": "2022-01-01T00:00:00Z",
+				"term":         []any{"term-1", "term-2"},
+			},
+			Message: "Violation 1 message",
+		},
+	}
+	warnings := []evaluator.Result{
+		{
+			Metadata: map[string]interface{}{
+				"code": "warning-1",
+				"term": "term-1",
+			},
+			Message: "Warning 1 message",
+		},
+	}
+	successes := []evaluator.Result{
+		{
+			Metadata: map[string]interface{}{
+				"code":        "success-1",
+				"title":       "Success 1 title",
+				"description": "Success 1 description",
+				"solution":    "Success 1 solution",
+			},
+			Message: "Pass",
+		},
+	}
+	signatures := []signature.EntitySignature{
+		{
+			KeyID:       "key-1",
+			Certificate: "-----BEGIN CERTIFICATE-----",
+			Metadata: map[string]string{
+				"Issuer": "https://issuer.example",
+			},
+		},
+	}
+
+	cases := []struct {
+		name   string
+		report Report
+	}{
+		{"nothing", Report{}},
+		{"bunch", Report{
+			Snapshot:           "snappy",
+			ShowSuccesses:      true,
+			ShowWarnings:       true,
+			ShowPolicyDocsLink: true,
+			Components: []Component{
+				{
+					SnapshotComponent: app.SnapshotComponent{
+						Name:           "component-1",
+						ContainerImage: "registry.io/repository/component-1:tag",
+					},
+					Violations: violations,
+					Warnings:   warnings,
+					Signatures: signatures,
+					Attestations: []AttestationResult{
+						{
+							Type:               "https://in-toto.io/Statement/v0.1",
+							PredicateType:      "https://slsa.dev/provenance/v0.2",
+							PredicateBuildType: "https://tekton.dev/attestations/chains/pipelinerun@v2",
+							Signatures:         signatures,
+						},
+					},
+				},
+				{
+					SnapshotComponent: app.SnapshotComponent{
+						Name:           "component-2",
+						ContainerImage: "registry.io/repository/component-2:tag",
+					},
+					Successes:    successes,
+					SuccessCount: 1,
+					Success:      true,
+				},
+			},
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := c.report
+			output, err := r.toFormat(HTML)
+			require.NoError(t, err)
+
+			snaps.MatchSnapshot(t, string(output))
+		})
+	}
+}
+
+func Test_HTMLReportEscaping(t *testing.T) {
+	r := Report{
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{
+					Name: "<b>name</b>",
+				},
+				Violations: []evaluator.Result{
+					{
+						Metadata: map[string]interface{}{
+							"code":  "<code>",
+							"title": "<script>alert('title')</script>",
+						},
+						Message: "<img src=x onerror=alert(1)>",
+					},
+				},
+			},
+		},
+	}
+
+	output, err := generateHTMLReport(&r)
+	require.NoError(t, err)
+
+	html := string(output)
+	assert.NotContains(t, html, "<b>name</b>")
+	assert.NotContains(t, html, "<script>")
+	assert.NotContains(t, html, "<img")
+	assert.NotContains(t, html, "<code><code>")
+	assert.Contains(t, html, "&lt;b&gt;name&lt;/b&gt;")
+	assert.Contains(t, html, "&lt;img src=x onerror=alert(1)&gt;")
+}
+
 func matchesJSONLFile(t *testing.T, fs afero.Fs, expected [][]byte, filename string) {
 	f, err := fs.Open(filename)
 	require.NoError(t, err)
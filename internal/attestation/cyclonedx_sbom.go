@@ -0,0 +1,201 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package attestation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	PredicateCycloneDX = "https://cyclonedx.org/bom"
+)
+
+// minimum supported CycloneDX specification version, 1.5
+const (
+	cycloneDXMinMajor = 1
+	cycloneDXMinMinor = 5
+)
+
+// cycloneDXDocument holds the parts of the CycloneDX JSON BOM we're interested
+// in, see https://cyclonedx.org/docs/1.5/json/
+type cycloneDXDocument struct {
+	BOMFormat    string                `json:"bomFormat"`
+	SpecVersion  string                `json:"specVersion"`
+	Components   []cycloneDXComponent  `json:"components"`
+	Dependencies []cycloneDXDependency `json:"dependencies"`
+}
+
+type cycloneDXComponent struct {
+	BOMRef     string                   `json:"bom-ref"`
+	Type       string                   `json:"type"`
+	Name       string                   `json:"name"`
+	Version    string                   `json:"version"`
+	PURL       string                   `json:"purl"`
+	Licenses   []cycloneDXLicenseChoice `json:"licenses"`
+	Hashes     []cycloneDXHash          `json:"hashes"`
+	Components []cycloneDXComponent     `json:"components"`
+}
+
+type cycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+type cycloneDXLicenseChoice struct {
+	License *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"license"`
+	Expression string `json:"expression"`
+}
+
+// IsCycloneDXPredicateType returns true for the predicate types of CycloneDX
+// SBOM attestations, both the unversioned predicate type and the versioned
+// ones, e.g. https://cyclonedx.org/bom/v1.5
+func IsCycloneDXPredicateType(predicateType string) bool {
+	return predicateType == PredicateCycloneDX || strings.HasPrefix(predicateType, PredicateCycloneDX+"/v")
+}
+
+func parseCycloneDX(predicate []byte) (SBOM, error) {
+	var doc cycloneDXDocument
+	if err := json.Unmarshal(predicate, &doc); err != nil {
+		return SBOM{}, fmt.Errorf("malformed CycloneDX SBOM: %w", err)
+	}
+
+	if doc.BOMFormat != "CycloneDX" {
+		return SBOM{}, fmt.Errorf("malformed CycloneDX SBOM: unexpected bomFormat %q", doc.BOMFormat)
+	}
+
+	if doc.SpecVersion == "" {
+		return SBOM{}, errors.New("malformed CycloneDX SBOM: missing specVersion")
+	}
+
+	if supported, err := isSupportedCycloneDXVersion(doc.SpecVersion); err != nil {
+		return SBOM{}, fmt.Errorf("malformed CycloneDX SBOM: %w", err)
+	} else if !supported {
+		return SBOM{}, fmt.Errorf("%w CycloneDX version: %s", ErrUnsupportedSBOM, doc.SpecVersion)
+	}
+
+	packages, err := cycloneDXPackages([]SBOMPackage{}, doc.Components)
+	if err != nil {
+		return SBOM{}, err
+	}
+
+	cycloneDXDependencies(packages, doc.Dependencies)
+
+	return SBOM{
+		Format:      SBOMFormatCycloneDX,
+		SpecVersion: doc.SpecVersion,
+		Packages:    packages,
+	}, nil
+}
+
+// cycloneDXPackages flattens the, possibly nested, components into packages
+func cycloneDXPackages(packages []SBOMPackage, components []cycloneDXComponent) ([]SBOMPackage, error) {
+	for _, c := range components {
+		if c.Name == "" {
+			return nil, fmt.Errorf("malformed CycloneDX SBOM: missing name of %s component", c.Type)
+		}
+
+		pkg := SBOMPackage{
+			ID:      c.BOMRef,
+			Name:    c.Name,
+			Version: c.Version,
+			PURL:    c.PURL,
+		}
+
+		for _, l := range c.Licenses {
+			switch {
+			case l.License != nil && l.License.ID != "":
+				pkg.Licenses = appendLicense(pkg.Licenses, l.License.ID)
+			case l.License != nil:
+				pkg.Licenses = appendLicense(pkg.Licenses, l.License.Name)
+			default:
+				pkg.Licenses = appendLicense(pkg.Licenses, l.Expression)
+			}
+		}
+
+		for _, h := range c.Hashes {
+			if pkg.Hashes == nil {
+				pkg.Hashes = make(map[string]string, len(c.Hashes))
+			}
+			pkg.Hashes[hashAlgorithm(h.Alg)] = h.Content
+		}
+
+		packages = append(packages, pkg)
+
+		var err error
+		if packages, err = cycloneDXPackages(packages, c.Components); err != nil {
+			return nil, err
+		}
+	}
+
+	return packages, nil
+}
+
+// cycloneDXDependencies sets the dependencies of packages from the dependency
+// graph of the BOM, references to anything other than components, e.g. the
+// metadata component or services, are not considered.
+func cycloneDXDependencies(packages []SBOMPackage, dependencies []cycloneDXDependency) {
+	byRef := make(map[string]int, len(packages))
+	for i, p := range packages {
+		if p.ID != "" {
+			byRef[p.ID] = i
+		}
+	}
+
+	for _, d := range dependencies {
+		i, ok := byRef[d.Ref]
+		if !ok {
+			continue
+		}
+
+		for _, ref := range d.DependsOn {
+			if _, ok := byRef[ref]; ok {
+				packages[i].Dependencies = appendDependency(packages[i].Dependencies, ref)
+			}
+		}
+	}
+}
+
+func isSupportedCycloneDXVersion(version string) (bool, error) {
+	major, minor, ok := strings.Cut(version, ".")
+	if !ok {
+		return false, fmt.Errorf("invalid specVersion %q", version)
+	}
+
+	majorVersion, err := strconv.Atoi(major)
+	if err != nil {
+		return false, fmt.Errorf("invalid specVersion %q", version)
+	}
+
+	minorVersion, err := strconv.Atoi(minor)
+	if err != nil {
+		return false, fmt.Errorf("invalid specVersion %q", version)
+	}
+
+	return majorVersion > cycloneDXMinMajor || (majorVersion == cycloneDXMinMajor && minorVersion >= cycloneDXMinMinor), nil
+}
@@ -0,0 +1,165 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package attestation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	SBOMFormatSPDX      = "spdx"
+	SBOMFormatCycloneDX = "cyclonedx"
+)
+
+// ErrUnsupportedSBOM is returned when the SBOM is of a specification version
+// that is not supported, e.g. CycloneDX 1.4. Such SBOMs are not malformed,
+// they're just not parsed.
+var ErrUnsupportedSBOM = errors.New("unsupported SBOM")
+
+// SBOM is the normalised content of an SPDX or CycloneDX software bill of
+// materials, made available to the policy rules so they don't need to handle
+// the different SBOM formats.
+type SBOM struct {
+	Format      string        `json:"format"`
+	SpecVersion string        `json:"specVersion"`
+	Packages    []SBOMPackage `json:"packages"`
+}
+
+// SBOMPackage is a package, or a component in CycloneDX terms, listed in the
+// SBOM. The ID is the identifier of the package within the SBOM, i.e. the
+// SPDXID or the CycloneDX bom-ref, and Dependencies hold the IDs of the
+// packages this package depends on. Hashes are keyed by the lower case name of
+// the algorithm, e.g. sha256.
+type SBOMPackage struct {
+	ID           string            `json:"id,omitempty"`
+	Name         string            `json:"name"`
+	Version      string            `json:"version,omitempty"`
+	PURL         string            `json:"purl,omitempty"`
+	Licenses     []string          `json:"licenses,omitempty"`
+	Hashes       map[string]string `json:"hashes,omitempty"`
+	Dependencies []string          `json:"dependencies,omitempty"`
+}
+
+// SBOMAttestation is an attestation with an SPDX or CycloneDX SBOM predicate.
+type SBOMAttestation interface {
+	Attestation
+	SBOM() SBOM
+}
+
+// IsSBOMPredicateType returns true if the attestations with the given
+// predicate type hold an SBOM that can be parsed by SBOMFromAttestation.
+func IsSBOMPredicateType(predicateType string) bool {
+	return IsSPDXPredicateType(predicateType) || IsCycloneDXPredicateType(predicateType)
+}
+
+// SBOMFromAttestation parses the SPDX or CycloneDX SBOM from the predicate of
+// the given attestation. An error wrapping ErrUnsupportedSBOM is returned for
+// SBOMs of unsupported specification versions, any other error denotes a
+// malformed SBOM.
+func SBOMFromAttestation(att Attestation) (SBOMAttestation, error) {
+	var statement struct {
+		Predicate json.RawMessage `json:"predicate"`
+	}
+	if err := json.Unmarshal(att.Statement(), &statement); err != nil {
+		return nil, fmt.Errorf("malformed attestation data: %w", err)
+	}
+
+	var sbom SBOM
+	var err error
+	switch pt := att.PredicateType(); {
+	case IsSPDXPredicateType(pt):
+		sbom, err = parseSPDX(statement.Predicate)
+	case IsCycloneDXPredicateType(pt):
+		sbom, err = parseCycloneDX(statement.Predicate)
+	default:
+		return nil, fmt.Errorf("%w predicate type: %s", ErrUnsupportedSBOM, pt)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sbomAttestation{Attestation: att, sbom: sbom}, nil
+}
+
+// ParseSBOM parses the given SPDX or CycloneDX JSON document, the format is
+// determined from the content of the document. An error wrapping
+// ErrUnsupportedSBOM is returned for documents in neither of the two formats
+// and for SBOMs of unsupported specification versions.
+func ParseSBOM(document []byte) (SBOM, error) {
+	var header struct {
+		SPDXVersion string `json:"spdxVersion"`
+		BOMFormat   string `json:"bomFormat"`
+	}
+	if err := json.Unmarshal(document, &header); err != nil {
+		return SBOM{}, fmt.Errorf("malformed SBOM: %w", err)
+	}
+
+	switch {
+	case header.SPDXVersion != "":
+		return parseSPDX(document)
+	case header.BOMFormat != "":
+		return parseCycloneDX(document)
+	}
+
+	return SBOM{}, fmt.Errorf("%w format, expecting SPDX or CycloneDX", ErrUnsupportedSBOM)
+}
+
+type sbomAttestation struct {
+	Attestation
+	sbom SBOM
+}
+
+func (a sbomAttestation) SBOM() SBOM {
+	return a.sbom
+}
+
+// appendLicense appends the license if it denotes an actual license and is not
+// already present.
+func appendLicense(licenses []string, license string) []string {
+	switch license {
+	case "", "NOASSERTION", "NONE":
+		return licenses
+	}
+
+	if slices.Contains(licenses, license) {
+		return licenses
+	}
+
+	return append(licenses, license)
+}
+
+// hashAlgorithm normalises the hash algorithm names used by SPDX (SHA256,
+// SHA3-256, BLAKE2b-256) and CycloneDX (SHA-256, SHA3-256, BLAKE2b-256) to the
+// lower case name without the dash following "sha", e.g. sha256, sha3-256,
+// blake2b-256.
+func hashAlgorithm(algorithm string) string {
+	return strings.Replace(strings.ToLower(algorithm), "sha-", "sha", 1)
+}
+
+// appendDependency appends the dependency ID if not already present.
+func appendDependency(dependencies []string, id string) []string {
+	if id == "" || slices.Contains(dependencies, id) {
+		return dependencies
+	}
+
+	return append(dependencies, id)
+}
@@ -0,0 +1,358 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package attestation
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sbomProvenance(predicateType, predicate string) Attestation {
+	return provenance{
+		//nolint:staticcheck
+		statement: in_toto.Statement{
+			StatementHeader: in_toto.StatementHeader{
+				Type:          in_toto.StatementInTotoV01,
+				PredicateType: predicateType,
+			},
+		},
+		data: []byte(fmt.Sprintf(`{"_type":%q,"predicateType":%q,"predicate":%s}`, in_toto.StatementInTotoV01, predicateType, predicate)),
+	}
+}
+
+func TestIsSBOMPredicateType(t *testing.T) {
+	cases := []struct {
+		predicateType string
+		expected      bool
+	}{
+		{predicateType: "https://spdx.dev/Document", expected: true},
+		{predicateType: "https://spdx.dev/Document/v2.3", expected: true},
+		{predicateType: "https://cyclonedx.org/bom", expected: true},
+		{predicateType: "https://cyclonedx.org/bom/v1.5", expected: true},
+		{predicateType: "https://cyclonedx.org/bom/v1.6", expected: true},
+		{predicateType: "https://cyclonedx.org/bomb", expected: false},
+		{predicateType: "https://slsa.dev/provenance/v0.2", expected: false},
+		{predicateType: "", expected: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.predicateType, func(t *testing.T) {
+			assert.Equal(t, c.expected, IsSBOMPredicateType(c.predicateType))
+		})
+	}
+}
+
+func TestSBOMFromAttestationSPDX(t *testing.T) {
+	att := sbomProvenance(PredicateSpdxDocument, `{
+		"spdxVersion": "SPDX-2.3",
+		"dataLicense": "CC0-1.0",
+		"SPDXID": "SPDXRef-DOCUMENT",
+		"name": "registry.io/repository/image",
+		"documentNamespace": "https://example.com/image",
+		"packages": [
+			{
+				"SPDXID": "SPDXRef-Package-1",
+				"name": "openssl",
+				"versionInfo": "3.0.7",
+				"licenseConcluded": "Apache-2.0",
+				"licenseDeclared": "Apache-2.0",
+				"checksums": [
+					{"algorithm": "SHA256", "checksumValue": "abc"},
+					{"algorithm": "SHA3-256", "checksumValue": "def"}
+				],
+				"externalRefs": [
+					{
+						"referenceCategory": "SECURITY",
+						"referenceType": "cpe23Type",
+						"referenceLocator": "cpe:2.3:a:openssl:openssl:3.0.7:*:*:*:*:*:*:*"
+					},
+					{
+						"referenceCategory": "PACKAGE-MANAGER",
+						"referenceType": "purl",
+						"referenceLocator": "pkg:rpm/redhat/openssl@3.0.7"
+					}
+				]
+			},
+			{
+				"SPDXID": "SPDXRef-Package-2",
+				"name": "unknown",
+				"licenseConcluded": "NOASSERTION",
+				"licenseDeclared": "NONE"
+			},
+			{
+				"SPDXID": "SPDXRef-Package-3",
+				"name": "zlib"
+			}
+		],
+		"relationships": [
+			{"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-Package-1"},
+			{"spdxElementId": "SPDXRef-Package-1", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-Package-3"},
+			{"spdxElementId": "SPDXRef-Package-2", "relationshipType": "RUNTIME_DEPENDENCY_OF", "relatedSpdxElement": "SPDXRef-Package-1"},
+			{"spdxElementId": "SPDXRef-Package-1", "relationshipType": "CONTAINS", "relatedSpdxElement": "SPDXRef-Package-2"},
+			{"spdxElementId": "SPDXRef-Package-1", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-File-1"},
+			{"spdxElementId": "SPDXRef-Package-1", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-Package-3"}
+		]
+	}`)
+
+	sbom, err := SBOMFromAttestation(att)
+	require.NoError(t, err)
+
+	assert.Equal(t, PredicateSpdxDocument, sbom.PredicateType())
+	assert.Equal(t, att.Statement(), sbom.Statement())
+	assert.Equal(t, SBOM{
+		Format:      SBOMFormatSPDX,
+		SpecVersion: "SPDX-2.3",
+		Packages: []SBOMPackage{
+			{
+				ID:           "SPDXRef-Package-1",
+				Name:         "openssl",
+				Version:      "3.0.7",
+				PURL:         "pkg:rpm/redhat/openssl@3.0.7",
+				Licenses:     []string{"Apache-2.0"},
+				Hashes:       map[string]string{"sha256": "abc", "sha3-256": "def"},
+				Dependencies: []string{"SPDXRef-Package-3", "SPDXRef-Package-2"},
+			},
+			{
+				ID:   "SPDXRef-Package-2",
+				Name: "unknown",
+			},
+			{
+				ID:   "SPDXRef-Package-3",
+				Name: "zlib",
+			},
+		},
+	}, sbom.SBOM())
+}
+
+func TestSBOMFromAttestationCycloneDX(t *testing.T) {
+	att := sbomProvenance(PredicateCycloneDX, `{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.5",
+		"metadata": {"component": {"bom-ref": "image", "type": "container", "name": "image"}},
+		"components": [
+			{
+				"bom-ref": "openssl",
+				"type": "library",
+				"name": "openssl",
+				"version": "3.0.7",
+				"purl": "pkg:rpm/redhat/openssl@3.0.7",
+				"hashes": [
+					{"alg": "SHA-256", "content": "abc"},
+					{"alg": "BLAKE2b-256", "content": "def"}
+				],
+				"licenses": [
+					{"license": {"id": "Apache-2.0"}},
+					{"license": {"name": "Custom"}}
+				],
+				"components": [
+					{
+						"bom-ref": "libcrypto",
+						"type": "library",
+						"name": "libcrypto",
+						"licenses": [
+							{"expression": "MIT OR Apache-2.0"}
+						]
+					}
+				]
+			},
+			{
+				"bom-ref": "zlib",
+				"type": "library",
+				"name": "zlib"
+			}
+		],
+		"dependencies": [
+			{"ref": "image", "dependsOn": ["openssl", "zlib"]},
+			{"ref": "openssl", "dependsOn": ["libcrypto", "zlib", "unknown", "zlib"]},
+			{"ref": "zlib"}
+		]
+	}`)
+
+	sbom, err := SBOMFromAttestation(att)
+	require.NoError(t, err)
+
+	assert.Equal(t, SBOM{
+		Format:      SBOMFormatCycloneDX,
+		SpecVersion: "1.5",
+		Packages: []SBOMPackage{
+			{
+				ID:           "openssl",
+				Name:         "openssl",
+				Version:      "3.0.7",
+				PURL:         "pkg:rpm/redhat/openssl@3.0.7",
+				Licenses:     []string{"Apache-2.0", "Custom"},
+				Hashes:       map[string]string{"sha256": "abc", "blake2b-256": "def"},
+				Dependencies: []string{"libcrypto", "zlib"},
+			},
+			{
+				ID:       "libcrypto",
+				Name:     "libcrypto",
+				Licenses: []string{"MIT OR Apache-2.0"},
+			},
+			{
+				ID:   "zlib",
+				Name: "zlib",
+			},
+		},
+	}, sbom.SBOM())
+}
+
+func TestSBOMFromAttestationNoPackages(t *testing.T) {
+	sbom, err := SBOMFromAttestation(sbomProvenance("https://cyclonedx.org/bom/v1.6", `{"bomFormat": "CycloneDX", "specVersion": "1.6"}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, SBOM{
+		Format:      SBOMFormatCycloneDX,
+		SpecVersion: "1.6",
+		Packages:    []SBOMPackage{},
+	}, sbom.SBOM())
+}
+
+func TestSBOMFromAttestationErrors(t *testing.T) {
+	cases := []struct {
+		name          string
+		predicateType string
+		predicate     string
+		err           string
+		unsupported   bool
+	}{
+		{
+			name:          "SPDX not an object",
+			predicateType: PredicateSpdxDocument,
+			predicate:     `"text"`,
+			err:           "malformed SPDX SBOM: json: cannot unmarshal string into Go value of type attestation.spdxDocument",
+		},
+		{
+			name:          "SPDX missing version",
+			predicateType: PredicateSpdxDocument,
+			predicate:     `{"SPDXID": "SPDXRef-DOCUMENT", "name": "x"}`,
+			err:           "malformed SPDX SBOM: missing spdxVersion",
+		},
+		{
+			name:          "SPDX missing SPDXID",
+			predicateType: PredicateSpdxDocument,
+			predicate:     `{"spdxVersion": "SPDX-2.3", "name": "x"}`,
+			err:           "malformed SPDX SBOM: missing SPDXID",
+		},
+		{
+			name:          "SPDX missing name",
+			predicateType: PredicateSpdxDocumentV23,
+			predicate:     `{"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT"}`,
+			err:           "malformed SPDX SBOM: missing name",
+		},
+		{
+			name:          "SPDX package missing name",
+			predicateType: PredicateSpdxDocument,
+			predicate:     `{"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT", "name": "x", "packages": [{"SPDXID": "SPDXRef-Package"}]}`,
+			err:           "malformed SPDX SBOM: missing name of package at 0",
+		},
+		{
+			name:          "SPDX wrong type",
+			predicateType: PredicateSpdxDocument,
+			predicate:     `{"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT", "name": "x", "packages": {}}`,
+			err:           "malformed SPDX SBOM: json: cannot unmarshal object into Go struct field spdxDocument.packages of type []attestation.spdxPackage",
+		},
+		{
+			name:          "SPDX 3",
+			predicateType: PredicateSpdxDocument,
+			predicate:     `{"spdxVersion": "SPDX-3.0"}`,
+			err:           "unsupported SBOM SPDX version: SPDX-3.0",
+			unsupported:   true,
+		},
+		{
+			name:          "CycloneDX wrong format",
+			predicateType: PredicateCycloneDX,
+			predicate:     `{"bomFormat": "SPDX", "specVersion": "1.5"}`,
+			err:           `malformed CycloneDX SBOM: unexpected bomFormat "SPDX"`,
+		},
+		{
+			name:          "CycloneDX missing version",
+			predicateType: PredicateCycloneDX,
+			predicate:     `{"bomFormat": "CycloneDX"}`,
+			err:           "malformed CycloneDX SBOM: missing specVersion",
+		},
+		{
+			name:          "CycloneDX invalid version",
+			predicateType: PredicateCycloneDX,
+			predicate:     `{"bomFormat": "CycloneDX", "specVersion": "one.five"}`,
+			err:           `malformed CycloneDX SBOM: invalid specVersion "one.five"`,
+		},
+		{
+			name:          "CycloneDX component missing name",
+			predicateType: PredicateCycloneDX,
+			predicate:     `{"bomFormat": "CycloneDX", "specVersion": "1.5", "components": [{"type": "library", "name": "a", "components": [{"type": "file"}]}]}`,
+			err:           "malformed CycloneDX SBOM: missing name of file component",
+		},
+		{
+			name:          "CycloneDX 1.4",
+			predicateType: PredicateCycloneDX,
+			predicate:     `{"bomFormat": "CycloneDX", "specVersion": "1.4"}`,
+			err:           "unsupported SBOM CycloneDX version: 1.4",
+			unsupported:   true,
+		},
+		{
+			name:          "not an SBOM",
+			predicateType: "https://slsa.dev/provenance/v0.2",
+			predicate:     `{}`,
+			err:           "unsupported SBOM predicate type: https://slsa.dev/provenance/v0.2",
+			unsupported:   true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := SBOMFromAttestation(sbomProvenance(c.predicateType, c.predicate))
+			assert.EqualError(t, err, c.err)
+			assert.Equal(t, c.unsupported, errors.Is(err, ErrUnsupportedSBOM))
+		})
+	}
+}
+
+func TestParseSBOM(t *testing.T) {
+	spdx, err := ParseSBOM([]byte(`{"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT", "name": "x", "packages": [{"SPDXID": "SPDXRef-Package", "name": "openssl"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, SBOM{
+		Format:      SBOMFormatSPDX,
+		SpecVersion: "SPDX-2.3",
+		Packages:    []SBOMPackage{{ID: "SPDXRef-Package", Name: "openssl"}},
+	}, spdx)
+
+	cyclonedx, err := ParseSBOM([]byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": [{"type": "library", "name": "zlib"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, SBOM{
+		Format:      SBOMFormatCycloneDX,
+		SpecVersion: "1.6",
+		Packages:    []SBOMPackage{{Name: "zlib"}},
+	}, cyclonedx)
+
+	_, err = ParseSBOM([]byte(`{"spdxVersion": "SPDX-2.3"}`))
+	assert.EqualError(t, err, "malformed SPDX SBOM: missing SPDXID")
+
+	_, err = ParseSBOM([]byte(`{"_type": "https://in-toto.io/Statement/v1"}`))
+	assert.EqualError(t, err, "unsupported SBOM format, expecting SPDX or CycloneDX")
+	assert.ErrorIs(t, err, ErrUnsupportedSBOM)
+
+	_, err = ParseSBOM([]byte(`[]`))
+	assert.ErrorContains(t, err, "malformed SBOM: json: cannot unmarshal array")
+}
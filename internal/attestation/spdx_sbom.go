@@ -16,9 +16,168 @@
 
 package attestation
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
 const (
-	PredicateSpdxDocument = "https://spdx.dev/Document"
+	PredicateSpdxDocument    = "https://spdx.dev/Document"
+	PredicateSpdxDocumentV23 = "https://spdx.dev/Document/v2.3"
 )
 
-// Todo: More code here in future probably, e.g. for unmarshaling an SPDX
-// document to confirm it is formatted correctly
+// spdxDocument holds the parts of the SPDX 2.x JSON document we're interested
+// in, see https://spdx.github.io/spdx-spec/v2.3/document-creation-information/
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs"`
+	Checksums        []spdxChecksum    `json:"checksums"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+// IsSPDXPredicateType returns true for the predicate types of SPDX SBOM
+// attestations.
+func IsSPDXPredicateType(predicateType string) bool {
+	return predicateType == PredicateSpdxDocument || predicateType == PredicateSpdxDocumentV23
+}
+
+// spdxDependencyOf are the relationship types denoting that the SPDX element is
+// a dependency of the related SPDX element, DEPENDS_ON denotes the reverse.
+var spdxDependencyOf = []string{
+	"BUILD_DEPENDENCY_OF",
+	"DEPENDENCY_OF",
+	"DEV_DEPENDENCY_OF",
+	"OPTIONAL_DEPENDENCY_OF",
+	"PROVIDED_DEPENDENCY_OF",
+	"RUNTIME_DEPENDENCY_OF",
+	"TEST_DEPENDENCY_OF",
+}
+
+func parseSPDX(predicate []byte) (SBOM, error) {
+	var doc spdxDocument
+	if err := json.Unmarshal(predicate, &doc); err != nil {
+		return SBOM{}, fmt.Errorf("malformed SPDX SBOM: %w", err)
+	}
+
+	if doc.SPDXVersion == "" {
+		return SBOM{}, errors.New("malformed SPDX SBOM: missing spdxVersion")
+	}
+
+	if !strings.HasPrefix(doc.SPDXVersion, "SPDX-2.") {
+		return SBOM{}, fmt.Errorf("%w SPDX version: %s", ErrUnsupportedSBOM, doc.SPDXVersion)
+	}
+
+	if doc.SPDXID == "" {
+		return SBOM{}, errors.New("malformed SPDX SBOM: missing SPDXID")
+	}
+
+	if doc.Name == "" {
+		return SBOM{}, errors.New("malformed SPDX SBOM: missing name")
+	}
+
+	packages := make([]SBOMPackage, 0, len(doc.Packages))
+	for i, p := range doc.Packages {
+		if p.Name == "" {
+			return SBOM{}, fmt.Errorf("malformed SPDX SBOM: missing name of package at %d", i)
+		}
+
+		pkg := SBOMPackage{
+			ID:      p.SPDXID,
+			Name:    p.Name,
+			Version: p.VersionInfo,
+		}
+
+		for _, ref := range p.ExternalRefs {
+			if ref.ReferenceType == "purl" && pkg.PURL == "" {
+				pkg.PURL = ref.ReferenceLocator
+			}
+		}
+
+		pkg.Licenses = appendLicense(pkg.Licenses, p.LicenseConcluded)
+		pkg.Licenses = appendLicense(pkg.Licenses, p.LicenseDeclared)
+
+		for _, c := range p.Checksums {
+			if pkg.Hashes == nil {
+				pkg.Hashes = make(map[string]string, len(p.Checksums))
+			}
+			pkg.Hashes[hashAlgorithm(c.Algorithm)] = c.ChecksumValue
+		}
+
+		packages = append(packages, pkg)
+	}
+
+	spdxDependencies(packages, doc.Relationships)
+
+	return SBOM{
+		Format:      SBOMFormatSPDX,
+		SpecVersion: doc.SPDXVersion,
+		Packages:    packages,
+	}, nil
+}
+
+// spdxDependencies sets the dependencies of packages from the dependency
+// relationships between them, relationships with other SPDX elements, e.g.
+// files, are not considered.
+func spdxDependencies(packages []SBOMPackage, relationships []spdxRelationship) {
+	byID := make(map[string]int, len(packages))
+	for i, p := range packages {
+		if p.ID != "" {
+			byID[p.ID] = i
+		}
+	}
+
+	for _, r := range relationships {
+		dependent, dependency := r.SPDXElementID, r.RelatedSPDXElement
+		switch {
+		case r.RelationshipType == "DEPENDS_ON":
+		case slices.Contains(spdxDependencyOf, r.RelationshipType):
+			dependent, dependency = dependency, dependent
+		default:
+			continue
+		}
+
+		i, ok := byID[dependent]
+		if !ok {
+			continue
+		}
+
+		if _, ok := byID[dependency]; !ok {
+			continue
+		}
+
+		packages[i].Dependencies = appendDependency(packages[i].Dependencies, dependency)
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"oras.land/oras-go/v2/registry/remote/retry"
 
 	"github.com/conforma/cli/internal/http"
+	"github.com/conforma/cli/internal/utils/oci"
 )
 
 type key int
@@ -53,6 +54,9 @@ var gatherFunc = func(ctx context.Context, source, destination string) (metadata
 }
 
 var _initialize = func() {
+	// apply the registries configuration to the policy bundle downloads
+	goci.Transport = oci.NewRegistriesTransport(goci.Transport)
+
 	if log.IsLevelEnabled(logrus.TraceLevel) {
 		goci.Transport = http.NewTracingRoundTripperWithLogger(goci.Transport)
 		ghttp.Transport = http.NewTracingRoundTripperWithLogger(ghttp.Transport)
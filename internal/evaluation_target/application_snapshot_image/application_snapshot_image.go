@@ -230,17 +230,10 @@ func (a *ApplicationSnapshotImage) ValidateAttestationSignature(ctx context.Cont
 			}
 			a.attestations = append(a.attestations, sp)
 
-		case attestation.PredicateSpdxDocument:
-			// It's an SPDX format SBOM
-			// Todo maybe: We could unmarshal it into a suitable SPDX struct
-			// similar to how it's done for SLSA above
-			a.attestations = append(a.attestations, att)
-
-		// Todo: CycloneDX format SBOM
-
 		default:
-			// It's some other kind of attestation
-			a.attestations = append(a.attestations, att)
+			// It's an SPDX or CycloneDX format SBOM, or some other kind of
+			// attestation
+			a.attestations = append(a.attestations, withSBOM(att))
 		}
 	}
 	return nil
@@ -275,11 +268,28 @@ func (a *ApplicationSnapshotImage) parseAttestationsFromBundles(layers []cosignO
 		}
 		t := att.PredicateType()
 		log.Debugf("Found bundle attestation with predicateType: %s", t)
-		a.attestations = append(a.attestations, att)
+		a.attestations = append(a.attestations, withSBOM(att))
 	}
 	return nil
 }
 
+// withSBOM returns the attestation with the SBOM parsed from its predicate if
+// it is an SPDX or CycloneDX SBOM attestation, otherwise the attestation is
+// returned as is. Malformed SBOMs are reported by ValidateAttestationSyntax.
+func withSBOM(att attestation.Attestation) attestation.Attestation {
+	if !attestation.IsSBOMPredicateType(att.PredicateType()) {
+		return att
+	}
+
+	sbom, err := attestation.SBOMFromAttestation(att)
+	if err != nil {
+		log.Debugf("Unable to parse SBOM from attestation with predicateType %s: %v", att.PredicateType(), err)
+		return att
+	}
+
+	return sbom
+}
+
 // ValidateAttestationSyntax validates the attestations against known JSON
 // schemas, errors out if there are no attestations to check to prevent
 // successful syntax check of no inputs, must invoke
@@ -298,17 +308,18 @@ func (a ApplicationSnapshotImage) ValidateAttestationSyntax(ctx context.Context)
 	var validationErr error
 	for _, sp := range a.attestations {
 		pt := sp.PredicateType()
-		if schema, ok := attestationSchemas[pt]; ok {
+
+		var statement any
+		if err := json.Unmarshal(sp.Statement(), &statement); err != nil {
+			return fmt.Errorf("unable to decode attestation data from attestation image: %w", err)
+		}
+
+		if schema, instance := attestationSchema(pt, statement); schema != nil {
 			// Found a validator for this predicate type so let's use it
 			log.Debugf("Attempting to validate an attestation with predicateType %s", pt)
 
-			var statement any
-			if err := json.Unmarshal(sp.Statement(), &statement); err != nil {
-				return fmt.Errorf("unable to decode attestation data from attestation image: %w", err)
-			}
-
-			if err := schema.Validate(statement); err != nil {
-				if _, ok = err.(*jsonschema.ValidationError); !ok {
+			if err := schema.Validate(instance); err != nil {
+				if _, ok := err.(*jsonschema.ValidationError); !ok {
 					// Error while trying to validate
 					return fmt.Errorf("unable to validate attestation data from attestation image: %w", err)
 				}
@@ -320,6 +331,10 @@ func (a ApplicationSnapshotImage) ValidateAttestationSyntax(ctx context.Context)
 		} else {
 			log.Debugf("No schema validation found for predicateType %s", pt)
 		}
+
+		if err := validateSBOMSyntax(sp); err != nil {
+			validationErr = errors.Join(validationErr, err)
+		}
 	}
 
 	if validationErr == nil {
@@ -332,6 +347,52 @@ func (a ApplicationSnapshotImage) ValidateAttestationSyntax(ctx context.Context)
 	return fmt.Errorf("attestation syntax validation failed: %s", validationErr.Error())
 }
 
+// attestationSchema returns the JSON schema for the attestation with the given
+// predicate type and the part of the statement it validates. The SLSA
+// Provenance schemas describe the whole statement, while the SBOM schemas
+// describe the predicate and are used only for SBOMs of the specification
+// version they describe. Returns nil schema if there is no schema to validate
+// the attestation with.
+func attestationSchema(predicateType string, statement any) (*jsonschema.Schema, any) {
+	if schema, ok := attestationSchemas[predicateType]; ok {
+		return schema, statement
+	}
+
+	s, ok := statement.(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+
+	predicate, ok := s["predicate"].(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+
+	switch {
+	case attestation.IsSPDXPredicateType(predicateType) && predicate["spdxVersion"] == "SPDX-2.3":
+		return schema.SPDX_2_3, predicate
+	case attestation.IsCycloneDXPredicateType(predicateType) && predicate["specVersion"] == "1.5":
+		return schema.CycloneDX_1_5, predicate
+	}
+
+	return nil, nil
+}
+
+// validateSBOMSyntax reports SBOM attestations with a malformed SBOM, i.e.
+// those for which the SBOM could not be parsed by withSBOM. SBOMs of versions
+// that are not supported are not considered malformed.
+func validateSBOMSyntax(att attestation.Attestation) error {
+	if _, ok := att.(attestation.SBOMAttestation); ok || !attestation.IsSBOMPredicateType(att.PredicateType()) {
+		return nil
+	}
+
+	if _, err := attestation.SBOMFromAttestation(att); err != nil && !errors.Is(err, attestation.ErrUnsupportedSBOM) {
+		return err
+	}
+
+	return nil
+}
+
 // Attestations returns the value of the attestations field of the ApplicationSnapshotImage struct
 func (a *ApplicationSnapshotImage) Attestations() []attestation.Attestation {
 	return a.attestations
@@ -356,6 +417,7 @@ func (a *ApplicationSnapshotImage) ImageReference(ctx context.Context) string {
 type attestationData struct {
 	Statement  json.RawMessage             `json:"statement"`
 	Signatures []signature.EntitySignature `json:"signatures,omitempty"`
+	SBOM       *attestation.SBOM           `json:"sbom,omitempty"`
 }
 
 // MarshalJSON returns a JSON representation of the attestationData. It is customized to take into
@@ -393,6 +455,20 @@ func (a attestationData) MarshalJSON() ([]byte, error) {
 		}
 	}
 
+	if a.SBOM != nil {
+		_, err = buffy.WriteString(`, "sbom":`)
+		if err != nil {
+			return nil, fmt.Errorf("write sbom key: %w", err)
+		}
+		sbom, err := json.Marshal(a.SBOM)
+		if err != nil {
+			return nil, fmt.Errorf("marshal json sbom: %w", err)
+		}
+		if _, err := buffy.Write(sbom); err != nil {
+			return nil, fmt.Errorf("write sbom value: %w", err)
+		}
+	}
+
 	if err := buffy.WriteByte('}'); err != nil {
 		return nil, fmt.Errorf("close json: %w", err)
 	}
@@ -423,10 +499,15 @@ func (a *ApplicationSnapshotImage) WriteInputFile(ctx context.Context) (string,
 
 	var attestations []attestationData
 	for _, a := range a.attestations {
-		attestations = append(attestations, attestationData{
+		data := attestationData{
 			Statement:  a.Statement(),
 			Signatures: a.Signatures(),
-		})
+		}
+		if s, ok := a.(attestation.SBOMAttestation); ok {
+			sbom := s.SBOM()
+			data.SBOM = &sbom
+		}
+		attestations = append(attestations, data)
 	}
 
 	input := Input{
@@ -880,6 +880,7 @@ func TestAttestationDataMarshalJSON(t *testing.T) {
 		name       string
 		statement  json.RawMessage
 		signatures []signature.EntitySignature
+		sbom       *attestation.SBOM
 		expected   string
 	}{
 		{
@@ -902,6 +903,16 @@ func TestAttestationDataMarshalJSON(t *testing.T) {
 			signatures: nil,
 			expected:   `{"statement":{"type":"test"}}`,
 		},
+		{
+			name:      "with sbom",
+			statement: json.RawMessage(`{"type":"test"}`),
+			sbom: &attestation.SBOM{
+				Format:      attestation.SBOMFormatSPDX,
+				SpecVersion: "SPDX-2.3",
+				Packages:    []attestation.SBOMPackage{{Name: "pkg", Version: "1.0"}},
+			},
+			expected: `{"statement":{"type":"test"},"sbom":{"format":"spdx","specVersion":"SPDX-2.3","packages":[{"name":"pkg","version":"1.0"}]}}`,
+		},
 	}
 
 	for _, tc := range cases {
@@ -909,6 +920,7 @@ func TestAttestationDataMarshalJSON(t *testing.T) {
 			data := attestationData{
 				Statement:  tc.statement,
 				Signatures: tc.signatures,
+				SBOM:       tc.sbom,
 			}
 			result, err := data.MarshalJSON()
 			require.NoError(t, err)
@@ -1367,3 +1379,169 @@ func TestValidateAttestationSignature(t *testing.T) {
 		})
 	}
 }
+
+func TestSBOMAttestations(t *testing.T) {
+	ref := name.MustParseReference("registry.io/repository/image:tag")
+
+	sbomStatement := func(predicateType, predicate string) oci.Signature {
+		//nolint:staticcheck
+		return createDSSESignature(t, in_toto.Statement{
+			//nolint:staticcheck
+			StatementHeader: in_toto.StatementHeader{
+				Type:          in_toto.StatementInTotoV01,
+				PredicateType: predicateType,
+				//nolint:staticcheck
+				Subject: []in_toto.Subject{
+					{
+						Name: "test-image",
+						Digest: common.DigestSet{
+							"sha256": "abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789",
+						},
+					},
+				},
+			},
+			Predicate: json.RawMessage(predicate),
+		})
+	}
+
+	spdx := sbomStatement(attestation.PredicateSpdxDocument, `{
+		"spdxVersion": "SPDX-2.3",
+		"dataLicense": "CC0-1.0",
+		"SPDXID": "SPDXRef-DOCUMENT",
+		"name": "image",
+		"creationInfo": {"created": "2024-03-21T12:00:00Z", "creators": ["Tool: syft"]},
+		"packages": [{"SPDXID": "SPDXRef-Package", "name": "openssl", "versionInfo": "3.0.7", "downloadLocation": "NOASSERTION", "licenseConcluded": "Apache-2.0"}]
+	}`)
+	cyclonedx := sbomStatement(attestation.PredicateCycloneDX, `{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.5",
+		"version": 1,
+		"components": [{"type": "library", "name": "zlib", "version": "1.3", "purl": "pkg:rpm/redhat/zlib@1.3"}]
+	}`)
+	unsupported := sbomStatement(attestation.PredicateCycloneDX, `{"bomFormat": "CycloneDX", "specVersion": "1.4"}`)
+	malformed := sbomStatement(attestation.PredicateSpdxDocument, `{"spdxVersion": "SPDX-2.3"}`)
+	nonConformingSPDX := sbomStatement(attestation.PredicateSpdxDocumentV23, `{
+		"spdxVersion": "SPDX-2.3",
+		"dataLicense": "CC0-1.0",
+		"SPDXID": "SPDXRef-DOCUMENT",
+		"name": "image",
+		"creationInfo": {"created": "2024-03-21T12:00:00Z", "creators": ["Tool: syft"]},
+		"packages": [{"SPDXID": "SPDXRef-Package", "name": "openssl", "downloadLocation": "NOASSERTION", "primaryPackagePurpose": "CONTAINER-IMAGE"}]
+	}`)
+	nonConformingCycloneDX := sbomStatement("https://cyclonedx.org/bom/v1.5", `{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.5",
+		"version": 1,
+		"components": [{"type": "package", "name": "zlib"}]
+	}`)
+
+	cases := []struct {
+		name       string
+		signatures []oci.Signature
+		sboms      []any
+		syntaxErr  string
+	}{
+		{
+			name:       "SPDX and CycloneDX",
+			signatures: []oci.Signature{spdx, cyclonedx},
+			sboms: []any{
+				map[string]any{
+					"format":      "spdx",
+					"specVersion": "SPDX-2.3",
+					"packages": []any{
+						map[string]any{"id": "SPDXRef-Package", "name": "openssl", "version": "3.0.7", "licenses": []any{"Apache-2.0"}},
+					},
+				},
+				map[string]any{
+					"format":      "cyclonedx",
+					"specVersion": "1.5",
+					"packages": []any{
+						map[string]any{"name": "zlib", "version": "1.3", "purl": "pkg:rpm/redhat/zlib@1.3"},
+					},
+				},
+			},
+		},
+		{
+			name:       "unsupported version",
+			signatures: []oci.Signature{unsupported},
+			sboms:      []any{nil},
+		},
+		{
+			name:       "malformed",
+			signatures: []oci.Signature{cyclonedx, malformed},
+			sboms: []any{
+				map[string]any{
+					"format":      "cyclonedx",
+					"specVersion": "1.5",
+					"packages": []any{
+						map[string]any{"name": "zlib", "version": "1.3", "purl": "pkg:rpm/redhat/zlib@1.3"},
+					},
+				},
+				nil,
+			},
+			syntaxErr: "attestation syntax validation failed: " +
+				"jsonschema: '' does not validate with http://spdx.org/rdf/terms/2.3#/required: missing properties: 'SPDXID', 'creationInfo', 'dataLicense', 'name'\n" +
+				"malformed SPDX SBOM: missing SPDXID",
+		},
+		{
+			name:       "not conforming to schema",
+			signatures: []oci.Signature{nonConformingSPDX, nonConformingCycloneDX},
+			sboms: []any{
+				map[string]any{
+					"format":      "spdx",
+					"specVersion": "SPDX-2.3",
+					"packages": []any{
+						map[string]any{"id": "SPDXRef-Package", "name": "openssl"},
+					},
+				},
+				map[string]any{
+					"format":      "cyclonedx",
+					"specVersion": "1.5",
+					"packages": []any{
+						map[string]any{"name": "zlib"},
+					},
+				},
+			},
+			syntaxErr: "attestation syntax validation failed: " +
+				"jsonschema: '/packages/0/primaryPackagePurpose' does not validate with http://spdx.org/rdf/terms/2.3#/properties/packages/items/properties/primaryPackagePurpose/enum: value must be one of \"OTHER\", \"INSTALL\", \"ARCHIVE\", \"FIRMWARE\", \"APPLICATION\", \"FRAMEWORK\", \"LIBRARY\", \"CONTAINER\", \"SOURCE\", \"DEVICE\", \"OPERATING_SYSTEM\", \"FILE\"\n" +
+				"jsonschema: '/components/0/type' does not validate with http://cyclonedx.org/schema/bom-1.5.schema.json#/properties/components/items/$ref/properties/type/enum: value must be one of \"application\", \"framework\", \"library\", \"container\", \"platform\", \"operating-system\", \"device\", \"device-driver\", \"firmware\", \"file\", \"machine-learning-model\", \"data\"",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			a := ApplicationSnapshotImage{reference: ref}
+
+			client := fake.FakeClient{}
+			client.On("HasBundles", mock.Anything, ref).Return(false, nil)
+			client.On("VerifyImageAttestations", ref, mock.Anything).Return(c.signatures, false, nil)
+
+			ctx := o.WithClient(context.Background(), &client)
+			require.NoError(t, a.ValidateAttestationSignature(ctx))
+
+			err := a.ValidateAttestationSyntax(ctx)
+			if c.syntaxErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, c.syntaxErr)
+			}
+
+			ctx = utils.WithFS(ctx, afero.NewMemMapFs())
+			_, inputJSON, err := a.WriteInputFile(ctx)
+			require.NoError(t, err)
+
+			var input struct {
+				Attestations []struct {
+					SBOM any `json:"sbom"`
+				} `json:"attestations"`
+			}
+			require.NoError(t, json.Unmarshal(inputJSON, &input))
+
+			sboms := make([]any, 0, len(input.Attestations))
+			for _, att := range input.Attestations {
+				sboms = append(sboms, att.SBOM)
+			}
+			assert.Equal(t, c.sboms, sboms)
+		})
+	}
+}
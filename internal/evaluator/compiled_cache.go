@@ -0,0 +1,104 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package evaluator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// compiledCache holds the compiled policies, loaded data and prepared queries
+// of an evaluator. An evaluator is created per policy source group and used to
+// evaluate every component of a snapshot, so the policies and data it has
+// downloaded are the same for each EvaluationTarget. Compiling them only once
+// avoids re-parsing and re-compiling the same Rego modules and data documents
+// for each component.
+//
+// The cache is safe for concurrent use. Concurrent requests for the same key
+// wait for the first one to finish compiling instead of compiling in parallel.
+type compiledCache struct {
+	mu      sync.Mutex
+	entries map[string]*compiledEntry
+}
+
+type compiledEntry struct {
+	once  sync.Once
+	value any
+	err   error
+}
+
+func newCompiledCache() *compiledCache {
+	return &compiledCache{
+		entries: map[string]*compiledEntry{},
+	}
+}
+
+// cached returns the value stored under the given key, creating it with the
+// provided function if not present. Errors are cached as well, compiling the
+// same policies again would yield the same error. A nil cache disables the
+// caching and always invokes the function.
+func cached[T any](c *compiledCache, key string, create func() (T, error)) (T, error) {
+	if c == nil {
+		return create()
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &compiledEntry{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	if ok {
+		log.Debugf("Reusing compiled policies for %q", key)
+	}
+
+	entry.once.Do(func() {
+		entry.value, entry.err = create()
+	})
+
+	if entry.err != nil {
+		var zero T
+		return zero, entry.err
+	}
+
+	value, ok := entry.value.(T)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("unexpected type %T cached for %q", entry.value, key)
+	}
+
+	return value, nil
+}
+
+// compiledCacheKey builds a cache key from the given parts, the order of the
+// parts within each slice is not significant.
+func compiledCacheKey(kind string, parts ...[]string) string {
+	key := []string{kind}
+	for _, p := range parts {
+		sorted := slices.Clone(p)
+		slices.Sort(sorted)
+		key = append(key, strings.Join(sorted, ","))
+	}
+
+	return strings.Join(key, "|")
+}
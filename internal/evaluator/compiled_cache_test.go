@@ -0,0 +1,215 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ecc "github.com/conforma/crds/api/v1alpha1"
+	"github.com/open-policy-agent/conftest/runner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conforma/cli/internal/policy"
+	"github.com/conforma/cli/internal/policy/source"
+)
+
+func TestCachedCreatesOnce(t *testing.T) {
+	c := newCompiledCache()
+
+	var calls atomic.Int32
+	create := func() (string, error) {
+		calls.Add(1)
+		return "compiled", nil
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := cached(c, "key", create)
+			assert.NoError(t, err)
+			assert.Equal(t, "compiled", v)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+
+	v, err := cached(c, "other", create)
+	require.NoError(t, err)
+	assert.Equal(t, "compiled", v)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestCachedErrors(t *testing.T) {
+	c := newCompiledCache()
+
+	expected := errors.New("expected")
+	calls := 0
+	create := func() (string, error) {
+		calls++
+		return "", expected
+	}
+
+	_, err := cached(c, "key", create)
+	assert.ErrorIs(t, err, expected)
+	_, err = cached(c, "key", create)
+	assert.ErrorIs(t, err, expected)
+	assert.Equal(t, 1, calls)
+
+	_, err = cached(c, "string", func() (string, error) { return "compiled", nil })
+	require.NoError(t, err)
+	_, err = cached(c, "string", func() (int, error) { return 1, nil })
+	assert.EqualError(t, err, `unexpected type string cached for "string"`)
+}
+
+func TestCachedWithoutCache(t *testing.T) {
+	calls := 0
+	create := func() (string, error) {
+		calls++
+		return "compiled", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		v, err := cached(nil, "key", create)
+		require.NoError(t, err)
+		assert.Equal(t, "compiled", v)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestCompiledCacheKey(t *testing.T) {
+	assert.Equal(t, "conftest|a,b|c", compiledCacheKey("conftest", []string{"b", "a"}, []string{"c"}))
+	assert.Equal(t, compiledCacheKey("opa", []string{"a", "b"}), compiledCacheKey("opa", []string{"b", "a"}))
+	assert.NotEqual(t, compiledCacheKey("opa", []string{"a"}), compiledCacheKey("conftest", []string{"a"}))
+}
+
+func TestConftestEvaluatorReusesCompiledPolicies(t *testing.T) {
+	dir := t.TempDir()
+	inputs := []string{}
+	for _, name := range []string{"one", "two", "three"} {
+		require.NoError(t, os.MkdirAll(path.Join(dir, name), 0755))
+		require.NoError(t, os.WriteFile(path.Join(dir, name, "data.json"), []byte("{}"), 0600))
+		inputs = append(inputs, path.Join(dir, name))
+	}
+
+	rego, err := fs.Sub(policies, "__testdir__/simple")
+	require.NoError(t, err)
+
+	rules, err := rulesArchiveFromFS(t, rego)
+	require.NoError(t, err)
+
+	ctx := withCapabilities(context.Background(), testCapabilities)
+
+	config := &mockConfigProvider{}
+	config.On("EffectiveTime").Return(time.Now())
+	config.On("SigstoreOpts").Return(policy.SigstoreOpts{}, nil)
+	config.On("Spec").Return(ecc.EnterpriseContractPolicySpec{})
+
+	e, err := NewConftestEvaluator(ctx, []source.PolicySource{
+		&source.PolicyUrl{
+			Url:  rules,
+			Kind: source.PolicyKind,
+		},
+	}, config, ecc.Source{})
+	require.NoError(t, err)
+	defer e.Destroy()
+
+	evaluator := e.(conftestEvaluator)
+
+	results := make([][]Outcome, 0, len(inputs))
+	for _, input := range inputs {
+		r, err := evaluator.Evaluate(ctx, EvaluationTarget{Inputs: []string{input}})
+		require.NoError(t, err)
+		sortOutcomes(r)
+		for j := range r {
+			r[j].FileName = ""
+		}
+		results = append(results, r)
+	}
+
+	// a single engine compiled, and its queries prepared, for all the inputs
+	assert.Len(t, evaluator.compiled.entries, 2)
+	assert.Equal(t, results[0], results[1])
+	assert.Equal(t, results[0], results[2])
+}
+
+func TestConftestRunnerFileInfoConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(dir, "policy"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(dir, "policy", "file.rego"), []byte(`package file
+
+import rego.v1
+
+deny contains data.conftest.file.dir
+
+deny_again contains data.conftest.file.dir
+
+warn contains data.conftest.file.dir
+
+warn_again contains data.conftest.file.dir
+`), 0600))
+	capabilities := path.Join(dir, "capabilities.json")
+	require.NoError(t, os.WriteFile(capabilities, []byte(testCapabilities), 0600))
+
+	// the components are evaluated concurrently using the same compiled
+	// policies, each sees its own file as data.conftest.file
+	compiled := newCompiledCache()
+	var wg sync.WaitGroup
+	for i := range 20 {
+		inputDir := path.Join(dir, fmt.Sprintf("input-%d", i))
+		require.NoError(t, os.MkdirAll(inputDir, 0755))
+		require.NoError(t, os.WriteFile(path.Join(inputDir, "input.json"), []byte(`{}`), 0600))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := conftestRunner{
+				TestRunner: runner.TestRunner{
+					Policy:       []string{path.Join(dir, "policy")},
+					Namespace:    []string{"file"},
+					Capabilities: capabilities,
+					RegoVersion:  "v1",
+				},
+				compiled: compiled,
+			}
+			results, err := r.Run(context.Background(), []string{path.Join(inputDir, "input.json")})
+			if assert.NoError(t, err) && assert.Len(t, results, 1) {
+				require.Len(t, results[0].Failures, 2)
+				require.Len(t, results[0].Warnings, 2)
+				for _, result := range slices.Concat(results[0].Failures, results[0].Warnings) {
+					assert.Equal(t, inputDir, result.Message)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -26,11 +26,13 @@ import (
 	"path"
 	"path/filepath"
 	"runtime/trace"
+	"strconv"
 	"strings"
 	"time"
 
 	ecc "github.com/conforma/crds/api/v1alpha1"
 	"github.com/open-policy-agent/conftest/output"
+	"github.com/open-policy-agent/conftest/parser"
 	conftest "github.com/open-policy-agent/conftest/policy"
 	"github.com/open-policy-agent/conftest/runner"
 	"github.com/open-policy-agent/opa/v1/ast"
@@ -43,6 +45,7 @@ import (
 	"github.com/conforma/cli/internal/opa/rule"
 	"github.com/conforma/cli/internal/policy"
 	"github.com/conforma/cli/internal/policy/source"
+	"github.com/conforma/cli/internal/rego/vuln"
 	"github.com/conforma/cli/internal/tracing"
 	"github.com/conforma/cli/internal/utils"
 )
@@ -183,8 +186,8 @@ const (
 )
 
 const (
-	severityWarning = "warning"
-	severityFailure = "failure"
+	SeverityWarning = "warning"
+	SeverityFailure = "failure"
 )
 
 // ConfigProvider is a subset of the policy.Policy interface. Its purpose is to codify which parts
@@ -209,68 +212,78 @@ type conftestEvaluator struct {
 	namespace      []string
 	source         ecc.Source
 	policyResolver PolicyResolver // Unified policy resolver for both pre and post-evaluation filtering
+	runnerFactory  runnerFactory  // Creates the runner used for evaluation, defaults to the Conftest runner
+	compiled       *compiledCache // Compiled policies and data reused across evaluations
 }
 
+// runnerFactory creates the testRunner used to evaluate the policies of the
+// given evaluator against the provided namespaces and data directories.
+type runnerFactory func(c conftestEvaluator, namespaces []string, allNamespaces bool, dataDirs []string) testRunner
+
 type conftestRunner struct {
 	runner.TestRunner
+	compiled *compiledCache
+}
+
+// newConftestRunner is the default runnerFactory, it delegates the evaluation
+// to the Conftest test runner.
+func newConftestRunner(c conftestEvaluator, namespaces []string, allNamespaces bool, dataDirs []string) testRunner {
+	return &conftestRunner{
+		runner.TestRunner{
+			Data:          dataDirs,
+			Policy:        []string{c.policyDir},
+			Namespace:     namespaces,
+			AllNamespaces: allNamespaces, // Use all namespaces for legacy filtering
+			NoFail:        true,
+			Output:        c.outputFormat,
+			Capabilities:  c.CapabilitiesPath(),
+			RegoVersion:   "v1",
+		},
+		c.compiled,
+	}
 }
 
 func (r conftestRunner) Run(ctx context.Context, fileList []string) (result []Outcome, err error) {
 	r.Trace = tracing.FromContext(ctx).Enabled(tracing.Opa)
 
-	var conftestResult []output.CheckResult
-	conftestResult, err = r.TestRunner.Run(ctx, fileList)
+	files, err := inputFiles(fileList)
 	if err != nil {
-		return
+		return nil, fmt.Errorf("parse files: %w", err)
 	}
 
-	for _, res := range conftestResult {
-		if log.IsLevelEnabled(log.TraceLevel) {
-			for _, q := range res.Queries {
-				for _, t := range q.Traces {
-					log.Tracef("[%s] %s", q.Query, t)
-				}
-			}
-		}
-		if log.IsLevelEnabled(log.DebugLevel) {
-			for _, q := range res.Queries {
-				for _, o := range q.Outputs {
-					log.Debugf("[%s] %s", q.Query, o)
-				}
-			}
-		}
-
-		result = append(result, Outcome{
-			FileName:  res.FileName,
-			Namespace: res.Namespace,
-			// Conftest doesn't give us a list of successes, just a count. Here we turn that count
-			// into a placeholder slice of that size to make processing easier later on.
-			Successes:  make([]Result, res.Successes),
-			Skipped:    toRules(res.Skipped),
-			Warnings:   toRules(res.Warnings),
-			Failures:   toRules(res.Failures),
-			Exceptions: toRules(res.Exceptions),
-		})
+	configurations, err := parser.ParseConfigurations(files)
+	if err != nil {
+		return nil, fmt.Errorf("parse configurations: %w", err)
 	}
 
-	// we can't reference the engine from the test runner or from the results so
-	// we need to recreate it, this needs to remain the same as in
-	// runner.TestRunner's Run function
-	var engine *conftest.Engine
-	capabilities, err := conftest.LoadCapabilities(r.Capabilities)
+	// The policies and data are the same for all the targets evaluated by the
+	// evaluator, so the engine holding them compiled is created only once. The
+	// engine's Check writes the file being evaluated to data.conftest.file in
+	// the store it holds, which is shared by the targets evaluated concurrently,
+	// so the queries are evaluated the same way the OPA runner does, with the
+	// file provided for each evaluation by fileInfoStore.
+	key := compiledCacheKey("conftest", r.Policy, r.Data, []string{strconv.FormatBool(r.Trace)})
+	engine, err := cached(r.compiled, key, r.load)
 	if err != nil {
-		return
+		return nil, err
 	}
-	compilerOptions := conftest.CompilerOptions{
-		Strict:       r.Strict,
-		RegoVersion:  r.RegoVersion,
-		Capabilities: capabilities,
+
+	opa := opaRunner{namespaces: r.Namespace, allNamespaces: r.AllNamespaces}
+	queriesKey := compiledCacheKey("conftest", r.Policy, r.Data, r.Namespace, []string{strconv.FormatBool(r.AllNamespaces), strconv.FormatBool(r.Trace)})
+	namespaces, err := cached(r.compiled, queriesKey, func() ([]opaNamespace, error) {
+		return opa.prepare(ctx, engine.Compiler(), engine.Modules(), fileInfoStore{engine.Store()}, r.Trace)
+	})
+	if err != nil {
+		return nil, err
 	}
-	engine, err = conftest.LoadWithData(r.Policy, r.Data, compilerOptions)
+
+	conftestResult, err := opa.evaluate(ctx, namespaces, configurations, r.Trace)
 	if err != nil {
-		return
+		return nil, fmt.Errorf("query rule: %w", err)
 	}
 
+	result = toOutcomes(conftestResult)
+
 	store := engine.Store()
 
 	var txn storage.Transaction
@@ -278,6 +291,7 @@ func (r conftestRunner) Run(ctx context.Context, fileList []string) (result []Ou
 	if err != nil {
 		return
 	}
+	defer store.Abort(ctx, txn)
 
 	ids := []string{} // everything
 
@@ -293,6 +307,31 @@ func (r conftestRunner) Run(ctx context.Context, fileList []string) (result []Ou
 	return
 }
 
+// load creates the Conftest engine with the policies and data compiled, this
+// needs to remain the same as in runner.TestRunner's Run function
+func (r conftestRunner) load() (*conftest.Engine, error) {
+	capabilities, err := conftest.LoadCapabilities(r.Capabilities)
+	if err != nil {
+		return nil, fmt.Errorf("load capabilities: %w", err)
+	}
+	compilerOptions := conftest.CompilerOptions{
+		Strict:       r.Strict,
+		RegoVersion:  r.RegoVersion,
+		Capabilities: capabilities,
+	}
+	engine, err := conftest.LoadWithData(r.Policy, r.Data, compilerOptions)
+	if err != nil {
+		return nil, fmt.Errorf("load: %w", err)
+	}
+	engine.EnableInterQueryCache()
+
+	if r.Trace {
+		engine.EnableTracing()
+	}
+
+	return engine, nil
+}
+
 // NewConftestEvaluator returns initialized conftestEvaluator implementing
 // Evaluator interface
 func NewConftestEvaluator(ctx context.Context, policySources []source.PolicySource, p ConfigProvider, source ecc.Source) (Evaluator, error) {
@@ -318,6 +357,25 @@ func NewConftestEvaluatorWithNamespaceAndFilterType(
 		defer r.End()
 	}
 
+	c, err := newConftestEvaluator(ctx, policySources, p, source, namespace, filterType)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug("Conftest test runner created")
+	return c, nil
+}
+
+// newConftestEvaluator prepares the work, data and capabilities files shared by
+// the evaluators regardless of the runner used to evaluate the policies.
+func newConftestEvaluator(
+	ctx context.Context,
+	policySources []source.PolicySource,
+	p ConfigProvider,
+	source ecc.Source,
+	namespace []string,
+	filterType string,
+) (conftestEvaluator, error) {
 	fs := utils.FS(ctx)
 	c := conftestEvaluator{
 		policySources: policySources,
@@ -326,6 +384,7 @@ func NewConftestEvaluatorWithNamespaceAndFilterType(
 		fs:            fs,
 		namespace:     namespace,
 		source:        source,
+		compiled:      newCompiledCache(),
 	}
 
 	// Initialize the policy resolver based on filter type
@@ -346,23 +405,22 @@ func NewConftestEvaluatorWithNamespaceAndFilterType(
 	dir, err := utils.CreateWorkDir(fs)
 	if err != nil {
 		log.Debug("Failed to create work dir!")
-		return nil, err
+		return conftestEvaluator{}, err
 	}
 	c.workDir = dir
 	c.policyDir = filepath.Join(c.workDir, "policy")
 	c.dataDir = filepath.Join(c.workDir, "data")
 
 	if err := c.createDataDirectory(ctx); err != nil {
-		return nil, err
+		return conftestEvaluator{}, err
 	}
 
 	log.Debugf("Created work dir %s", dir)
 
 	if err := c.createCapabilitiesFile(ctx); err != nil {
-		return nil, err
+		return conftestEvaluator{}, err
 	}
 
-	log.Debug("Conftest test runner created")
 	return c, nil
 }
 
@@ -581,23 +639,22 @@ func (c conftestEvaluator) Evaluate(ctx context.Context, target EvaluationTarget
 			return nil, err
 		}
 
-		r = &conftestRunner{
-			runner.TestRunner{
-				Data:          dataDirs,
-				Policy:        []string{c.policyDir},
-				Namespace:     namespacesToUse,
-				AllNamespaces: allNamespaces, // Use all namespaces for legacy filtering
-				NoFail:        true,
-				Output:        c.outputFormat,
-				Capabilities:  c.CapabilitiesPath(),
-				RegoVersion:   "v1",
-			},
+		newRunner := c.runnerFactory
+		if newRunner == nil {
+			newRunner = newConftestRunner
 		}
+		r = newRunner(c, namespacesToUse, allNamespaces, dataDirs)
 	}
 
 	log.Debugf("runner: %#v", r)
 	log.Debugf("inputs: %#v", target.Inputs)
 
+	// The OSV vulnerability database is read from the data sources by the
+	// ec.vuln.match rego builtin
+	if osvDirs := c.osvDatabaseDirs(dataSourceDirs); len(osvDirs) > 0 {
+		ctx = vuln.WithDatabaseDirs(ctx, osvDirs)
+	}
+
 	runResults, err := r.Run(ctx, target.Inputs)
 	if err != nil {
 		// TODO do we want to evaluate further policies instead of erroring out?
@@ -702,6 +759,10 @@ func (c conftestEvaluator) prepareDataDirs(ctx context.Context, dataSourceDirs [
 
 	dirsWithDataFiles := make(map[string]bool)
 
+	// The OSV vulnerability databases are not loaded as data, the records
+	// would clash with each other and with the other data
+	osvDirs := sets.New(c.osvDatabaseDirs(dataSourceDirs)...)
+
 	// Walk each data source directory returned by GetPolicy
 	// These are the actual directories (possibly symlinks) where data was downloaded
 	// Walking them directly ensures we find files even if they're symlinks
@@ -715,6 +776,10 @@ func (c conftestEvaluator) prepareDataDirs(ctx context.Context, dataSourceDirs [
 				return err
 			}
 
+			if d.IsDir() && osvDirs.Has(path) {
+				return fs.SkipDir
+			}
+
 			// Only process files, not directories
 			if !d.IsDir() {
 				ext := filepath.Ext(d.Name())
@@ -747,6 +812,10 @@ func (c conftestEvaluator) prepareDataDirs(ctx context.Context, dataSourceDirs [
 			return nil
 		}
 
+		if d.IsDir() && osvDirs.Has(path) {
+			return fs.SkipDir
+		}
+
 		// Only process files, not directories
 		if !d.IsDir() {
 			ext := filepath.Ext(d.Name())
@@ -774,6 +843,56 @@ func (c conftestEvaluator) prepareDataDirs(ctx context.Context, dataSourceDirs [
 	return dataDirs, nil
 }
 
+// osvDatabaseDirs returns the directories holding the OSV vulnerability
+// database within the data source directories.
+func (c conftestEvaluator) osvDatabaseDirs(dataSourceDirs []string) []string {
+	dirs := []string{}
+	for _, dataSourceDir := range dataSourceDirs {
+		dir := filepath.Join(dataSourceDir, vuln.DatabaseDir)
+		if exists, err := afero.DirExists(c.fs, dir); err == nil && exists {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
+
+// toOutcomes converts the Conftest check results into outcomes, logging the
+// traces and outputs of the queries
+func toOutcomes(results output.CheckResults) []Outcome {
+	var outcomes []Outcome
+	for _, res := range results {
+		if log.IsLevelEnabled(log.TraceLevel) {
+			for _, q := range res.Queries {
+				for _, t := range q.Traces {
+					log.Tracef("[%s] %s", q.Query, t)
+				}
+			}
+		}
+		if log.IsLevelEnabled(log.DebugLevel) {
+			for _, q := range res.Queries {
+				for _, o := range q.Outputs {
+					log.Debugf("[%s] %s", q.Query, o)
+				}
+			}
+		}
+
+		outcomes = append(outcomes, Outcome{
+			FileName:  res.FileName,
+			Namespace: res.Namespace,
+			// Conftest doesn't give us a list of successes, just a count. Here we turn that count
+			// into a placeholder slice of that size to make processing easier later on.
+			Successes:  make([]Result, res.Successes),
+			Skipped:    toRules(res.Skipped),
+			Warnings:   toRules(res.Warnings),
+			Failures:   toRules(res.Failures),
+			Exceptions: toRules(res.Exceptions),
+		})
+	}
+
+	return outcomes
+}
+
 func toRules(results []output.Result) []Result {
 	var eResults []Result
 	for _, r := range results {
@@ -1078,7 +1197,10 @@ func (c *conftestEvaluator) createCapabilitiesFile(ctx context.Context) error {
 	return nil
 }
 
-func getSeverity(r Result) string {
+// GetSeverity returns the severity of the result as set in its metadata, either
+// SeverityFailure or SeverityWarning. An empty string is returned if the
+// severity is not set or has an unexpected value.
+func GetSeverity(r Result) string {
 	raw, found := r.Metadata[metadataSeverity]
 	if !found {
 		return ""
@@ -1090,7 +1212,7 @@ func getSeverity(r Result) string {
 	}
 
 	switch severity {
-	case severityFailure, severityWarning:
+	case SeverityFailure, SeverityWarning:
 		return severity
 	default:
 		log.Warnf("Ignoring unexpected %q value %s", metadataSeverity, severity)
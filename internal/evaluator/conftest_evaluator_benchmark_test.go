@@ -18,6 +18,7 @@
 // performance characteristics. It includes benchmarks for:
 // - Basic evaluation performance (BenchmarkConftestEvaluatorEvaluate)
 // - Large input evaluation performance (BenchmarkConftestEvaluatorWithLargeInput)
+// - Reuse of compiled policies across components (BenchmarkConftestEvaluatorManyComponents)
 // These benchmarks help identify performance bottlenecks and regressions
 // in the evaluator's performance.
 
@@ -27,6 +28,10 @@ package evaluator
 
 import (
 	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
 	"testing"
 	"time"
 
@@ -114,3 +119,75 @@ func BenchmarkConftestEvaluatorWithLargeInput(b *testing.B) {
 		require.NoError(b, err)
 	}
 }
+
+// BenchmarkConftestEvaluatorManyComponents evaluates a snapshot of many
+// components with the same evaluator. The "compiled once" case reuses the
+// compiled policies and data across components, the "compiled per component"
+// case disables the reuse, which is how the evaluator behaved previously.
+func BenchmarkConftestEvaluatorManyComponents(b *testing.B) {
+	const components = 100
+
+	dir := b.TempDir()
+	targets := make([]EvaluationTarget, 0, components)
+	for i := 0; i < components; i++ {
+		inputDir := path.Join(dir, fmt.Sprintf("component-%d", i))
+		require.NoError(b, os.MkdirAll(inputDir, 0755))
+		require.NoError(b, os.WriteFile(path.Join(inputDir, "input.json"), []byte("{}"), 0600))
+		targets = append(targets, EvaluationTarget{
+			Inputs: []string{inputDir},
+			Target: fmt.Sprintf("registry.io/repository/image-%d", i),
+		})
+	}
+
+	rego, err := fs.Sub(policies, "__testdir__/simple")
+	require.NoError(b, err)
+
+	rules, err := rulesArchiveFromFS(b, rego)
+	require.NoError(b, err)
+
+	ctx := withCapabilities(context.Background(), testCapabilities)
+
+	configProvider := &mockConfigProvider{}
+	configProvider.On("EffectiveTime").Return(time.Now())
+	configProvider.On("SigstoreOpts").Return(policy.SigstoreOpts{}, nil)
+	configProvider.On("Spec").Return(ecc.EnterpriseContractPolicySpec{})
+
+	// Downloaded policies are cached process wide, both evaluators need to be
+	// alive for the duration of the benchmark
+	evaluators := map[bool]conftestEvaluator{}
+	for _, reuse := range []bool{true, false} {
+		e, err := NewConftestEvaluator(ctx, []source.PolicySource{
+			&source.PolicyUrl{
+				Url:  rules,
+				Kind: source.PolicyKind,
+			},
+		}, configProvider, ecc.Source{})
+		require.NoError(b, err)
+		defer e.Destroy()
+
+		evaluator := e.(conftestEvaluator)
+		if !reuse {
+			evaluator.compiled = nil
+		}
+		evaluators[reuse] = evaluator
+	}
+
+	for _, reuse := range []bool{true, false} {
+		name := "compiled once"
+		if !reuse {
+			name = "compiled per component"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			evaluator := evaluators[reuse]
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, target := range targets {
+					_, err := evaluator.Evaluate(ctx, target)
+					require.NoError(b, err)
+				}
+			}
+		})
+	}
+}
@@ -140,7 +140,7 @@ func setupTestContext(r *mockTestRunner, dl *mockDownloader) context.Context {
 	return ctx
 }
 
-func rulesArchiveFromFS(t *testing.T, files fs.FS) (string, error) {
+func rulesArchiveFromFS(t testing.TB, files fs.FS) (string, error) {
 	t.Helper()
 
 	dir := t.TempDir()
@@ -71,6 +71,17 @@ func TestPrepareDataDirs(t *testing.T) {
 				"data/config",
 			},
 		},
+		{
+			name: "OSV database",
+			filePaths: []string{
+				"rule_data.yml",
+				"osv/npm/GHSA-1234-5678-9abc.json",
+				"osv/PyPI/PYSEC-2024-1.json",
+			},
+			expectedDirs: []string{
+				".",
+			},
+		},
 	}
 
 	for _, tt := range tests {
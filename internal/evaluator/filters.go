@@ -990,13 +990,13 @@ func (f *LegacyPostEvaluationFilter) CategorizeResults(
 		// Apply severity logic based on original type
 		switch originalType {
 		case "warning":
-			if getSeverity(result) == severityFailure {
+			if GetSeverity(result) == SeverityFailure {
 				failures = append(failures, result)
 			} else {
 				warnings = append(warnings, result)
 			}
 		case "failure":
-			if getSeverity(result) == severityWarning || !isResultEffective(result, effectiveTime) {
+			if GetSeverity(result) == SeverityWarning || !isResultEffective(result, effectiveTime) {
 				warnings = append(warnings, result)
 			} else {
 				failures = append(failures, result)
@@ -1113,13 +1113,13 @@ func (f *UnifiedPostEvaluationFilter) CategorizeResults(
 		// Apply severity logic based on original type
 		switch originalType {
 		case "warning":
-			if getSeverity(filteredResult) == severityFailure {
+			if GetSeverity(filteredResult) == SeverityFailure {
 				failures = append(failures, filteredResult)
 			} else {
 				warnings = append(warnings, filteredResult)
 			}
 		case "failure":
-			if getSeverity(filteredResult) == severityWarning || !isResultEffective(filteredResult, effectiveTime) {
+			if GetSeverity(filteredResult) == SeverityWarning || !isResultEffective(filteredResult, effectiveTime) {
 				warnings = append(warnings, filteredResult)
 			} else {
 				failures = append(failures, filteredResult)
@@ -17,25 +17,71 @@
 package evaluator
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io/fs"
+	"maps"
 	"os"
-	"path"
+	"path/filepath"
+	"regexp"
+	"runtime/trace"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 
-	"github.com/spf13/afero"
+	ecc "github.com/conforma/crds/api/v1alpha1"
+	"github.com/open-policy-agent/conftest/output"
+	"github.com/open-policy-agent/conftest/parser"
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/bundle"
+	"github.com/open-policy-agent/opa/v1/loader"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/open-policy-agent/opa/v1/storage"
+	"github.com/open-policy-agent/opa/v1/topdown"
+	"github.com/open-policy-agent/opa/v1/topdown/cache"
+	"github.com/open-policy-agent/opa/v1/topdown/print"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/conforma/cli/internal/policy/source"
+	"github.com/conforma/cli/internal/tracing"
+)
+
+var (
+	// These mirror the rule naming conventions used by Conftest so that both
+	// evaluators consider the same rules when evaluating a namespace.
+	warningRuleRegex = regexp.MustCompile("^warn(_[a-zA-Z0-9]+)*$")
+	failureRuleRegex = regexp.MustCompile("^(deny|violation)(_[a-zA-Z0-9]+)*$")
 )
 
-// not sure what the properties will be yet, so setting the minimum.
+const exceptionRule = "exception"
+
+// opaEvaluator evaluates policies using the OPA Go API directly instead of
+// going through the Conftest test runner. Everything besides the evaluation
+// itself, i.e. downloading the policy sources, collecting rule metadata and
+// filtering the results, is shared with the conftestEvaluator so that both
+// produce the same results.
 type opaEvaluator struct {
-	workDir string
-	fs      afero.Fs
+	conftestEvaluator
 }
 
-func NewOPAEvaluator() (Evaluator, error) {
-	return opaEvaluator{}, nil
-}
+// NewOPAEvaluator returns initialized opaEvaluator implementing Evaluator
+// interface
+func NewOPAEvaluator(ctx context.Context, policySources []source.PolicySource, p ConfigProvider, source ecc.Source, filterType string) (Evaluator, error) {
+	if trace.IsEnabled() {
+		r := trace.StartRegion(ctx, "ec:opa-create-evaluator")
+		defer r.End()
+	}
+
+	c, err := newConftestEvaluator(ctx, policySources, p, source, []string{}, filterType)
+	if err != nil {
+		return nil, err
+	}
+	c.runnerFactory = newOPARunner
 
-func (o opaEvaluator) Evaluate(ctx context.Context, target EvaluationTarget) ([]Outcome, error) {
-	return []Outcome{}, nil
+	log.Debug("OPA evaluator created")
+	return opaEvaluator{c}, nil
 }
 
 func (o opaEvaluator) Destroy() {
@@ -44,6 +90,576 @@ func (o opaEvaluator) Destroy() {
 	}
 }
 
-func (o opaEvaluator) CapabilitiesPath() string {
-	return path.Join(o.workDir, "capabilities.json")
+// opaRunner implements testRunner by compiling the policies and loading the
+// data using the OPA Go API.
+type opaRunner struct {
+	policy        []string
+	data          []string
+	namespaces    []string
+	allNamespaces bool
+	capabilities  string
+	compiled      *compiledCache
+}
+
+func newOPARunner(c conftestEvaluator, namespaces []string, allNamespaces bool, dataDirs []string) testRunner {
+	return &opaRunner{
+		policy:        []string{c.policyDir},
+		data:          dataDirs,
+		namespaces:    namespaces,
+		allNamespaces: allNamespaces,
+		capabilities:  c.CapabilitiesPath(),
+		compiled:      c.compiled,
+	}
+}
+
+// opaQuery is a prepared query for a single rule within a namespace
+type opaQuery struct {
+	rule      string
+	query     string
+	prepared  rego.PreparedEvalQuery
+	exception *rego.PreparedEvalQuery
+}
+
+// opaNamespace holds the prepared queries for a namespace and the number of
+// rules within it, which is needed to compute the count of successes.
+type opaNamespace struct {
+	name      string
+	ruleCount int
+	queries   []opaQuery
+}
+
+func (r opaRunner) Run(ctx context.Context, fileList []string) ([]Outcome, error) {
+	results, err := r.checkResults(ctx, fileList)
+	if err != nil {
+		return nil, err
+	}
+
+	return toOutcomes(results), nil
+}
+
+// checkResults evaluates the policies against the given files producing the
+// same results as Conftest's engine does.
+func (r opaRunner) checkResults(ctx context.Context, fileList []string) (output.CheckResults, error) {
+	if trace.IsEnabled() {
+		region := trace.StartRegion(ctx, "ec:opa-run")
+		defer region.End()
+	}
+
+	files, err := inputFiles(fileList)
+	if err != nil {
+		return nil, err
+	}
+
+	configurations, err := parser.ParseConfigurations(files)
+	if err != nil {
+		return nil, fmt.Errorf("parse configurations: %w", err)
+	}
+
+	traceEnabled := tracing.FromContext(ctx).Enabled(tracing.Opa)
+
+	// The policies and data are the same for all the targets evaluated by the
+	// evaluator, the namespaces can differ depending on the include/exclude
+	// criteria that apply to the target
+	key := compiledCacheKey("opa", r.policy, r.data, r.namespaces, []string{strconv.FormatBool(r.allNamespaces), strconv.FormatBool(traceEnabled)})
+	namespaces, err := cached(r.compiled, key, func() ([]opaNamespace, error) {
+		c, err := cached(r.compiled, compiledCacheKey("opa", r.policy, r.data), r.load)
+		if err != nil {
+			return nil, err
+		}
+
+		return r.prepare(ctx, c.compiler, c.modules, fileInfoStore{c.store}, traceEnabled)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.evaluate(ctx, namespaces, configurations, traceEnabled)
+}
+
+// evaluate evaluates the prepared queries of the namespaces against the given
+// configurations, each with its own data.conftest.file.
+func (r opaRunner) evaluate(ctx context.Context, namespaces []opaNamespace, configurations map[string]any, traceEnabled bool) (output.CheckResults, error) {
+	// Evaluate the configurations in a stable order, Conftest uses map
+	// iteration order here
+	fileNames := make([]string, 0, len(configurations))
+	for name := range configurations {
+		fileNames = append(fileNames, name)
+	}
+	sort.Strings(fileNames)
+
+	interQueryCache := cache.NewInterQueryCacheWithContext(ctx, nil)
+
+	var results output.CheckResults
+	for _, ns := range namespaces {
+		for _, fileName := range fileNames {
+			config := configurations[fileName]
+
+			fileCtx, err := withFileInfo(ctx, fileName)
+			if err != nil {
+				return nil, err
+			}
+
+			// Multi-document files are evaluated one document at a time and the
+			// results are aggregated under the same file name
+			documents := []any{config}
+			if subconfigs, ok := config.([]any); ok {
+				documents = subconfigs
+			}
+
+			result := output.CheckResult{
+				FileName:  fileName,
+				Namespace: ns.name,
+			}
+			for _, document := range documents {
+				s, err := r.check(fileCtx, ns, document, &result, interQueryCache, traceEnabled)
+				if err != nil {
+					return nil, err
+				}
+				result.Successes += s
+			}
+
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+// inputFiles expands the given list of files and directories into a list of
+// files supported by the configuration parser, as the Conftest runner does.
+func inputFiles(fileList []string) ([]string, error) {
+	var files []string
+	for _, file := range fileList {
+		if file == "" {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("get file info: %w", err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, file)
+			continue
+		}
+
+		err = filepath.WalkDir(file, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && parser.FileSupported(p) {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("walk path: %w", err)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files found")
+	}
+
+	return files, nil
+}
+
+// opaCompiled holds the compiled policies and the loaded data
+type opaCompiled struct {
+	compiler *ast.Compiler
+	modules  map[string]*ast.Module
+	store    storage.Store
+}
+
+func (r opaRunner) load() (opaCompiled, error) {
+	compiler, modules, err := r.compile()
+	if err != nil {
+		return opaCompiled{}, err
+	}
+
+	store, err := r.store()
+	if err != nil {
+		return opaCompiled{}, err
+	}
+
+	return opaCompiled{compiler: compiler, modules: modules, store: store}, nil
+}
+
+// compile loads and compiles all Rego files within the policy directories
+// using the provided capabilities.
+func (r opaRunner) compile() (*ast.Compiler, map[string]*ast.Module, error) {
+	capabilities, err := ast.LoadCapabilitiesFile(r.capabilities)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load capabilities: %w", err)
+	}
+
+	policies, err := loader.NewFileLoader().
+		WithProcessAnnotation(true).
+		WithRegoVersion(ast.RegoV1).
+		Filtered(r.policy, func(_ string, info os.FileInfo, _ int) bool {
+			return !info.IsDir() && !strings.HasSuffix(info.Name(), bundle.RegoExt)
+		})
+	if err != nil {
+		return nil, nil, fmt.Errorf("load: %w", err)
+	}
+	if len(policies.Modules) == 0 {
+		return nil, nil, fmt.Errorf("no policies found in %v", r.policy)
+	}
+
+	modules := policies.ParsedModules()
+	compiler := ast.NewCompiler().
+		WithEnablePrintStatements(true).
+		WithCapabilities(capabilities)
+	compiler.Compile(modules)
+	if compiler.Failed() {
+		return nil, nil, fmt.Errorf("get compiler: %w", compiler.Errors)
+	}
+
+	return compiler, modules, nil
+}
+
+// store loads all data documents from the data directories into an in-memory
+// store.
+func (r opaRunner) store() (storage.Store, error) {
+	documents, err := loader.NewFileLoader().Filtered(r.data, func(_ string, info os.FileInfo, _ int) bool {
+		if info.IsDir() {
+			return false
+		}
+		return !slices.Contains([]string{".yaml", ".yml", ".json"}, filepath.Ext(info.Name()))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load documents: %w", err)
+	}
+
+	store, err := documents.Store()
+	if err != nil {
+		return nil, fmt.Errorf("get documents store: %w", err)
+	}
+
+	return store, nil
+}
+
+// prepare creates prepared queries for each deny/violation/warn rule of the
+// namespaces being evaluated, the queries are reused for each input document.
+func (r opaRunner) prepare(ctx context.Context, compiler *ast.Compiler, modules map[string]*ast.Module, store storage.Store, traceEnabled bool) ([]opaNamespace, error) {
+	// Sort the modules by name so that the order of namespaces and rules is
+	// deterministic
+	moduleNames := make([]string, 0, len(modules))
+	for name := range modules {
+		moduleNames = append(moduleNames, name)
+	}
+	sort.Strings(moduleNames)
+
+	namespaceNames := r.namespaces
+	if r.allNamespaces {
+		namespaceNames = []string{}
+		for _, name := range moduleNames {
+			namespace := strings.Replace(modules[name].Package.Path.String(), "data.", "", 1)
+			if !slices.Contains(namespaceNames, namespace) {
+				namespaceNames = append(namespaceNames, namespace)
+			}
+		}
+	}
+
+	prepareQuery := func(query string) (rego.PreparedEvalQuery, error) {
+		return rego.New(
+			rego.Query(query),
+			rego.Compiler(compiler),
+			rego.Store(store),
+			rego.Trace(traceEnabled),
+			rego.EnablePrintStatements(true),
+		).PrepareForEval(ctx)
+	}
+
+	namespaces := make([]opaNamespace, 0, len(namespaceNames))
+	for _, namespace := range namespaceNames {
+		ns := opaNamespace{name: namespace}
+
+		rules := []string{}
+		hasExceptions := false
+		for _, name := range moduleNames {
+			module := modules[name]
+			if strings.Replace(module.Package.Path.String(), "data.", "", 1) != namespace {
+				continue
+			}
+
+			for _, rule := range module.Rules {
+				ruleName := rule.Head.Name.String()
+				if ruleName == exceptionRule {
+					hasExceptions = true
+				}
+				if !failureRuleRegex.MatchString(ruleName) && !warningRuleRegex.MatchString(ruleName) {
+					continue
+				}
+
+				// A rule can be defined with multiple bodies, each counts towards
+				// the number of rules but is queried only once
+				ns.ruleCount++
+				if !slices.Contains(rules, ruleName) {
+					rules = append(rules, ruleName)
+				}
+			}
+		}
+
+		for _, rule := range rules {
+			q := opaQuery{
+				rule:  rule,
+				query: fmt.Sprintf("data.%s.%s", namespace, rule),
+			}
+
+			var err error
+			if q.prepared, err = prepareQuery(q.query); err != nil {
+				return nil, fmt.Errorf("prepare query %q: %w", q.query, err)
+			}
+
+			if hasExceptions {
+				exceptionQuery := exceptionQueryFor(namespace, rule)
+				prepared, err := prepareQuery(exceptionQuery)
+				if err != nil {
+					return nil, fmt.Errorf("prepare query %q: %w", exceptionQuery, err)
+				}
+				q.exception = &prepared
+			}
+
+			ns.queries = append(ns.queries, q)
+		}
+
+		namespaces = append(namespaces, ns)
+	}
+
+	return namespaces, nil
+}
+
+// check evaluates all the prepared queries of the namespace against the given
+// document, results are appended to the check result and the number of
+// successes is returned.
+func (r opaRunner) check(ctx context.Context, ns opaNamespace, document any, checkResult *output.CheckResult, interQueryCache cache.InterQueryCache, traceEnabled bool) (int, error) {
+	successes := 0
+	resultCount := 0
+	for _, q := range ns.queries {
+		if q.exception != nil {
+			exceptionQuery := exceptionQueryFor(ns.name, q.rule)
+			rs, err := r.eval(ctx, *q.exception, exceptionQuery, document, interQueryCache, traceEnabled)
+			if err != nil {
+				return 0, fmt.Errorf("query exception: %w", err)
+			}
+
+			excepted := false
+			for _, result := range rs {
+				for _, expression := range result.Expressions {
+					if v, ok := expression.Value.(bool); ok && v {
+						excepted = true
+						checkResult.Exceptions = append(checkResult.Exceptions, output.Result{Message: exceptionQuery})
+						resultCount++
+					}
+				}
+			}
+
+			if excepted {
+				// Exceptions take precedence over the results of the rule
+				continue
+			}
+		}
+
+		rs, err := r.eval(ctx, q.prepared, q.query, document, interQueryCache, traceEnabled)
+		if err != nil {
+			return 0, fmt.Errorf("query rule: %w", err)
+		}
+
+		for _, result := range rs {
+			for _, expression := range result.Expressions {
+				values, _ := expression.Value.([]any)
+				if len(values) == 0 {
+					successes++
+					resultCount++
+					continue
+				}
+
+				for _, v := range values {
+					res, err := toResult(v, q.query)
+					if err != nil {
+						return 0, err
+					}
+					if res == nil {
+						continue
+					}
+
+					if failureRuleRegex.MatchString(q.rule) {
+						checkResult.Failures = append(checkResult.Failures, *res)
+					} else {
+						checkResult.Warnings = append(checkResult.Warnings, *res)
+					}
+					resultCount++
+				}
+			}
+		}
+	}
+
+	// Only a single success is reported for a rule even if it has multiple
+	// bodies, the difference between the number of rules and the number of
+	// results is counted as successes, same as Conftest does
+	if resultCount < ns.ruleCount {
+		successes += ns.ruleCount - resultCount
+	}
+
+	return successes, nil
+}
+
+// eval evaluates the prepared query against the given input, logging any
+// traces and print statement outputs.
+func (r opaRunner) eval(ctx context.Context, q rego.PreparedEvalQuery, query string, input any, interQueryCache cache.InterQueryCache, traceEnabled bool) (rego.ResultSet, error) {
+	ph := printHook{}
+	options := []rego.EvalOption{
+		rego.EvalInput(input),
+		rego.EvalPrintHook(&ph),
+		rego.EvalInterQueryBuiltinCache(interQueryCache),
+	}
+
+	var tracer *topdown.BufferTracer
+	if traceEnabled {
+		tracer = topdown.NewBufferTracer()
+		options = append(options, rego.EvalQueryTracer(tracer))
+	}
+
+	rs, err := q.Eval(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating policy: %w", err)
+	}
+
+	if tracer != nil && log.IsLevelEnabled(log.TraceLevel) {
+		buf := bytes.Buffer{}
+		topdown.PrettyTrace(&buf, *tracer)
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if len(line) > 0 {
+				log.Tracef("[%s] %s", query, line)
+			}
+		}
+	}
+
+	if log.IsLevelEnabled(log.DebugLevel) {
+		for _, o := range ph.outputs {
+			log.Debugf("[%s] %s", query, o)
+		}
+	}
+
+	return rs, nil
+}
+
+// toResult converts a single value produced by a deny/violation/warn rule into
+// a Result the same way Conftest does. Rules can either produce a string
+// message or an object with a "msg" attribute, any other attributes are
+// captured as metadata along with the query that produced the result.
+func toResult(value any, query string) (*output.Result, error) {
+	switch v := value.(type) {
+	case string:
+		return &output.Result{Message: v, Metadata: map[string]any{metadataQuery: query}}, nil
+	case map[string]any:
+		result, err := output.NewResult(v)
+		if err != nil {
+			return nil, fmt.Errorf("new result: %w", err)
+		}
+		result.Metadata[metadataQuery] = query
+
+		return &result, nil
+	default:
+		return nil, nil
+	}
+}
+
+// exceptionQueryFor returns the query used to find exceptions for the given
+// rule, the severity prefix is removed from the rule name when matching
+func exceptionQueryFor(namespace, rule string) string {
+	name := rule
+	if name == "violation" || name == "deny" || name == "warn" {
+		name = ""
+	}
+	for _, prefix := range []string{"violation_", "deny_", "warn_"} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+
+	return fmt.Sprintf("data.%s.%s[_][_] == %q", namespace, exceptionRule, name)
+}
+
+type fileInfoKey struct{}
+
+// withFileInfo returns a context holding the name and directory of the file
+// being evaluated, which fileInfoStore provides as data.conftest.file.
+func withFileInfo(ctx context.Context, path string) (context.Context, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("get absolute path: %w", err)
+	}
+
+	return context.WithValue(ctx, fileInfoKey{}, map[string]any{
+		"name": filepath.Base(abs),
+		"dir":  filepath.Dir(abs),
+	}), nil
+}
+
+// fileInfoStore provides the file being evaluated as data.conftest.file, as
+// Conftest does, on top of the store shared by all evaluations. The file is
+// taken from the context of the evaluation instead of being written to the
+// store so that concurrent evaluations do not see each other's file.
+type fileInfoStore struct {
+	storage.Store
+}
+
+func (s fileInfoStore) Read(ctx context.Context, txn storage.Transaction, path storage.Path) (any, error) {
+	info, ok := ctx.Value(fileInfoKey{}).(map[string]any)
+	if !ok {
+		return s.Store.Read(ctx, txn, path)
+	}
+
+	switch {
+	case len(path) >= 2 && path[0] == "conftest" && path[1] == "file":
+		var value any = info
+		for _, key := range path[2:] {
+			obj, ok := value.(map[string]any)
+			if !ok {
+				return nil, &storage.Error{Code: storage.NotFoundErr, Message: path.String() + ": document does not exist"}
+			}
+			if value, ok = obj[key]; !ok {
+				return nil, &storage.Error{Code: storage.NotFoundErr, Message: path.String() + ": document does not exist"}
+			}
+		}
+		return value, nil
+	case len(path) == 0 || (len(path) == 1 && path[0] == "conftest"):
+		doc, err := s.Store.Read(ctx, txn, path)
+		if err != nil && !storage.IsNotFound(err) {
+			return nil, err
+		}
+
+		conftestDoc := map[string]any{}
+		root := map[string]any{}
+		if obj, ok := doc.(map[string]any); ok {
+			if len(path) == 0 {
+				maps.Copy(root, obj)
+				if c, ok := obj["conftest"].(map[string]any); ok {
+					maps.Copy(conftestDoc, c)
+				}
+			} else {
+				maps.Copy(conftestDoc, obj)
+			}
+		}
+		conftestDoc["file"] = info
+
+		if len(path) == 0 {
+			root["conftest"] = conftestDoc
+			return root, nil
+		}
+		return conftestDoc, nil
+	default:
+		return s.Store.Read(ctx, txn, path)
+	}
+}
+
+// printHook captures the output of print statements in the policies
+type printHook struct {
+	outputs []string
+}
+
+func (ph *printHook) Print(pctx print.Context, msg string) error {
+	ph.outputs = append(ph.outputs, fmt.Sprintf("%v: %s", pctx.Location, msg))
+	return nil
 }
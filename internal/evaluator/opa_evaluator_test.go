@@ -17,27 +17,69 @@
 package evaluator
 
 import (
-	"context"
+	"encoding/json"
 	"os"
 	"testing"
 
+	"github.com/open-policy-agent/conftest/output"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 )
 
-// TestNewOPAEvaluator tests the constructor NewOPAEvaluator.
-func TestNewOPAEvaluator(t *testing.T) {
-	evaluator, err := NewOPAEvaluator()
-	assert.NoError(t, err, "Expected no error from NewOPAEvaluator")
-	assert.Equal(t, evaluator, opaEvaluator{})
-}
+func TestToResult(t *testing.T) {
+	cases := []struct {
+		name     string
+		value    any
+		expected *output.Result
+		err      string
+	}{
+		{
+			name:     "string message",
+			value:    "Fails always",
+			expected: &output.Result{Message: "Fails always", Metadata: map[string]any{"query": "data.main.deny"}},
+		},
+		{
+			name: "object with metadata",
+			value: map[string]any{
+				"msg":  "Fails always",
+				"code": "main.rejector",
+				"_loc": map[string]any{"file": "input.json", "line": json.Number("3")},
+			},
+			expected: &output.Result{
+				Message:  "Fails always",
+				Location: &output.Location{File: "input.json", Line: json.Number("3")},
+				Metadata: map[string]any{"code": "main.rejector", "query": "data.main.deny"},
+			},
+		},
+		{
+			name:  "object without message",
+			value: map[string]any{"code": "main.rejector"},
+			err:   `new result: "msg" field must be present and a string`,
+		},
+		{
+			name:  "unsupported value",
+			value: true,
+		},
+	}
 
-func TestEvaluate(t *testing.T) {
-	opaEval := opaEvaluator{}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := toResult(c.value, "data.main.deny")
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, result)
+		})
+	}
+}
 
-	outcomes, err := opaEval.Evaluate(context.Background(), EvaluationTarget{})
-	assert.NoError(t, err, "Expected no error from Evaluate")
-	assert.Equal(t, []Outcome{}, outcomes)
+func TestExceptionQueryFor(t *testing.T) {
+	assert.Equal(t, `data.main.exception[_][_] == ""`, exceptionQueryFor("main", "deny"))
+	assert.Equal(t, `data.main.exception[_][_] == "image"`, exceptionQueryFor("main", "deny_image"))
+	assert.Equal(t, `data.main.exception[_][_] == "image"`, exceptionQueryFor("main", "warn_image"))
+	assert.Equal(t, `data.a.b.exception[_][_] == "image"`, exceptionQueryFor("a.b", "violation_image"))
 }
 
 // Test Destroy method of opaEvaluator.
@@ -88,10 +130,10 @@ func TestDestroy(t *testing.T) {
 			}
 
 			// Initialize the evaluator
-			opaEval := opaEvaluator{
+			opaEval := opaEvaluator{conftestEvaluator{
 				workDir: tc.workDir,
 				fs:      fs,
-			}
+			}}
 
 			// Call Destroy
 			opaEval.Destroy()
@@ -139,10 +181,10 @@ func TestCapabilitiesPath(t *testing.T) {
 			fs := afero.NewMemMapFs()
 
 			// Initialize the evaluator with test data
-			opaEval := opaEvaluator{
+			opaEval := opaEvaluator{conftestEvaluator{
 				workDir: tc.workDir,
 				fs:      fs,
-			}
+			}}
 
 			// Call CapabilitiesPath
 			result := opaEval.CapabilitiesPath()
@@ -0,0 +1,261 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// This file contains unit tests for the OPA Evaluator verifying that it
+// produces the same results as the Conftest Evaluator for the same policies.
+
+//go:build unit
+
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ecc "github.com/conforma/crds/api/v1alpha1"
+	"github.com/open-policy-agent/conftest/parser"
+	"github.com/open-policy-agent/conftest/runner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conforma/cli/internal/policy"
+	"github.com/conforma/cli/internal/policy/source"
+)
+
+func sortOutcomes(results []Outcome) {
+	sort.Slice(results, func(l, r int) bool {
+		return strings.Compare(results[l].Namespace, results[r].Namespace) < 0
+	})
+
+	for i := range results {
+		sort.Slice(results[i].Successes, func(l, r int) bool {
+			return strings.Compare(results[i].Successes[l].Metadata[metadataCode].(string), results[i].Successes[r].Metadata[metadataCode].(string)) < 0
+		})
+	}
+}
+
+func TestOPAEvaluatorMatchesConftestEvaluator(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(dir, "inputs"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(dir, "inputs", "data.json"), []byte("{}"), 0600))
+
+	rego, err := fs.Sub(policies, "__testdir__/simple")
+	require.NoError(t, err)
+
+	rules, err := rulesArchiveFromFS(t, rego)
+	require.NoError(t, err)
+
+	ctx := withCapabilities(context.Background(), testCapabilities)
+
+	eTime, err := time.Parse(policy.DateFormat, "2014-05-31")
+	require.NoError(t, err)
+	config := &mockConfigProvider{}
+	config.On("EffectiveTime").Return(eTime)
+	config.On("SigstoreOpts").Return(policy.SigstoreOpts{}, nil)
+	config.On("Spec").Return(ecc.EnterpriseContractPolicySpec{})
+
+	policySources := []source.PolicySource{
+		&source.PolicyUrl{
+			Url:  rules,
+			Kind: source.PolicyKind,
+		},
+	}
+	target := EvaluationTarget{Inputs: []string{path.Join(dir, "inputs")}}
+
+	conftest, err := NewConftestEvaluatorWithFilterType(ctx, policySources, config, ecc.Source{}, "include-exclude")
+	require.NoError(t, err)
+	defer conftest.Destroy()
+
+	expected, err := conftest.Evaluate(ctx, target)
+	require.NoError(t, err)
+
+	opa, err := NewOPAEvaluator(ctx, policySources, config, ecc.Source{}, "include-exclude")
+	require.NoError(t, err)
+	defer opa.Destroy()
+
+	results, err := opa.Evaluate(ctx, target)
+	require.NoError(t, err)
+
+	sortOutcomes(expected)
+	sortOutcomes(results)
+	assert.Equal(t, expected, results)
+}
+
+func TestOPAEvaluatorExcludes(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(dir, "inputs"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(dir, "inputs", "data.json"), []byte("{}"), 0600))
+
+	rego, err := fs.Sub(policies, "__testdir__/simple")
+	require.NoError(t, err)
+
+	rules, err := rulesArchiveFromFS(t, rego)
+	require.NoError(t, err)
+
+	ctx := withCapabilities(context.Background(), testCapabilities)
+
+	config := &mockConfigProvider{}
+	config.On("EffectiveTime").Return(time.Now())
+	config.On("SigstoreOpts").Return(policy.SigstoreOpts{}, nil)
+	config.On("Spec").Return(ecc.EnterpriseContractPolicySpec{})
+
+	opa, err := NewOPAEvaluator(ctx, []source.PolicySource{
+		&source.PolicyUrl{
+			Url:  rules,
+			Kind: source.PolicyKind,
+		},
+	}, config, ecc.Source{
+		Config: &ecc.SourceConfig{
+			Exclude: []string{"a.failure", "b"},
+		},
+	}, "include-exclude")
+	require.NoError(t, err)
+	defer opa.Destroy()
+
+	results, err := opa.Evaluate(ctx, EvaluationTarget{Inputs: []string{path.Join(dir, "inputs")}})
+	require.NoError(t, err)
+
+	codes := []string{}
+	for _, r := range results {
+		for _, f := range r.Failures {
+			codes = append(codes, f.Metadata[metadataCode].(string))
+		}
+		for _, w := range r.Warnings {
+			codes = append(codes, w.Metadata[metadataCode].(string))
+		}
+	}
+	assert.Equal(t, []string{"a.warning"}, codes)
+}
+
+func TestOPARunnerMatchesConftestEngine(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"policy", "data", "input"} {
+		require.NoError(t, os.MkdirAll(path.Join(dir, d), 0755))
+	}
+	require.NoError(t, os.WriteFile(path.Join(dir, "policy", "file.rego"), []byte(`package file
+
+import rego.v1
+
+deny contains result if {
+	result := {
+		"code": "file.name",
+		"msg": sprintf("Evaluated %s in %s", [data.conftest.file.name, data.conftest.file.dir]),
+	}
+}
+
+warn contains msg if {
+	data.config.warn
+	msg := sprintf("Warning for %s", [data.conftest.file.name])
+}
+
+deny_exception contains "Excepted!"
+
+exception contains ["exception"]
+`), 0600))
+	require.NoError(t, os.WriteFile(path.Join(dir, "data", "config.json"), []byte(`{"config": {"warn": true}}`), 0600))
+	input := path.Join(dir, "input", "input.json")
+	require.NoError(t, os.WriteFile(input, []byte(`{}`), 0600))
+	capabilities := path.Join(dir, "capabilities.json")
+	require.NoError(t, os.WriteFile(capabilities, []byte(testCapabilities), 0600))
+
+	ctx := context.Background()
+
+	conftest := conftestRunner{
+		TestRunner: runner.TestRunner{
+			Policy:       []string{path.Join(dir, "policy")},
+			Data:         []string{path.Join(dir, "data")},
+			Capabilities: capabilities,
+			RegoVersion:  "v1",
+		},
+	}
+	engine, err := conftest.load()
+	require.NoError(t, err)
+
+	configurations, err := parser.ParseConfigurations([]string{input})
+	require.NoError(t, err)
+	expected, err := engine.Check(ctx, configurations, "file")
+	require.NoError(t, err)
+
+	opa := opaRunner{
+		policy:       []string{path.Join(dir, "policy")},
+		data:         []string{path.Join(dir, "data")},
+		namespaces:   []string{"file"},
+		capabilities: capabilities,
+		compiled:     newCompiledCache(),
+	}
+	results, err := opa.checkResults(ctx, []string{input})
+	require.NoError(t, err)
+
+	// The OPA runner logs the traces and outputs of the queries as it
+	// evaluates them instead of returning them
+	for i := range expected {
+		expected[i].Queries = nil
+	}
+	assert.Equal(t, expected, results)
+
+	require.Len(t, results, 1)
+	require.Len(t, results[0].Failures, 1)
+	assert.Equal(t, fmt.Sprintf("Evaluated input.json in %s", path.Join(dir, "input")), results[0].Failures[0].Message)
+	assert.Equal(t, "data.file.deny", results[0].Failures[0].Metadata[metadataQuery])
+	require.Len(t, results[0].Warnings, 1)
+	assert.Equal(t, "Warning for input.json", results[0].Warnings[0].Message)
+	assert.Equal(t, "data.file.warn", results[0].Warnings[0].Metadata[metadataQuery])
+}
+
+func TestOPARunnerFileInfoConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(dir, "policy"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(dir, "policy", "file.rego"), []byte(`package file
+
+import rego.v1
+
+deny contains data.conftest.file.dir
+`), 0600))
+	capabilities := path.Join(dir, "capabilities.json")
+	require.NoError(t, os.WriteFile(capabilities, []byte(testCapabilities), 0600))
+
+	opa := opaRunner{
+		policy:       []string{path.Join(dir, "policy")},
+		namespaces:   []string{"file"},
+		capabilities: capabilities,
+		compiled:     newCompiledCache(),
+	}
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		inputDir := path.Join(dir, fmt.Sprintf("input-%d", i))
+		require.NoError(t, os.MkdirAll(inputDir, 0755))
+		require.NoError(t, os.WriteFile(path.Join(inputDir, "input.json"), []byte(`{}`), 0600))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results, err := opa.checkResults(context.Background(), []string{path.Join(inputDir, "input.json")})
+			if assert.NoError(t, err) && assert.Len(t, results, 1) && assert.Len(t, results[0].Failures, 1) {
+				assert.Equal(t, inputDir, results[0].Failures[0].Message)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,204 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package files
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"runtime/trace"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/conforma/cli/internal/utils/oci"
+)
+
+// Types of the layer file entries
+const (
+	TypeFile      = "file"
+	TypeDirectory = "dir"
+	TypeSymlink   = "symlink"
+	TypeHardlink  = "hardlink"
+	TypeChar      = "char"
+	TypeBlock     = "block"
+	TypeFIFO      = "fifo"
+	// TypeWhiteout marks the path as deleted from the lower layers
+	TypeWhiteout = "whiteout"
+	// TypeOpaque marks the directory as opaque, hiding the contents of the
+	// directory in the lower layers
+	TypeOpaque = "opaque"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	opaqueWhiteout = ".wh..wh..opq"
+)
+
+// Layer is the listing of the files within an image layer.
+type Layer struct {
+	Digest    string      `json:"digest"`
+	DiffID    string      `json:"diffID"`
+	MediaType string      `json:"mediaType"`
+	Files     []LayerFile `json:"files"`
+}
+
+// LayerFile is a single entry of a layer.
+type LayerFile struct {
+	// Path is the absolute path of the entry, for whiteouts the path of the
+	// deleted entry, and for opaque whiteouts the path of the opaque directory
+	Path string `json:"path"`
+	Type string `json:"type"`
+	// Mode holds the permission bits, including the setuid, setgid and sticky
+	// bits
+	Mode int64 `json:"mode"`
+	Size int64 `json:"size"`
+	UID  int   `json:"uid"`
+	GID  int   `json:"gid"`
+	// Link is the target of symbolic and hard links
+	Link string `json:"link"`
+}
+
+// LayerFiles lists the files within each layer of the image, in layer order.
+// The layers are streamed one at a time and the file contents are never read
+// into memory.
+func LayerFiles(ctx context.Context, ref name.Reference) ([]Layer, error) {
+	if trace.IsEnabled() {
+		region := trace.StartRegion(ctx, "ec:image-fetch-layer-files")
+		defer region.End()
+		trace.Logf(ctx, "", "image=%q", ref)
+	}
+
+	img, err := oci.NewClient(ctx).Image(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Layer, 0, len(layers))
+	for _, layer := range layers {
+		l, err := layerFiles(layer)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, l)
+	}
+
+	return result, nil
+}
+
+func layerFiles(layer v1.Layer) (Layer, error) {
+	digest, err := layer.Digest()
+	if err != nil {
+		return Layer{}, err
+	}
+
+	diffID, err := layer.DiffID()
+	if err != nil {
+		return Layer{}, err
+	}
+
+	mediaType, err := layer.MediaType()
+	if err != nil {
+		return Layer{}, err
+	}
+
+	content, err := layer.Uncompressed()
+	if err != nil {
+		return Layer{}, err
+	}
+	defer content.Close()
+
+	files, err := listFiles(tar.NewReader(content))
+	if err != nil {
+		return Layer{}, fmt.Errorf("reading layer %s: %w", digest, err)
+	}
+
+	return Layer{
+		Digest:    digest.String(),
+		DiffID:    diffID.String(),
+		MediaType: string(mediaType),
+		Files:     files,
+	}, nil
+}
+
+func listFiles(archive *tar.Reader) ([]LayerFile, error) {
+	files := []LayerFile{}
+	for {
+		header, err := archive.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		files = append(files, layerFile(header))
+	}
+
+	return files, nil
+}
+
+func layerFile(header *tar.Header) LayerFile {
+	file := LayerFile{
+		Path: path.Join("/", header.Name),
+		Mode: header.Mode & 0o7777,
+		Size: header.Size,
+		UID:  header.Uid,
+		GID:  header.Gid,
+	}
+
+	dir, base := path.Split(file.Path)
+	switch {
+	case base == opaqueWhiteout:
+		file.Path = path.Clean(dir)
+		file.Type = TypeOpaque
+		return file
+	case strings.HasPrefix(base, whiteoutPrefix):
+		file.Path = path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix))
+		file.Type = TypeWhiteout
+		return file
+	}
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		file.Type = TypeDirectory
+	case tar.TypeSymlink:
+		file.Type = TypeSymlink
+		file.Link = header.Linkname
+	case tar.TypeLink:
+		file.Type = TypeHardlink
+		file.Link = path.Join("/", header.Linkname)
+	case tar.TypeChar:
+		file.Type = TypeChar
+	case tar.TypeBlock:
+		file.Type = TypeBlock
+	case tar.TypeFifo:
+		file.Type = TypeFIFO
+	default:
+		file.Type = TypeFile
+	}
+
+	return file
+}
@@ -0,0 +1,168 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package files
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conforma/cli/internal/utils/oci"
+	"github.com/conforma/cli/internal/utils/oci/fake"
+)
+
+func tarLayer(t *testing.T, headers ...tar.Header) v1.Layer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	for _, h := range headers {
+		require.NoError(t, w.WriteHeader(&h))
+		if h.Typeflag == tar.TypeReg {
+			_, err := w.Write(bytes.Repeat([]byte{'x'}, int(h.Size)))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, w.Close())
+
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	})
+	require.NoError(t, err)
+
+	return layer
+}
+
+func TestLayerFiles(t *testing.T) {
+	ref := name.MustParseReference("registry.io/repository/image:tag")
+
+	base := tarLayer(t,
+		tar.Header{Name: "bin/", Typeflag: tar.TypeDir, Mode: 0o755},
+		tar.Header{Name: "bin/su", Typeflag: tar.TypeReg, Mode: 0o4755, Size: 3},
+		tar.Header{Name: "./etc/passwd", Typeflag: tar.TypeReg, Mode: 0o644, Size: 5, Uid: 1, Gid: 2},
+		tar.Header{Name: "bin/sh", Typeflag: tar.TypeSymlink, Mode: 0o777, Linkname: "bash"},
+		tar.Header{Name: "bin/bash2", Typeflag: tar.TypeLink, Linkname: "bin/su"},
+		tar.Header{Name: "dev/null", Typeflag: tar.TypeChar, Mode: 0o666},
+		tar.Header{Name: "dev/sda", Typeflag: tar.TypeBlock, Mode: 0o660},
+		tar.Header{Name: "run/pipe", Typeflag: tar.TypeFifo, Mode: 0o600},
+	)
+	top := tarLayer(t,
+		tar.Header{Name: "etc/.wh.passwd", Typeflag: tar.TypeReg},
+		tar.Header{Name: "tmp/.wh..wh..opq", Typeflag: tar.TypeReg},
+		tar.Header{Name: "tmp/key.pem", Typeflag: tar.TypeReg, Mode: 0o600, Size: 1},
+	)
+
+	image, err := mutate.AppendLayers(empty.Image, base, top)
+	require.NoError(t, err)
+
+	client := fake.FakeClient{}
+	client.On("Image", ref).Return(image, nil)
+
+	ctx := oci.WithClient(context.Background(), &client)
+
+	layers, err := LayerFiles(ctx, ref)
+	require.NoError(t, err)
+	require.Len(t, layers, 2)
+
+	for i, layer := range []v1.Layer{base, top} {
+		digest, err := layer.Digest()
+		require.NoError(t, err)
+		diffID, err := layer.DiffID()
+		require.NoError(t, err)
+		mediaType, err := layer.MediaType()
+		require.NoError(t, err)
+
+		assert.Equal(t, digest.String(), layers[i].Digest)
+		assert.Equal(t, diffID.String(), layers[i].DiffID)
+		assert.Equal(t, string(mediaType), layers[i].MediaType)
+	}
+
+	assert.Equal(t, []LayerFile{
+		{Path: "/bin", Type: TypeDirectory, Mode: 0o755},
+		{Path: "/bin/su", Type: TypeFile, Mode: 0o4755, Size: 3},
+		{Path: "/etc/passwd", Type: TypeFile, Mode: 0o644, Size: 5, UID: 1, GID: 2},
+		{Path: "/bin/sh", Type: TypeSymlink, Mode: 0o777, Link: "bash"},
+		{Path: "/bin/bash2", Type: TypeHardlink, Link: "/bin/su"},
+		{Path: "/dev/null", Type: TypeChar, Mode: 0o666},
+		{Path: "/dev/sda", Type: TypeBlock, Mode: 0o660},
+		{Path: "/run/pipe", Type: TypeFIFO, Mode: 0o600},
+	}, layers[0].Files)
+
+	assert.Equal(t, []LayerFile{
+		{Path: "/etc/passwd", Type: TypeWhiteout},
+		{Path: "/tmp", Type: TypeOpaque},
+		{Path: "/tmp/key.pem", Type: TypeFile, Mode: 0o600, Size: 1},
+	}, layers[1].Files)
+}
+
+func TestLayerFilesEmptyImage(t *testing.T) {
+	ref := name.MustParseReference("registry.io/repository/image:tag")
+
+	client := fake.FakeClient{}
+	client.On("Image", ref).Return(empty.Image, nil)
+
+	ctx := oci.WithClient(context.Background(), &client)
+
+	layers, err := LayerFiles(ctx, ref)
+	require.NoError(t, err)
+	assert.Empty(t, layers)
+}
+
+func TestLayerFilesErrors(t *testing.T) {
+	ref := name.MustParseReference("registry.io/repository/image:tag")
+
+	t.Run("remote error", func(t *testing.T) {
+		client := fake.FakeClient{}
+		client.On("Image", ref).Return(nil, errors.New("kaboom!"))
+
+		ctx := oci.WithClient(context.Background(), &client)
+
+		_, err := LayerFiles(ctx, ref)
+		assert.EqualError(t, err, "kaboom!")
+	})
+
+	t.Run("malformed layer", func(t *testing.T) {
+		layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader([]byte("not a tar archive"))), nil
+		})
+		require.NoError(t, err)
+
+		image, err := mutate.AppendLayers(empty.Image, layer)
+		require.NoError(t, err)
+
+		client := fake.FakeClient{}
+		client.On("Image", ref).Return(image, nil)
+
+		ctx := oci.WithClient(context.Background(), &client)
+
+		_, err = LayerFiles(ctx, ref)
+		assert.ErrorContains(t, err, "reading layer sha256:")
+	})
+}
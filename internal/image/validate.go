@@ -19,13 +19,17 @@ package image
 import (
 	"context"
 	"encoding/json"
+	"os"
 	"runtime/trace"
 	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	app "github.com/konflux-ci/application-api/api/v1alpha1"
 	"github.com/qri-io/jsonpointer"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/conforma/cli/internal/attestation"
 	"github.com/conforma/cli/internal/evaluation_target/application_snapshot_image"
@@ -35,6 +39,11 @@ import (
 	"github.com/conforma/cli/internal/validate/vsa"
 )
 
+const (
+	evaluatorWorkersEnvVar  = "EC_EVALUATOR_WORKERS"
+	defaultEvaluatorWorkers = 4
+)
+
 // ValidateImage executes the required method calls to evaluate a given policy
 // against a given image url.
 func ValidateImage(ctx context.Context, comp app.SnapshotComponent, snap *app.SnapshotSpec, p policy.Policy, evaluators []evaluator.Evaluator, detailed bool) (*output.Output, error) {
@@ -118,28 +127,20 @@ func ValidateImage(ctx context.Context, comp app.SnapshotComponent, snap *app.Sn
 		return nil, err
 	}
 
-	var allResults []evaluator.Outcome
-
-	for _, e := range evaluators {
-		// Todo maybe: Handle each one concurrently
-		target := evaluator.EvaluationTarget{
-			Inputs:        []string{inputPath},
-			ComponentName: comp.Name,
-		}
-		if ref := a.ImageReference(ctx); ref == "" {
-			log.Debug("Problem getting image reference")
-		} else {
-			target.Target = ref
-		}
-
-		results, err := e.Evaluate(ctx, target)
-		log.Debug("\n\nRunning conftest policy check\n\n")
+	target := evaluator.EvaluationTarget{
+		Inputs:        []string{inputPath},
+		ComponentName: comp.Name,
+	}
+	if ref := a.ImageReference(ctx); ref == "" {
+		log.Debug("Problem getting image reference")
+	} else {
+		target.Target = ref
+	}
 
-		if err != nil {
-			log.Debug("Problem running conftest policy check!")
-			return nil, err
-		}
-		allResults = append(allResults, results...)
+	allResults, err := evaluate(ctx, evaluators, target)
+	if err != nil {
+		log.Debug("Problem running conftest policy check!")
+		return nil, err
 	}
 
 	out.PolicyInput = inputJSON
@@ -150,6 +151,70 @@ func ValidateImage(ctx context.Context, comp app.SnapshotComponent, snap *app.Sn
 	return out, nil
 }
 
+// evaluate runs all the evaluators against the target concurrently, with the
+// number of evaluators running at the same time limited by evaluatorWorkers.
+// The outcomes are returned in the order of the evaluators, regardless of the
+// order in which the evaluators finish. The first error cancels the remaining
+// evaluations, as does the cancellation of the given context, e.g. when the
+// --timeout is exceeded.
+func evaluate(ctx context.Context, evaluators []evaluator.Evaluator, target evaluator.EvaluationTarget) ([]evaluator.Outcome, error) {
+	if trace.IsEnabled() {
+		region := trace.StartRegion(ctx, "ec:evaluate")
+		defer region.End()
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(evaluatorWorkers())
+
+	results := make([][]evaluator.Outcome, len(evaluators))
+	for i, e := range evaluators {
+		g.Go(func() error {
+			// Do not start evaluating if the context has been cancelled while
+			// waiting for a free worker
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+
+			log.Debug("\n\nRunning conftest policy check\n\n")
+			r, err := e.Evaluate(gctx, target)
+			if err != nil {
+				return err
+			}
+			results[i] = r
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var allResults []evaluator.Outcome
+	for _, r := range results {
+		allResults = append(allResults, r...)
+	}
+
+	return allResults, nil
+}
+
+// evaluatorWorkers returns the maximum number of evaluators run concurrently
+// for a single image, configurable via the EC_EVALUATOR_WORKERS environment
+// variable. The variable is read only once.
+var evaluatorWorkers = sync.OnceValue(parseEvaluatorWorkers)
+
+func parseEvaluatorWorkers() int {
+	workers := defaultEvaluatorWorkers
+	if value, exists := os.LookupEnv(evaluatorWorkersEnvVar); exists {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			workers = parsed
+		} else {
+			log.Warnf("Ignoring invalid %s value %q, using %d", evaluatorWorkersEnvVar, value, workers)
+		}
+	}
+	return workers
+}
+
 // ValidateImageWithVSACheck executes validation with VSA expiration checking.
 // If a valid, unexpired VSA exists, validation is skipped.
 func ValidateImageWithVSACheck(ctx context.Context, comp app.SnapshotComponent, snap *app.SnapshotSpec, p policy.Policy, evaluators []evaluator.Evaluator, detailed bool, vsaChecker *vsa.VSAChecker, vsaExpiration time.Duration) (*output.Output, error) {
@@ -25,6 +25,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -342,7 +343,7 @@ func TestEvaluatorLifecycle(t *testing.T) {
 	require.NoError(t, err)
 
 	e := &mockEvaluator{}
-	e.On("Evaluate", ctx, mock.Anything).Return([]evaluator.Outcome{}, nil)
+	e.On("Evaluate", mock.Anything, mock.Anything).Return([]evaluator.Outcome{}, nil)
 
 	// e.Destroy() should not be invoked
 
@@ -426,6 +427,136 @@ func (e *mockEvaluatorWithCapture) CapabilitiesPath() string {
 	return ""
 }
 
+// funcEvaluator is an evaluator that delegates the evaluation to a function
+type funcEvaluator func(ctx context.Context, target evaluator.EvaluationTarget) ([]evaluator.Outcome, error)
+
+func (f funcEvaluator) Evaluate(ctx context.Context, target evaluator.EvaluationTarget) ([]evaluator.Outcome, error) {
+	return f(ctx, target)
+}
+
+func (f funcEvaluator) Destroy() {}
+
+func (f funcEvaluator) CapabilitiesPath() string {
+	return ""
+}
+
+// setEvaluatorWorkers overrides the number of evaluator workers for the
+// duration of the test
+func setEvaluatorWorkers(t *testing.T, workers int) {
+	t.Helper()
+
+	orig := evaluatorWorkers
+	evaluatorWorkers = func() int { return workers }
+	t.Cleanup(func() { evaluatorWorkers = orig })
+}
+
+func TestEvaluateOrdering(t *testing.T) {
+	setEvaluatorWorkers(t, 3)
+
+	// evaluators finish in the reverse order they were given in
+	evaluators := []evaluator.Evaluator{}
+	for i := 0; i < 3; i++ {
+		evaluators = append(evaluators, funcEvaluator(func(ctx context.Context, target evaluator.EvaluationTarget) ([]evaluator.Outcome, error) {
+			time.Sleep(time.Duration(3-i) * 10 * time.Millisecond)
+			return []evaluator.Outcome{{Namespace: fmt.Sprintf("ns%d", i)}}, nil
+		}))
+	}
+
+	results, err := evaluate(context.Background(), evaluators, evaluator.EvaluationTarget{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []evaluator.Outcome{{Namespace: "ns0"}, {Namespace: "ns1"}, {Namespace: "ns2"}}, results)
+}
+
+func TestEvaluateConcurrencyLimit(t *testing.T) {
+	setEvaluatorWorkers(t, 2)
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	e := funcEvaluator(func(ctx context.Context, target evaluator.EvaluationTarget) ([]evaluator.Outcome, error) {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return []evaluator.Outcome{}, nil
+	})
+
+	_, err := evaluate(context.Background(), []evaluator.Evaluator{e, e, e, e, e}, evaluator.EvaluationTarget{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, maxRunning)
+}
+
+func TestEvaluateError(t *testing.T) {
+	expected := errors.New("expected")
+
+	started := make(chan struct{})
+	failing := funcEvaluator(func(ctx context.Context, target evaluator.EvaluationTarget) ([]evaluator.Outcome, error) {
+		<-started
+		return nil, expected
+	})
+
+	cancelled := make(chan struct{})
+	waiting := funcEvaluator(func(ctx context.Context, target evaluator.EvaluationTarget) ([]evaluator.Outcome, error) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	})
+
+	_, err := evaluate(context.Background(), []evaluator.Evaluator{waiting, failing}, evaluator.EvaluationTarget{})
+	assert.ErrorIs(t, err, expected)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		assert.Fail(t, "remaining evaluation was not cancelled")
+	}
+}
+
+func TestEvaluateTimeout(t *testing.T) {
+	setEvaluatorWorkers(t, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	started := 0
+	e := funcEvaluator(func(ctx context.Context, target evaluator.EvaluationTarget) ([]evaluator.Outcome, error) {
+		started++
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	_, err := evaluate(ctx, []evaluator.Evaluator{e, e, e}, evaluator.EvaluationTarget{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, started)
+}
+
+func TestEvaluatorWorkers(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected int
+	}{
+		{value: "8", expected: 8},
+		{value: "0", expected: defaultEvaluatorWorkers},
+		{value: "-1", expected: defaultEvaluatorWorkers},
+		{value: "many", expected: defaultEvaluatorWorkers},
+	}
+
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			t.Setenv(evaluatorWorkersEnvVar, c.value)
+			assert.Equal(t, c.expected, parseEvaluatorWorkers())
+		})
+	}
+}
+
 // createMockVSAChecker creates a mock VSA checker for testing
 func createMockVSAChecker() *vsa.VSAChecker {
 	// Create a mock retriever that always returns "not found"
@@ -30,6 +30,7 @@ import (
 	"github.com/conforma/cli/internal/evaluator"
 	"github.com/conforma/cli/internal/format"
 	"github.com/conforma/cli/internal/policy"
+	"github.com/conforma/cli/internal/report/sarif"
 	"github.com/conforma/cli/internal/utils"
 	"github.com/conforma/cli/internal/version"
 )
@@ -94,6 +95,7 @@ const (
 	YAML    = "yaml"
 	Text    = "text"
 	Summary = "summary"
+	SARIF   = "sarif"
 )
 
 // WriteReport returns a new instance of Report representing the state of
@@ -165,12 +167,28 @@ func (r *Report) toFormat(format string) (data []byte, err error) {
 		data, err = generateTextReport(r)
 	case Summary:
 		data, err = json.Marshal(r.toSummary())
+	case SARIF:
+		data, err = r.toSARIF()
 	default:
 		return nil, fmt.Errorf("%q is not a valid report format", format)
 	}
 	return
 }
 
+// toSARIF returns the violations and warnings of the report in SARIF format.
+func (r *Report) toSARIF() ([]byte, error) {
+	artifacts := make([]sarif.Artifact, 0, len(r.FilePaths))
+	for _, f := range r.FilePaths {
+		artifacts = append(artifacts, sarif.Artifact{
+			URI:        f.FilePath,
+			Violations: f.Violations,
+			Warnings:   f.Warnings,
+		})
+	}
+
+	return sarif.Marshal(r.EcVersion, artifacts)
+}
+
 // toSummary returns a condensed version of the report.
 func (r *Report) toSummary() summary {
 	pr := summary{}
@@ -24,7 +24,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/owenrumney/go-sarif/v2/sarif"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/conforma/cli/internal/evaluator"
 	"github.com/conforma/cli/internal/policy"
@@ -284,6 +286,67 @@ func testInputsFor(filePaths []string) []Input {
 	return inputs
 }
 
+func Test_ReportSARIF(t *testing.T) {
+	inputs := []Input{
+		{
+			FilePath: "/path/to/file1.yaml",
+			Violations: []evaluator.Result{
+				{
+					Message: "violation1",
+					Metadata: map[string]interface{}{
+						"code": "test.violation",
+					},
+				},
+			},
+			Warnings: []evaluator.Result{
+				{
+					Message: "warning1",
+					Metadata: map[string]interface{}{
+						"code":     "test.warning",
+						"severity": "failure",
+					},
+				},
+			},
+			Successes: []evaluator.Result{
+				{
+					Message: "success1",
+					Metadata: map[string]interface{}{
+						"code": "test.success",
+					},
+				},
+			},
+			SuccessCount: 1,
+			Success:      false,
+		},
+	}
+	ctx := context.Background()
+	testPolicy := createTestPolicy(t, ctx)
+	report, err := NewReport(inputs, testPolicy, nil, false, true, true)
+	require.NoError(t, err)
+
+	data, err := report.toFormat(SARIF)
+	require.NoError(t, err)
+
+	log, err := sarif.FromBytes(data)
+	require.NoError(t, err)
+	require.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+	require.Len(t, run.Tool.Driver.Rules, 2)
+	assert.Equal(t, "test.violation", run.Tool.Driver.Rules[0].ID)
+	assert.Equal(t, "https://conforma.dev/docs/policy/packages/release_test.html#test__violation", *run.Tool.Driver.Rules[0].HelpURI)
+	assert.Equal(t, "test.warning", run.Tool.Driver.Rules[1].ID)
+
+	require.Len(t, run.Results, 2)
+	assert.Equal(t, "test.violation", *run.Results[0].RuleID)
+	assert.Equal(t, "error", *run.Results[0].Level)
+	assert.Equal(t, "violation1", *run.Results[0].Message.Text)
+	assert.Equal(t, "/path/to/file1.yaml", *run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, "test.warning", *run.Results[1].RuleID)
+	// the severity takes precedence
+	assert.Equal(t, "error", *run.Results[1].Level)
+}
+
 func Test_ReportText(t *testing.T) {
 	inputs := []Input{
 		{
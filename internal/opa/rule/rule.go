@@ -215,6 +215,10 @@ func code(a *ast.AnnotationsRef) string {
 	return fmt.Sprintf("%s.%s", packageName(a), shortName(a))
 }
 
+// ruleDocUrlFormat is the format of the documentation URL of a rule, the
+// arguments being the package name, twice, and the rule short name.
+const ruleDocUrlFormat = "https://conforma.dev/docs/policy/packages/release_%s.html#%s__%s"
+
 func documentationUrl(a *ast.AnnotationsRef) string {
 	if a == nil {
 		return ""
@@ -231,7 +235,6 @@ func documentationUrl(a *ast.AnnotationsRef) string {
 	// Given all this, the documentationUrl is not really reliable. We could remove it
 	// entirely, but since it's used only the `ec inspect policy` output, let's live
 	// with its flaws for now and fix it later.
-	ruleDocUrlFormat := "https://conforma.dev/docs/policy/packages/release_%s.html#%s__%s"
 
 	// a.Path might be something like this: "data.foo.deny" or
 	// "data.some.path.foo.warn". We want to pick out just "foo".
@@ -248,6 +251,26 @@ func documentationUrl(a *ast.AnnotationsRef) string {
 	return ""
 }
 
+// DocumentationUrlFromCode returns the documentation URL for the rule with the
+// given code, e.g. "some.path.foo.bar" for the rule with the short name "bar"
+// in the package "some.path.foo". This is useful when only the results of the
+// evaluation are at hand and not the rule annotations. The same caveats as for
+// the DocumentationUrl of the Info apply.
+func DocumentationUrlFromCode(code string) string {
+	i := strings.LastIndex(code, ".")
+	if i <= 0 || i == len(code)-1 {
+		return ""
+	}
+
+	pkg, shortName := code[:i], code[i+1:]
+	packageName := pkg[strings.LastIndex(pkg, ".")+1:]
+	if packageName == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(ruleDocUrlFormat, packageName, packageName, shortName)
+}
+
 func dependsOn(a *ast.AnnotationsRef) []string {
 	if a == nil {
 		return []string{}
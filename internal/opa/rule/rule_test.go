@@ -582,6 +582,28 @@ func TestCodeAndDocumentationUrl(t *testing.T) {
 		t.Run(fmt.Sprintf("[%d] - %s", i, c.name), func(t *testing.T) {
 			assert.Equal(t, c.expected, code(c.annotation))
 			assert.Equal(t, c.expectedUrl, documentationUrl(c.annotation))
+			assert.Equal(t, c.expectedUrl, DocumentationUrlFromCode(c.expected))
+		})
+	}
+}
+
+func TestDocumentationUrlFromCode(t *testing.T) {
+	cases := []struct {
+		code     string
+		expected string
+	}{
+		{code: "", expected: ""},
+		{code: "x", expected: ""},
+		{code: ".x", expected: ""},
+		{code: "a.", expected: ""},
+		{code: "a..x", expected: ""},
+		{code: "a.x", expected: "https://conforma.dev/docs/policy/packages/release_a.html#a__x"},
+		{code: "a.b.c.x", expected: "https://conforma.dev/docs/policy/packages/release_c.html#c__x"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.code, func(t *testing.T) {
+			assert.Equal(t, c.expected, DocumentationUrlFromCode(c.code))
 		})
 	}
 }
@@ -350,6 +350,12 @@ func (p *policy) loadPolicy(ctx context.Context, policyRef string) error {
 				return fmt.Errorf("policy does not conform to the schema")
 			}
 		}
+
+		verifications, err := parseSourceVerifications(policyRef)
+		if err != nil {
+			return err
+		}
+		requireSourceVerification(ctx, p.Sources, verifications)
 	} else {
 		log.Debug("Read EnterpriseContractPolicy as k8s resource")
 		k8s, err := kubernetes.NewClient(ctx)
@@ -366,7 +372,8 @@ func (p *policy) loadPolicy(ctx context.Context, policyRef string) error {
 		}
 		p.EnterpriseContractPolicySpec = ecp.Spec
 	}
-	return nil
+
+	return source.CheckLock(ctx, p.Sources)
 }
 
 // isConformant checks if the given policy conforms to the Enterprise Contract
@@ -615,6 +622,9 @@ func validatePolicyConfig(policyConfig string) error {
 		}
 	}
 
+	// The extensions are not part of the schema, they're validated when parsed
+	removeSourceExtensions(v)
+
 	// Validate the policy against the schema.
 	if err := policySchema.Validate(v); err != nil {
 		log.Error(err)
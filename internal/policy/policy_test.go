@@ -1458,6 +1458,21 @@ func TestValidatePolicyConfig(t *testing.T) {
 			expectErr:   false,
 			description: "Should successfully validate policy with spec wrapper and identity configuration",
 		},
+		{
+			name: "valid policy configuration with source verification",
+			policyConfig: `{
+				"sources": [
+					{
+						"policy": ["oci::registry.io/policy:latest"],
+						"verification": {
+							"publicKey": "test-public-key"
+						}
+					}
+				]
+			}`,
+			expectErr:   false,
+			description: "Should successfully validate policy with source verification extension",
+		},
 		{
 			name: "invalid policy configuration with malformed YAML",
 			policyConfig: `{
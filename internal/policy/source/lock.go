@@ -0,0 +1,336 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	ecc "github.com/conforma/crds/api/v1alpha1"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/yaml"
+
+	"github.com/conforma/cli/internal/utils"
+	"github.com/conforma/cli/internal/utils/oci"
+)
+
+const (
+	lockKey     key = 1
+	resolverKey key = 2
+)
+
+const lockHeader = "# Generated by \"ec policy lock\", do not edit.\n"
+
+// Lock pins the policy and data sources to the git commit or OCI digest they
+// resolved to when the lock was created.
+type Lock struct {
+	Sources []LockedSource `json:"sources"`
+}
+
+// LockedSource is a source URL, as it appears in the policy configuration,
+// along with the URL pinned to the resolved revision.
+type LockedSource struct {
+	Url    string `json:"url"`
+	Pinned string `json:"pinned"`
+}
+
+// WithLock returns a context in which the sources are downloaded as pinned in
+// the given lock.
+func WithLock(ctx context.Context, l *Lock) context.Context {
+	return context.WithValue(ctx, lockKey, l)
+}
+
+func lockFrom(ctx context.Context) *Lock {
+	if l, ok := ctx.Value(lockKey).(*Lock); ok {
+		return l
+	}
+
+	return nil
+}
+
+// ResolveLock downloads all sources of the given policy sources into the
+// working directory and returns the lock with the URLs pinned to the resolved
+// revisions.
+func ResolveLock(ctx context.Context, sources []ecc.Source, workDir string) (*Lock, error) {
+	l := &Lock{}
+	for _, s := range sources {
+		for _, ps := range PolicySourcesFrom(s) {
+			u, ok := ps.(*PolicyUrl)
+			if !ok {
+				// inline data is part of the policy configuration
+				continue
+			}
+
+			url := u.Url
+			if _, err := u.GetPolicy(ctx, workDir, false); err != nil {
+				return nil, err
+			}
+
+			if _, ok := revision(u.Url); !ok {
+				log.Warnf("Policy source %s cannot be pinned to an immutable revision", url)
+			}
+			l.add(url, u.Url)
+		}
+	}
+
+	return l, nil
+}
+
+func (l *Lock) add(url, pinned string) {
+	if _, ok := l.pinned(url); ok {
+		return
+	}
+
+	l.Sources = append(l.Sources, LockedSource{Url: url, Pinned: pinned})
+}
+
+func (l *Lock) pinned(url string) (string, bool) {
+	for _, s := range l.Sources {
+		// Once downloaded, the sources refer to the pinned URL
+		if s.Url == url || s.Pinned == url {
+			return s.Pinned, true
+		}
+	}
+
+	return "", false
+}
+
+// ReadLock reads the lock from the file at the given path.
+func ReadLock(ctx context.Context, path string) (*Lock, error) {
+	data, err := afero.ReadFile(utils.FS(ctx), path)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy lock: %w", err)
+	}
+
+	var l Lock
+	if err := yaml.UnmarshalStrict(data, &l); err != nil {
+		return nil, fmt.Errorf("parsing policy lock %s: %w", path, err)
+	}
+
+	for _, s := range l.Sources {
+		if s.Url == "" || s.Pinned == "" {
+			return nil, fmt.Errorf("policy lock %s contains an entry without url or pinned url", path)
+		}
+	}
+
+	return &l, nil
+}
+
+// Write writes the lock to the file at the given path.
+func (l *Lock) Write(ctx context.Context, path string) error {
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(utils.FS(ctx), path, append([]byte(lockHeader), data...), 0644)
+}
+
+// CheckLock reports the drift between the given policy sources and the lock
+// in the context, if any. Sources missing from the lock are an error, as they
+// cannot be pinned. Locked sources no longer in use, and floating sources that
+// currently resolve to a revision other than the pinned one, are only logged.
+func CheckLock(ctx context.Context, sources []ecc.Source) error {
+	l := lockFrom(ctx)
+	if l == nil {
+		return nil
+	}
+
+	used := map[string]bool{}
+	var missing []string
+	for _, s := range sources {
+		for _, url := range slices.Concat(s.Policy, s.Data) {
+			if used[url] {
+				continue
+			}
+			used[url] = true
+
+			pinned, ok := l.pinned(url)
+			if !ok {
+				missing = append(missing, url)
+				continue
+			}
+			checkDrift(ctx, url, pinned)
+		}
+	}
+
+	for _, s := range l.Sources {
+		if !used[s.Url] {
+			log.Warnf("Policy lock pins %s, which is not a source of the policy", s.Url)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("the policy sources have drifted from the policy lock, run \"ec policy lock\" to update it, sources not in the lock: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// checkDrift logs a warning if the floating source URL currently resolves to a
+// revision other than the one pinned by the lock.
+func checkDrift(ctx context.Context, url, pinned string) {
+	if _, ok := revision(url); ok {
+		// pinned in the policy configuration, cannot drift
+		return
+	}
+
+	locked, ok := revision(pinned)
+	if !ok {
+		return
+	}
+
+	current, err := currentRevision(ctx, url, pinned)
+	if err != nil {
+		log.Debugf("Unable to resolve the current revision of policy source %s: %v", url, err)
+		return
+	}
+
+	if current != locked {
+		log.Warnf("Policy source %s is pinned to %s by the policy lock, but currently resolves to %s, run \"ec policy lock\" to update it", url, locked, current)
+	}
+}
+
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// revision returns the immutable revision the source URL is pinned to, the
+// commit of git URLs or the digest of OCI URLs, and false if the URL is not
+// pinned.
+func revision(url string) (string, bool) {
+	if _, ref, ok := strings.Cut(url, "?ref="); ok {
+		ref, _, _ = strings.Cut(ref, "//")
+		ref, _, _ = strings.Cut(ref, "&")
+		return ref, commitPattern.MatchString(ref)
+	}
+
+	if i := strings.LastIndex(url, "@"); i != -1 {
+		if h, err := v1.NewHash(url[i+1:]); err == nil {
+			return h.String(), true
+		}
+	}
+
+	return "", false
+}
+
+type revisionResolver interface {
+	Resolve(ctx context.Context, url, pinned string) (string, error)
+}
+
+// currentRevision resolves the revision the floating source URL points to
+// now. The kind of the source is taken from the pinned URL, as the source URL
+// might not state it.
+func currentRevision(ctx context.Context, url, pinned string) (string, error) {
+	if r, ok := ctx.Value(resolverKey).(revisionResolver); ok {
+		return r.Resolve(ctx, url, pinned)
+	}
+
+	switch {
+	case strings.HasPrefix(pinned, "oci::"):
+		ref, err := name.ParseReference(strings.TrimPrefix(strings.TrimPrefix(url, "oci::"), "oci://"))
+		if err != nil {
+			return "", err
+		}
+
+		return oci.NewClient(ctx).ResolveDigest(ref)
+	case strings.HasPrefix(pinned, "git::"):
+		return gitRevision(ctx, url)
+	}
+
+	return "", fmt.Errorf("unsupported policy source %s", pinned)
+}
+
+// gitRevision lists the references of the git repository of the source URL
+// and returns the commit of the referenced branch or tag, or of HEAD if there
+// is no reference.
+func gitRevision(ctx context.Context, url string) (string, error) {
+	url = strings.TrimPrefix(url, "git::")
+	for _, scheme := range []string{"git://", "https://"} {
+		url = strings.TrimPrefix(url, scheme)
+	}
+	if _, u, ok := strings.Cut(url, "git@"); ok {
+		url = strings.Replace(u, ":", "/", 1)
+	}
+
+	repo, ref, _ := strings.Cut(url, "?ref=")
+	repo, _, _ = strings.Cut(repo, "//")
+	ref, _, _ = strings.Cut(ref, "//")
+	ref, _, _ = strings.Cut(ref, "&")
+
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{"https://" + repo},
+	})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{PeelingOption: git.AppendPeeled})
+	if err != nil {
+		return "", err
+	}
+
+	byName := map[plumbing.ReferenceName]*plumbing.Reference{}
+	for _, r := range refs {
+		byName[r.Name()] = r
+	}
+
+	candidates := []plumbing.ReferenceName{plumbing.HEAD}
+	if ref != "" {
+		// annotated tags are pinned to the commit they point to
+		candidates = []plumbing.ReferenceName{
+			plumbing.NewBranchReferenceName(ref),
+			plumbing.NewTagReferenceName(ref + "^{}"),
+			plumbing.NewTagReferenceName(ref),
+		}
+	}
+
+	for _, c := range candidates {
+		r, ok := byName[c]
+		// follow symbolic references, e.g. HEAD to the default branch
+		for i := 0; ok && r.Type() == plumbing.SymbolicReference && i < 5; i++ {
+			r, ok = byName[r.Target()]
+		}
+		if ok && r.Type() == plumbing.HashReference {
+			return r.Hash().String(), nil
+		}
+	}
+
+	return "", fmt.Errorf("reference %q not found in %s", ref, repo)
+}
+
+// lockedUrl returns the URL to download the source from, pinned by the lock
+// in the context if there is one.
+func lockedUrl(ctx context.Context, url string) (string, error) {
+	l := lockFrom(ctx)
+	if l == nil {
+		return url, nil
+	}
+
+	pinned, ok := l.pinned(url)
+	if !ok {
+		return "", fmt.Errorf("policy source %s is not pinned in the policy lock", url)
+	}
+	log.Debugf("Using %s pinned by the policy lock for %s", pinned, url)
+
+	return pinned, nil
+}
@@ -0,0 +1,222 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package source
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	ecc "github.com/conforma/crds/api/v1alpha1"
+	gitMetadata "github.com/conforma/go-gather/gather/git"
+	ociMetadata "github.com/conforma/go-gather/gather/oci"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/conforma/cli/internal/utils"
+)
+
+func TestResolveLock(t *testing.T) {
+	ClearDownloadCache()
+	t.Cleanup(ClearDownloadCache)
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	commit := strings.Repeat("b", 40)
+
+	dl := mockDownloader{}
+	dl.On("Download", mock.Anything, mock.Anything, "oci::registry.io/policy:latest", false).Return(&ociMetadata.OCIMetadata{Digest: digest}, nil)
+	dl.On("Download", mock.Anything, mock.Anything, "git::https://github.com/org/repo//data", false).Return(&gitMetadata.GitMetadata{LatestCommit: commit}, nil)
+
+	fs := afero.NewMemMapFs()
+	ctx := usingDownloader(utils.WithFS(context.Background(), fs), &dl)
+
+	lock, err := ResolveLock(ctx, []ecc.Source{
+		{
+			Policy:   []string{"oci::registry.io/policy:latest"},
+			Data:     []string{"git::https://github.com/org/repo//data"},
+			RuleData: &extv1.JSON{Raw: []byte(`{"key": "value"}`)},
+		},
+		{
+			// sources in multiple groups are locked once
+			Policy: []string{"oci::registry.io/policy:latest"},
+		},
+	}, "/work")
+	require.NoError(t, err)
+
+	expected := &Lock{Sources: []LockedSource{
+		{Url: "oci::registry.io/policy:latest", Pinned: "oci::registry.io/policy:latest@" + digest},
+		{Url: "git::https://github.com/org/repo//data", Pinned: "git::github.com/org/repo//data?ref=" + commit},
+	}}
+	assert.Equal(t, expected, lock)
+
+	require.NoError(t, lock.Write(ctx, "policy.lock.yaml"))
+	read, err := ReadLock(ctx, "policy.lock.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, expected, read)
+}
+
+func TestReadLockErrors(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	ctx := utils.WithFS(context.Background(), fs)
+
+	_, err := ReadLock(ctx, "missing.yaml")
+	assert.ErrorContains(t, err, "reading policy lock")
+
+	require.NoError(t, afero.WriteFile(fs, "unknown.yaml", []byte("sources:\n- url: a\n  pinned: b\n  digest: c\n"), 0644))
+	_, err = ReadLock(ctx, "unknown.yaml")
+	assert.ErrorContains(t, err, "parsing policy lock unknown.yaml")
+
+	require.NoError(t, afero.WriteFile(fs, "incomplete.yaml", []byte("sources:\n- url: a\n"), 0644))
+	_, err = ReadLock(ctx, "incomplete.yaml")
+	assert.EqualError(t, err, "policy lock incomplete.yaml contains an entry without url or pinned url")
+}
+
+func TestGetPolicyWithLock(t *testing.T) {
+	ClearDownloadCache()
+	t.Cleanup(ClearDownloadCache)
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	pinned := "oci::registry.io/policy:latest@" + digest
+
+	dl := mockDownloader{}
+	dl.On("Download", mock.Anything, mock.Anything, pinned, false).Return(&ociMetadata.OCIMetadata{Digest: digest}, nil)
+
+	ctx := WithLock(usingDownloader(context.Background(), &dl), &Lock{Sources: []LockedSource{
+		{Url: "oci::registry.io/policy:latest", Pinned: pinned},
+	}})
+
+	p := &PolicyUrl{Url: "oci::registry.io/policy:latest", Kind: PolicyKind}
+	_, err := p.GetPolicy(ctx, "/work", false)
+	require.NoError(t, err)
+	assert.Equal(t, pinned, p.Url)
+
+	// already pinned
+	_, err = p.GetPolicy(ctx, "/work", false)
+	require.NoError(t, err)
+
+	other := &PolicyUrl{Url: "oci::registry.io/other:latest", Kind: PolicyKind}
+	_, err = other.GetPolicy(ctx, "/work", false)
+	assert.EqualError(t, err, "policy source oci::registry.io/other:latest is not pinned in the policy lock")
+
+	mock.AssertExpectationsForObjects(t, &dl)
+}
+
+func TestCheckLock(t *testing.T) {
+	sources := []ecc.Source{
+		{
+			Policy: []string{"oci::registry.io/policy:latest"},
+			Data:   []string{"git::https://github.com/org/repo//data"},
+		},
+	}
+
+	assert.NoError(t, CheckLock(context.Background(), sources))
+
+	ctx := WithLock(context.Background(), &Lock{Sources: []LockedSource{
+		{Url: "oci::registry.io/policy:latest", Pinned: "oci::registry.io/policy:latest@sha256:abc"},
+		{Url: "oci::registry.io/removed:latest", Pinned: "oci::registry.io/removed:latest@sha256:abc"},
+	}})
+
+	assert.EqualError(t, CheckLock(ctx, sources), `the policy sources have drifted from the policy lock, run "ec policy lock" to update it, sources not in the lock: git::https://github.com/org/repo//data`)
+
+	// unused locked sources are not an error
+	assert.NoError(t, CheckLock(ctx, sources[:0]))
+}
+
+type mockResolver struct {
+	mock.Mock
+}
+
+func (m *mockResolver) Resolve(ctx context.Context, url, pinned string) (string, error) {
+	args := m.Called(ctx, url, pinned)
+
+	return args.String(0), args.Error(1)
+}
+
+func TestCheckLockDrift(t *testing.T) {
+	locked := "sha256:" + strings.Repeat("a", 64)
+	current := "sha256:" + strings.Repeat("c", 64)
+	commit := strings.Repeat("b", 40)
+
+	r := mockResolver{}
+	r.On("Resolve", mock.Anything, "oci::registry.io/policy:latest", "oci::registry.io/policy:latest@"+locked).Return(current, nil)
+	r.On("Resolve", mock.Anything, "git::https://github.com/org/repo//data", "git::github.com/org/repo//data?ref="+commit).Return(commit, nil)
+
+	ctx := WithLock(context.WithValue(context.Background(), resolverKey, &r), &Lock{Sources: []LockedSource{
+		{Url: "oci::registry.io/policy:latest", Pinned: "oci::registry.io/policy:latest@" + locked},
+		{Url: "git::https://github.com/org/repo//data", Pinned: "git::github.com/org/repo//data?ref=" + commit},
+		{Url: "oci::registry.io/pinned@" + locked, Pinned: "oci::registry.io/pinned@" + locked},
+	}})
+
+	hook := test.NewGlobal()
+	t.Cleanup(func() { log.StandardLogger().ReplaceHooks(make(log.LevelHooks)) })
+
+	require.NoError(t, CheckLock(ctx, []ecc.Source{
+		{
+			Policy: []string{"oci::registry.io/policy:latest", "oci::registry.io/pinned@" + locked},
+			Data:   []string{"git::https://github.com/org/repo//data"},
+		},
+	}))
+
+	var warnings []string
+	for _, e := range hook.AllEntries() {
+		if e.Level == log.WarnLevel {
+			warnings = append(warnings, e.Message)
+		}
+	}
+	assert.Equal(t, []string{
+		"Policy source oci::registry.io/policy:latest is pinned to " + locked + " by the policy lock, but currently resolves to " + current + `, run "ec policy lock" to update it`,
+	}, warnings)
+
+	// sources pinned in the policy configuration are not resolved
+	mock.AssertExpectationsForObjects(t, &r)
+	r.AssertNumberOfCalls(t, "Resolve", 2)
+}
+
+func TestRevision(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	commit := strings.Repeat("b", 40)
+
+	cases := []struct {
+		url      string
+		revision string
+		pinned   bool
+	}{
+		{url: "oci::registry.io/policy@" + digest, revision: digest, pinned: true},
+		{url: "oci::registry.io/policy:latest@" + digest, revision: digest, pinned: true},
+		{url: "oci::registry.io/policy:latest"},
+		{url: "git::github.com/org/repo//data?ref=" + commit, revision: commit, pinned: true},
+		{url: "git::https://github.com/org/repo?ref=" + commit + "//data", revision: commit, pinned: true},
+		{url: "git::https://github.com/org/repo//data?ref=main", revision: "main"},
+		{url: "git::git@github.com:org/repo.git"},
+		{url: "http::https://example.com/data.json"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.url, func(t *testing.T) {
+			revision, pinned := revision(c.url)
+			assert.Equal(t, c.revision, revision)
+			assert.Equal(t, c.pinned, pinned)
+		})
+	}
+}
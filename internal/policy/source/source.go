@@ -29,6 +29,7 @@ import (
 	"path"
 	"path/filepath"
 	"runtime/trace"
+	"strings"
 	"sync"
 
 	ecc "github.com/conforma/crds/api/v1alpha1"
@@ -36,6 +37,7 @@ import (
 	gitMetadata "github.com/conforma/go-gather/gather/git"
 	ociMetadata "github.com/conforma/go-gather/gather/oci"
 	"github.com/conforma/go-gather/metadata"
+	"github.com/google/go-containerregistry/pkg/name"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 
@@ -81,10 +83,101 @@ var downloadCache sync.Map
 // symlinkMutexes provides per-destination synchronization for symlink creation
 var symlinkMutexes sync.Map
 
+// verifications holds the verifications required for the sources, keyed by
+// the source URL
+var verifications sync.Map
+
 // ClearDownloadCache clears the download cache. This is primarily used for testing.
 func ClearDownloadCache() {
 	downloadCache = sync.Map{}
 	symlinkMutexes = sync.Map{}
+	verifications = sync.Map{}
+}
+
+// Verifier verifies the authenticity of the downloaded OCI bundle, given its
+// reference pinned to the digest.
+type Verifier func(ctx context.Context, ref name.Digest) error
+
+type verification struct {
+	mu        sync.Mutex
+	verifiers []Verifier
+	// verified holds the outcome of the verification per bundle digest
+	verified map[string]error
+}
+
+// RequireVerification makes the downloads of the source with the given URL
+// fail unless the verifier accepts the downloaded bundle. Only OCI bundles can
+// be verified. When multiple verifiers are required for the same source, all
+// of them need to accept the bundle.
+func RequireVerification(sourceUrl string, v Verifier) {
+	value, _ := verifications.LoadOrStore(sourceUrl, &verification{verified: map[string]error{}})
+	ver := value.(*verification)
+
+	ver.mu.Lock()
+	defer ver.mu.Unlock()
+	ver.verifiers = append(ver.verifiers, v)
+	ver.verified = map[string]error{}
+}
+
+// verifySource runs the verifications required for the source with the given
+// URL against the downloaded bundle described by the metadata.
+func verifySource(ctx context.Context, sourceUrl string, m metadata.Metadata) error {
+	value, ok := verifications.Load(sourceUrl)
+	if !ok {
+		return nil
+	}
+	ver := value.(*verification)
+
+	oci, ok := m.(*ociMetadata.OCIMetadata)
+	if !ok || oci.Digest == "" {
+		return fmt.Errorf("policy source %s requires verification, which is supported only for OCI bundles", sourceUrl)
+	}
+
+	ref, err := bundleReference(sourceUrl, oci.Digest)
+	if err != nil {
+		return err
+	}
+
+	ver.mu.Lock()
+	defer ver.mu.Unlock()
+
+	if err, ok := ver.verified[ref.String()]; ok {
+		return err
+	}
+
+	if trace.IsEnabled() {
+		region := trace.StartRegion(ctx, "ec:verify-policy-source")
+		defer region.End()
+		trace.Logf(ctx, "", "policy=%q", ref)
+	}
+
+	for _, v := range ver.verifiers {
+		if err = v(ctx, ref); err != nil {
+			err = fmt.Errorf("verification of policy source %s failed: %w", sourceUrl, err)
+			break
+		}
+	}
+	if err == nil {
+		log.Debugf("Verified policy source %s as %s", sourceUrl, ref)
+	}
+	ver.verified[ref.String()] = err
+
+	return err
+}
+
+// bundleReference returns the reference of the OCI bundle from the go-getter
+// style source URL, pinned to the given digest
+func bundleReference(sourceUrl, digest string) (name.Digest, error) {
+	ref := strings.TrimPrefix(strings.TrimPrefix(sourceUrl, "oci::"), "oci://")
+	// drop the subdirectory, if any
+	ref, _, _ = strings.Cut(ref, "//")
+
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("unable to parse the OCI reference of policy source %s: %w", sourceUrl, err)
+	}
+
+	return r.Context().Digest(digest), nil
 }
 
 type cacheContent struct {
@@ -114,6 +207,12 @@ func getPolicyThroughCache(ctx context.Context, s PolicySource, workDir string,
 		return "", c.metadata, c.err
 	}
 
+	// Checked on each use, not only on download, as the verification might be
+	// required after the source has been downloaded
+	if err := verifySource(ctx, sourceUrl, c.metadata); err != nil {
+		return "", c.metadata, err
+	}
+
 	fs := utils.FS(ctx)
 
 	// If the destination directory is different from the source directory, we
@@ -148,6 +247,9 @@ func getPolicyThroughCache(ctx context.Context, s PolicySource, workDir string,
 		} else {
 			log.Debugf("Filesystem does not support symlinking: %q, re-downloading instead", fs.Name())
 			m, err := dl(sourceUrl, dest)
+			if err == nil {
+				err = verifySource(ctx, sourceUrl, m)
+			}
 			logMetadata(m)
 			return dest, m, err
 		}
@@ -173,7 +275,16 @@ func (p *PolicyUrl) GetPolicy(ctx context.Context, workDir string, showMsg bool)
 		trace.Logf(ctx, "", "policy=%q", p.Url)
 	}
 
+	locked, err := lockedUrl(ctx, p.Url)
+	if err != nil {
+		return "", err
+	}
+
 	dl := func(source string, dest string) (metadata.Metadata, error) {
+		if source == p.Url {
+			source = locked
+		}
+
 		x := ctx.Value(DownloaderFuncKey)
 		if dl, ok := x.(downloaderFunc); ok {
 			return dl.Download(ctx, dest, source, showMsg)
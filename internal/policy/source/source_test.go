@@ -26,6 +26,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"sync"
 	"testing"
 
@@ -34,6 +35,7 @@ import (
 	gitMetadata "github.com/conforma/go-gather/gather/git"
 	ociMetadata "github.com/conforma/go-gather/gather/oci"
 	"github.com/conforma/go-gather/metadata"
+	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -634,6 +636,89 @@ func TestDownloadCacheWorkdirMismatch(t *testing.T) {
 	assert.Equal(t, destination1, destination2)
 }
 
+func TestGetPolicyThroughCacheVerification(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	cases := []struct {
+		name     string
+		url      string
+		metadata metadata.Metadata
+		verifier func(ref name.Digest) error
+		expected string
+		err      string
+	}{
+		{
+			name:     "verified",
+			url:      "oci::registry.io/repository/policy:latest",
+			metadata: &ociMetadata.OCIMetadata{Digest: digest},
+			verifier: func(name.Digest) error { return nil },
+			expected: "registry.io/repository/policy@" + digest,
+		},
+		{
+			name:     "verified with subdirectory",
+			url:      "oci://registry.io/repository/policy:latest//policy/release",
+			metadata: &ociMetadata.OCIMetadata{Digest: digest},
+			verifier: func(name.Digest) error { return nil },
+			expected: "registry.io/repository/policy@" + digest,
+		},
+		{
+			name:     "rejected",
+			url:      "oci::registry.io/repository/policy:latest",
+			metadata: &ociMetadata.OCIMetadata{Digest: digest},
+			verifier: func(name.Digest) error { return errors.New("no signature") },
+			expected: "registry.io/repository/policy@" + digest,
+			err:      "verification of policy source oci::registry.io/repository/policy:latest failed: no signature",
+		},
+		{
+			name:     "not an OCI bundle",
+			url:      "git::https://github.com/org/repo//policy",
+			metadata: &gitMetadata.GitMetadata{LatestCommit: "abc"},
+			verifier: func(name.Digest) error { return nil },
+			err:      "policy source git::https://github.com/org/repo//policy requires verification, which is supported only for OCI bundles",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ClearDownloadCache()
+			t.Cleanup(ClearDownloadCache)
+
+			verified := []string{}
+			RequireVerification(c.url, func(_ context.Context, ref name.Digest) error {
+				verified = append(verified, ref.String())
+				return c.verifier(ref)
+			})
+
+			fs := afero.NewMemMapFs()
+			ctx := utils.WithFS(context.Background(), fs)
+
+			dl := func(_, dest string) (metadata.Metadata, error) {
+				return c.metadata, fs.MkdirAll(dest, 0755)
+			}
+
+			source := &mockPolicySource{&mock.Mock{}}
+			source.On("PolicyUrl").Return(c.url)
+			source.On("Subdir").Return("policy")
+
+			for _, workDir := range []string{"/workdir1", "/workdir2"} {
+				_, _, err := getPolicyThroughCache(ctx, source, workDir, dl)
+				if c.err != "" {
+					assert.EqualError(t, err, c.err)
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+
+			if c.expected != "" {
+				// the outcome of the verification is reused for the same digest
+				assert.Equal(t, []string{c.expected}, verified)
+			} else {
+				assert.Empty(t, verified)
+			}
+		})
+	}
+}
+
 // TestConcurrentPolicyCachingRaceCondition reproduces the "file exists" error
 // that occurs when multiple workers simultaneously try to create symlinks from
 // cached policy downloads to their individual work directories
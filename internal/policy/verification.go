@@ -0,0 +1,206 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	ecc "github.com/conforma/crds/api/v1alpha1"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/sigstore/cosign/v3/pkg/cosign"
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	"github.com/conforma/cli/internal/policy/source"
+	"github.com/conforma/cli/internal/utils/oci"
+)
+
+// sourceVerificationKey is the key of the source verification within a source
+// of the policy configuration. It is an extension to the
+// EnterpriseContractPolicy spec, so it is available only in policy
+// configurations provided as files or inline, not in EnterpriseContractPolicy
+// resources fetched from the cluster.
+const sourceVerificationKey = "verification"
+
+// SourceVerification requires the OCI policy and data bundles of a source to
+// carry a valid signature, or attestation, from the given public key or
+// keyless identity, for example:
+//
+//	sources:
+//	  - policy:
+//	      - oci::quay.io/enterprise-contract/ec-release-policy:latest
+//	    verification:
+//	      identity:
+//	        subject: https://github.com/conforma/policy/.github/workflows/release.yaml@refs/heads/main
+//	        issuer: https://token.actions.githubusercontent.com
+type SourceVerification struct {
+	// PublicKey used to verify the signature, same as the policy publicKey
+	PublicKey string `json:"publicKey,omitempty"`
+	// Identity for keyless verification, when no PublicKey is provided
+	Identity *ecc.Identity `json:"identity,omitempty"`
+	// RekorUrl of the Rekor instance
+	RekorUrl string `json:"rekorUrl,omitempty"`
+	// IgnoreRekor skips the transparency log verification
+	IgnoreRekor bool `json:"ignoreRekor,omitempty"`
+}
+
+type sourceExtensions struct {
+	Verification json.RawMessage `json:"verification,omitempty"`
+}
+
+// parseSourceVerifications returns the source verifications from the policy
+// configuration, in the order of the sources.
+func parseSourceVerifications(policyConfig string) ([]*SourceVerification, error) {
+	var doc struct {
+		Spec struct {
+			Sources []sourceExtensions `json:"sources"`
+		} `json:"spec"`
+		Sources []sourceExtensions `json:"sources"`
+	}
+
+	if err := yaml.Unmarshal([]byte(policyConfig), &doc); err != nil {
+		return nil, fmt.Errorf("unable to parse source verification: %w", err)
+	}
+
+	sources := doc.Sources
+	if len(doc.Spec.Sources) > 0 {
+		sources = doc.Spec.Sources
+	}
+
+	verifications := make([]*SourceVerification, 0, len(sources))
+	for i, s := range sources {
+		if len(s.Verification) == 0 {
+			verifications = append(verifications, nil)
+			continue
+		}
+
+		var v SourceVerification
+		if err := yaml.UnmarshalStrict(s.Verification, &v); err != nil {
+			return nil, fmt.Errorf("invalid verification of source %d: %w", i, err)
+		}
+		if err := v.validate(); err != nil {
+			return nil, fmt.Errorf("invalid verification of source %d: %w", i, err)
+		}
+		verifications = append(verifications, &v)
+	}
+
+	return verifications, nil
+}
+
+// removeSourceExtensions removes the extensions to the spec from the sources,
+// so the policy configuration can be validated against the schema.
+func removeSourceExtensions(spec map[string]any) {
+	sources, ok := spec["sources"].([]any)
+	if !ok {
+		return
+	}
+
+	for _, s := range sources {
+		if source, ok := s.(map[string]any); ok {
+			delete(source, sourceVerificationKey)
+		}
+	}
+}
+
+func (v *SourceVerification) validate() error {
+	if v.PublicKey != "" {
+		return nil
+	}
+
+	if v.Identity == nil {
+		return errors.New("either publicKey or identity must be provided")
+	}
+
+	return validateIdentity(v.identity())
+}
+
+func (v *SourceVerification) identity() cosign.Identity {
+	if v.Identity == nil {
+		return cosign.Identity{}
+	}
+
+	return cosign.Identity{
+		Issuer:        v.Identity.Issuer,
+		IssuerRegExp:  v.Identity.IssuerRegExp,
+		Subject:       v.Identity.Subject,
+		SubjectRegExp: v.Identity.SubjectRegExp,
+	}
+}
+
+// requireSourceVerification requires the verification of the policy and data
+// sources according to the source verifications.
+func requireSourceVerification(ctx context.Context, sources []ecc.Source, verifications []*SourceVerification) {
+	for i, v := range verifications {
+		if v == nil || i >= len(sources) {
+			continue
+		}
+
+		verifier := sourceVerifier(ctx, *v)
+		for _, url := range append(append([]string{}, sources[i].Policy...), sources[i].Data...) {
+			log.Debugf("Requiring verification of policy source %s", url)
+			source.RequireVerification(url, verifier)
+		}
+	}
+}
+
+// sourceVerifier returns the verifier accepting the bundles with a valid
+// signature or attestation
+func sourceVerifier(ctx context.Context, v SourceVerification) source.Verifier {
+	opts := sync.OnceValues(func() (*cosign.CheckOpts, error) {
+		p := &policy{
+			EnterpriseContractPolicySpec: ecc.EnterpriseContractPolicySpec{
+				PublicKey: v.PublicKey,
+				RekorUrl:  v.RekorUrl,
+			},
+			ignoreRekor: v.IgnoreRekor,
+		}
+		if v.PublicKey == "" {
+			p.identity = v.identity()
+		}
+
+		return checkOpts(ctx, p)
+	})
+
+	return func(ctx context.Context, ref name.Digest) error {
+		o, err := opts()
+		if err != nil {
+			return err
+		}
+
+		client := oci.NewClient(ctx)
+
+		sigOpts := *o
+		sigOpts.ClaimVerifier = cosign.SimpleClaimVerifier
+		_, _, sigErr := client.VerifyImageSignatures(ref, &sigOpts)
+		if sigErr == nil {
+			return nil
+		}
+
+		attOpts := *o
+		attOpts.ClaimVerifier = cosign.IntotoSubjectClaimVerifier
+		_, _, attErr := client.VerifyImageAttestations(ref, &attOpts)
+		if attErr == nil {
+			return nil
+		}
+
+		return fmt.Errorf("no valid signature or attestation found for %s: %w", ref, errors.Join(sigErr, attErr))
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package policy
+
+import (
+	"testing"
+
+	hd "github.com/MakeNowJust/heredoc"
+	ecc "github.com/conforma/crds/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSourceVerifications(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   string
+		expected []*SourceVerification
+		err      string
+	}{
+		{
+			name: "no verification",
+			config: hd.Doc(`
+				sources:
+				  - policy:
+				      - oci::registry.io/policy:latest
+			`),
+			expected: []*SourceVerification{nil},
+		},
+		{
+			name: "public key",
+			config: hd.Doc(`
+				sources:
+				  - policy:
+				      - oci::registry.io/policy:latest
+				  - policy:
+				      - oci::registry.io/other:latest
+				    verification:
+				      publicKey: k8s://tekton-chains/public-key
+				      ignoreRekor: true
+			`),
+			expected: []*SourceVerification{nil, {PublicKey: "k8s://tekton-chains/public-key", IgnoreRekor: true}},
+		},
+		{
+			name: "identity within spec",
+			config: hd.Doc(`
+				apiVersion: appstudio.redhat.com/v1alpha1
+				kind: EnterpriseContractPolicy
+				spec:
+				  sources:
+				    - policy:
+				        - oci::registry.io/policy:latest
+				      verification:
+				        identity:
+				          subject: subject
+				          issuer: issuer
+			`),
+			expected: []*SourceVerification{{Identity: &ecc.Identity{Subject: "subject", Issuer: "issuer"}}},
+		},
+		{
+			name: "no key or identity",
+			config: hd.Doc(`
+				sources:
+				  - policy:
+				      - oci::registry.io/policy:latest
+				    verification:
+				      rekorUrl: https://rekor.example.com
+			`),
+			err: "invalid verification of source 0: either publicKey or identity must be provided",
+		},
+		{
+			name: "incomplete identity",
+			config: hd.Doc(`
+				sources:
+				  - policy:
+				      - oci::registry.io/policy:latest
+				    verification:
+				      identity:
+				        subject: subject
+			`),
+			err: "invalid verification of source 0: certificate OIDC issuer must be provided for keyless workflow",
+		},
+		{
+			name: "unknown field",
+			config: hd.Doc(`
+				sources:
+				  - policy:
+				      - oci::registry.io/policy:latest
+				    verification:
+				      publicKey: key
+				      pubicKey: key
+			`),
+			err: "invalid verification of source 0",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			verifications, err := parseSourceVerifications(c.config)
+			if c.err != "" {
+				assert.ErrorContains(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, verifications)
+		})
+	}
+}
@@ -19,5 +19,7 @@ package rego
 import (
 	_ "github.com/conforma/cli/internal/rego/oci"
 	_ "github.com/conforma/cli/internal/rego/purl"
+	_ "github.com/conforma/cli/internal/rego/sbom"
 	_ "github.com/conforma/cli/internal/rego/sigstore"
+	_ "github.com/conforma/cli/internal/rego/vuln"
 )
@@ -31,12 +31,15 @@ import (
 	"io"
 	"path"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/open-policy-agent/opa/v1/ast"
 	"github.com/open-policy-agent/opa/v1/rego"
@@ -60,9 +63,12 @@ const (
 	ociImageManifestName       = "ec.oci.image_manifest"
 	ociImageManifestsBatchName = "ec.oci.image_manifests"
 	ociImageFilesName          = "ec.oci.image_files"
+	ociImageLayerFilesName     = "ec.oci.image_layer_files"
 	ociImageIndexName          = "ec.oci.image_index"
+	ociImageLineageName        = "ec.oci.image_lineage"
 	ociImageTagRefsName        = "ec.oci.image_tag_refs"
 	ociImageReferrersName      = "ec.oci.image_referrers"
+	ociImageReferrersTreeName  = "ec.oci.image_referrers_tree"
 	maxTarEntrySizeConst       = 500 * 1024 * 1024 // 500MB
 )
 
@@ -332,6 +338,49 @@ func registerOCIImageFiles() {
 	rego.RegisterBuiltin2(&decl, ociImageFiles)
 }
 
+func registerOCIImageLayerFiles() {
+	file := types.NewObject(
+		[]*types.StaticProperty{
+			{Key: "path", Value: types.Named("path", types.S).Description("the absolute path of the file, or of the deleted file for whiteouts")},
+			{Key: "type", Value: types.Named("type", types.S).Description("one of file, dir, symlink, hardlink, char, block, fifo, whiteout or opaque")},
+			{Key: "mode", Value: types.Named("mode", types.N).Description("the permission bits, including setuid, setgid and sticky bits")},
+			{Key: "size", Value: types.N},
+			{Key: "uid", Value: types.N},
+			{Key: "gid", Value: types.N},
+			{Key: "link", Value: types.Named("link", types.S).Description("the target of symbolic and hard links")},
+		},
+		nil,
+	)
+
+	layer := types.NewObject(
+		[]*types.StaticProperty{
+			{Key: "digest", Value: types.S},
+			{Key: "diffID", Value: types.S},
+			{Key: "mediaType", Value: types.S},
+			{Key: "files", Value: types.NewArray(nil, file)},
+		},
+		nil,
+	)
+
+	decl := rego.Function{
+		Name:        ociImageLayerFilesName,
+		Description: "List the files within each layer of an image.",
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("ref", types.S).Description("OCI image reference"),
+			),
+			types.Named("layers", types.NewArray(nil, layer)).Description("the layers of the image, in order, with their files"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic. But also mark it as non-deterministic because it does rely on external
+		// entities, i.e. OCI registry. https://www.openpolicyagent.org/docs/latest/extensions/
+		Memoize:          true,
+		Nondeterministic: true,
+	}
+
+	rego.RegisterBuiltin1(&decl, ociImageLayerFiles)
+}
+
 func registerOCIBlobFiles() {
 	filesObject := types.NewObject(
 		nil,
@@ -421,6 +470,74 @@ func registerOCIImageIndex() {
 	})
 }
 
+func registerOCIImageLineage() {
+	history := types.NewObject(
+		[]*types.StaticProperty{
+			{Key: "created", Value: types.S},
+			{Key: "created_by", Value: types.S},
+			{Key: "author", Value: types.S},
+			{Key: "comment", Value: types.S},
+			{Key: "empty_layer", Value: types.B},
+		},
+		nil,
+	)
+
+	layer := types.NewObject(
+		[]*types.StaticProperty{
+			{Key: "diffID", Value: types.S},
+			{Key: "digest", Value: types.S},
+			{Key: "history", Value: history},
+			{Key: "bases", Value: types.Named("bases", types.NewArray(nil, types.S)).Description("the given base images containing the layer")},
+		},
+		nil,
+	)
+
+	base := types.NewObject(
+		[]*types.StaticProperty{
+			{Key: "ref", Value: types.S},
+			{Key: "match", Value: types.Named("match", types.B).Description("true when the image is built on the base image")},
+			{Key: "layers", Value: types.Named("layers", types.N).Description("number of layers of the image from the base image")},
+		},
+		nil,
+	)
+
+	lineage := types.NewObject(
+		[]*types.StaticProperty{
+			{Key: "layers", Value: types.NewArray(nil, layer)},
+			{Key: "history", Value: types.NewArray(nil, history)},
+			{Key: "bases", Value: types.NewArray(nil, base)},
+		},
+		nil,
+	)
+
+	decl := rego.Function{
+		Name: ociImageLineageName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("ref", types.S).Description("OCI image reference"),
+				types.Named("base_refs", types.NewArray(nil, types.S)).Description("references of known base images, image indexes are matched by any of their images and images that cannot be fetched do not match"),
+			),
+			types.Named("lineage", lineage).Description("the layers and history of the image, and the base images it is built on"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic. But also mark it as non-deterministic because it does rely on external
+		// entities, i.e. OCI registry. https://www.openpolicyagent.org/docs/latest/extensions/
+		Memoize:          true,
+		Nondeterministic: true,
+	}
+
+	rego.RegisterBuiltin2(&decl, ociImageLineage)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      "Fetch the layer lineage of an image from its config, matching it against known base images.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
 func registerOCIImageTagRefs() {
 	resultType := types.NewArray([]types.Type{types.S}, nil)
 
@@ -482,10 +599,88 @@ func registerOCIImageReferrers() {
 	})
 }
 
+func registerOCIImageReferrersTree() {
+	annotations := types.NewObject(nil, types.NewDynamicProperty(types.S, types.S))
+	descriptor := types.NewObject(
+		[]*types.StaticProperty{
+			{Key: "mediaType", Value: types.S},
+			{Key: "size", Value: types.N},
+			{Key: "digest", Value: types.S},
+			{Key: "data", Value: types.S},
+			{Key: "urls", Value: types.NewArray(
+				[]types.Type{types.S}, nil,
+			)},
+			{Key: "annotations", Value: annotations},
+			{Key: "artifactType", Value: types.S},
+		},
+		nil,
+	)
+	manifest := types.NewObject(
+		[]*types.StaticProperty{
+			{Key: "schemaVersion", Value: types.N},
+			{Key: "mediaType", Value: types.S},
+			{Key: "config", Value: descriptor},
+			{Key: "layers", Value: types.NewArray(
+				[]types.Type{descriptor}, nil,
+			)},
+			{Key: "annotations", Value: annotations},
+			{Key: "subject", Value: descriptor},
+		},
+		nil,
+	)
+	referrer := types.NewObject(
+		[]*types.StaticProperty{
+			{Key: "mediaType", Value: types.S},
+			{Key: "size", Value: types.N},
+			{Key: "digest", Value: types.S},
+			{Key: "artifactType", Value: types.S},
+			{Key: "ref", Value: types.S},
+			{Key: "annotations", Value: annotations},
+			{Key: "manifest", Value: manifest},
+			// Rego types cannot be recursive, the nested referrers have the same
+			// shape as the referrer itself.
+			{Key: "referrers", Value: types.NewArray(nil, types.A)},
+		},
+		nil,
+	)
+
+	resultType := types.NewArray([]types.Type{referrer}, nil)
+
+	decl := rego.Function{
+		Name: ociImageReferrersTreeName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("ref", types.S).Description("OCI image reference"),
+				types.Named("depth", types.N).Description(fmt.Sprintf("number of referrer levels to walk, from 1 to %d", maxReferrersDepth)),
+				types.Named("artifact_types", types.NewArray(nil, types.S)).Description("artifact types of the referrers to include and walk, empty to include all"),
+			),
+			types.Named("referrers", resultType).Description("tree of referrer descriptors discovered via OCI Referrers API"),
+		),
+		Memoize:          true,
+		Nondeterministic: true,
+	}
+
+	rego.RegisterBuiltin3(&decl, ociImageReferrersTree)
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      "Discover artifacts attached to an image, and to those artifacts in turn, via OCI Referrers API.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
 func ociBlob(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 	return ociBlobInternal(bctx, a, true)
 }
 
+// Blob fetches the blob with the given digest reference the same way
+// ec.oci.blob does, sharing its component-scoped cache. Returns nil if the blob
+// could not be fetched or its digest does not match.
+func Blob(bctx rego.BuiltinContext, ref string) *ast.Term {
+	blob, _ := ociBlob(bctx, ast.StringTerm(ref))
+	return blob
+}
+
 func ociBlobInternal(bctx rego.BuiltinContext, a *ast.Term, verifyDigest bool) (*ast.Term, error) {
 	logger := log.WithField("function", ociBlobName)
 
@@ -777,12 +972,14 @@ var maxParallelManifestFetches = runtime.GOMAXPROCS(0) * 4
 var (
 	defaultComponentCache = &ComponentCache{} // fallback for blob/imageFiles when no context cache
 
-	descriptorCache  sync.Map // map[string]*ast.Term - for ociDescriptor (always global)
-	descriptorFlight singleflight.Group
-	manifestCache    sync.Map // map[string]*ast.Term - for ociImageManifest (always global)
-	manifestFlight   singleflight.Group
-	imageIndexCache  sync.Map // map[string]*ast.Term - for ociImageIndex (always global)
-	imageIndexFlight singleflight.Group
+	descriptorCache    sync.Map // map[string]*ast.Term - for ociDescriptor (always global)
+	descriptorFlight   singleflight.Group
+	manifestCache      sync.Map // map[string]*ast.Term - for ociImageManifest (always global)
+	manifestFlight     singleflight.Group
+	imageIndexCache    sync.Map // map[string]*ast.Term - for ociImageIndex (always global)
+	imageIndexFlight   singleflight.Group
+	imageLineageCache  sync.Map // map[string]*ast.Term - for ociImageLineage (always global)
+	imageLineageFlight singleflight.Group
 )
 
 // batchCallCounter tracks how many times ociImageManifestsBatch is called (for debugging)
@@ -797,6 +994,7 @@ func ClearCaches() {
 	descriptorCache = sync.Map{}
 	manifestCache = sync.Map{}
 	imageIndexCache = sync.Map{}
+	imageLineageCache = sync.Map{}
 }
 
 // ComponentCache holds per-component caches for heavy OCI data (blobs and image files).
@@ -1071,6 +1269,77 @@ func ociImageFiles(bctx rego.BuiltinContext, refTerm *ast.Term, pathsTerm *ast.T
 	return result.(*ast.Term), nil
 }
 
+func ociImageLayerFiles(bctx rego.BuiltinContext, refTerm *ast.Term) (*ast.Term, error) {
+	logger := log.WithField("function", ociImageLayerFilesName)
+
+	uri, ok := refTerm.Value.(ast.String)
+	if !ok {
+		logger.Error("input ref is not a string")
+		return nil, nil
+	}
+	refStr := string(uri)
+	logger = logger.WithField("ref", refStr)
+
+	cacheKey := "layers:" + refStr
+
+	// Use component-scoped cache if available, otherwise fall back to global.
+	// The listings of large images can be substantial and are unique per component.
+	cc := componentCacheFromContext(bctx.Context)
+
+	// Check cache first (fast path)
+	if cached, found := cc.filesCache.Load(cacheKey); found {
+		logger.Debug("Image layer files served from cache")
+		return cached.(*ast.Term), nil
+	}
+
+	// Use singleflight to prevent thundering herd
+	result, err, _ := cc.filesFlight.Do(cacheKey, func() (any, error) {
+		// Double-check cache inside singleflight
+		if cached, found := cc.filesCache.Load(cacheKey); found {
+			logger.Debug("Image layer files served from cache (after singleflight)")
+			return cached, nil
+		}
+		logger.Debug("Starting image layer files listing")
+
+		ref, err := name.NewDigest(refStr)
+		if err != nil {
+			logger.WithFields(log.Fields{
+				"action": "new digest",
+				"error":  err,
+			}).Error("failed to create new digest")
+			return nil, nil //nolint:nilerr
+		}
+
+		layers, err := files.LayerFiles(bctx.Context, ref)
+		if err != nil {
+			logger.WithFields(log.Fields{
+				"action": "list files",
+				"error":  err,
+			}).Error("failed to list image layer files")
+			return nil, nil //nolint:nilerr
+		}
+
+		layersValue, err := ast.InterfaceToValue(layers)
+		if err != nil {
+			logger.WithFields(log.Fields{
+				"action": "convert layers",
+				"error":  err,
+			}).Error("failed to convert layers to value")
+			return nil, nil //nolint:nilerr
+		}
+
+		logger.WithField("layer_count", len(layers)).Debug("Successfully listed image layer files")
+		term := ast.NewTerm(layersValue)
+		cc.filesCache.Store(cacheKey, term)
+		return term, nil
+	})
+
+	if err != nil || result == nil {
+		return nil, nil
+	}
+	return result.(*ast.Term), nil
+}
+
 func ociBlobFiles(bctx rego.BuiltinContext, refTerm *ast.Term, pathsTerm *ast.Term) (*ast.Term, error) {
 	logger := log.WithField("function", ociBlobFilesName)
 
@@ -1364,6 +1633,235 @@ func ociImageIndex(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 	return result.(*ast.Term), nil
 }
 
+// ociImageLineage returns the layers and history of an image, and which of the
+// given base images it is built on. Base images that cannot be fetched are
+// reported as not matching. Returns nil if the image cannot be fetched.
+func ociImageLineage(bctx rego.BuiltinContext, refTerm *ast.Term, baseRefsTerm *ast.Term) (*ast.Term, error) {
+	logger := log.WithField("function", ociImageLineageName)
+
+	uriValue, ok := refTerm.Value.(ast.String)
+	if !ok {
+		logger.Error("input is not a string")
+		return nil, nil
+	}
+	refStr := string(uriValue)
+	logger = logger.WithField("input_ref", refStr)
+
+	baseRefsArray, err := builtins.ArrayOperand(baseRefsTerm.Value, 2)
+	if err != nil {
+		logger.WithError(err).Error("failed to convert base refs to array operand")
+		return nil, nil
+	}
+	var baseRefs []string
+	err = baseRefsArray.Iter(func(t *ast.Term) error {
+		baseRef, ok := t.Value.(ast.String)
+		if !ok {
+			return fmt.Errorf("base ref is not a string: %#v", t)
+		}
+		baseRefs = append(baseRefs, string(baseRef))
+		return nil
+	})
+	if err != nil {
+		logger.WithError(err).Error("failed iterating base refs")
+		return nil, nil
+	}
+
+	baseRefsHash := fmt.Sprintf("%x", sha256.Sum256([]byte(baseRefsTerm.String())))[:12]
+	cacheKey := refStr + ":" + baseRefsHash
+
+	// Check cache first (fast path)
+	if cached, found := imageLineageCache.Load(cacheKey); found {
+		logger.Debug("Image lineage served from cache")
+		return cached.(*ast.Term), nil
+	}
+
+	// Use singleflight to prevent thundering herd
+	result, err, _ := imageLineageFlight.Do(cacheKey, func() (any, error) {
+		// Double-check cache inside singleflight
+		if cached, found := imageLineageCache.Load(cacheKey); found {
+			logger.Debug("Image lineage served from cache (after singleflight)")
+			return cached, nil
+		}
+		logger.Debug("Starting image lineage retrieval")
+
+		client := oci.NewClient(bctx.Context)
+
+		_, ref, err := resolveIfNeeded(client, refStr)
+		if err != nil {
+			logger.WithField("action", "resolveIfNeeded").Error(err)
+			return nil, nil //nolint:nilerr
+		}
+
+		image, err := client.Image(ref)
+		if err != nil {
+			logger.WithFields(log.Fields{
+				"action": "fetch image",
+				"error":  err,
+			}).Error("failed to fetch image")
+			return nil, nil //nolint:nilerr
+		}
+
+		configFile, err := image.ConfigFile()
+		if err != nil {
+			logger.WithFields(log.Fields{
+				"action": "fetch config",
+				"error":  err,
+			}).Error("failed to fetch image config")
+			return nil, nil //nolint:nilerr
+		}
+
+		manifest, err := image.Manifest()
+		if err != nil {
+			logger.WithFields(log.Fields{
+				"action": "fetch manifest",
+				"error":  err,
+			}).Error("failed to fetch manifest")
+			return nil, nil //nolint:nilerr
+		}
+
+		diffIDs := configFile.RootFS.DiffIDs
+
+		bases := make([]*ast.Term, 0, len(baseRefs))
+		layerBases := make([][]*ast.Term, len(diffIDs))
+		for _, baseRef := range baseRefs {
+			n, err := baseImageLayers(client, baseRef, diffIDs)
+			if err != nil {
+				logger.WithFields(log.Fields{
+					"action":   "fetch base image",
+					"base_ref": baseRef,
+					"error":    err,
+				}).Warn("failed to fetch base image, reporting it as not matching")
+			}
+
+			for i := 0; i < n; i++ {
+				layerBases[i] = append(layerBases[i], ast.StringTerm(baseRef))
+			}
+
+			bases = append(bases, ast.ObjectTerm(
+				ast.Item(ast.StringTerm("ref"), ast.StringTerm(baseRef)),
+				ast.Item(ast.StringTerm("match"), ast.BooleanTerm(n > 0)),
+				ast.Item(ast.StringTerm("layers"), ast.IntNumberTerm(n)),
+			))
+		}
+
+		// History entries that are not empty layers correspond to the layers in order
+		history := make([]*ast.Term, 0, len(configFile.History))
+		var layerHistory []*ast.Term
+		for _, h := range configFile.History {
+			term := newHistoryTerm(h)
+			history = append(history, term)
+			if !h.EmptyLayer {
+				layerHistory = append(layerHistory, term)
+			}
+		}
+
+		layers := make([]*ast.Term, 0, len(diffIDs))
+		for i, diffID := range diffIDs {
+			items := [][2]*ast.Term{
+				ast.Item(ast.StringTerm("diffID"), ast.StringTerm(diffID.String())),
+				ast.Item(ast.StringTerm("bases"), ast.ArrayTerm(layerBases[i]...)),
+			}
+			if i < len(manifest.Layers) {
+				items = append(items, ast.Item(ast.StringTerm("digest"), ast.StringTerm(manifest.Layers[i].Digest.String())))
+			}
+			if i < len(layerHistory) {
+				items = append(items, ast.Item(ast.StringTerm("history"), layerHistory[i]))
+			}
+			layers = append(layers, ast.ObjectTerm(items...))
+		}
+
+		logger.Debug("Successfully retrieved image lineage")
+		term := ast.ObjectTerm(
+			ast.Item(ast.StringTerm("layers"), ast.ArrayTerm(layers...)),
+			ast.Item(ast.StringTerm("history"), ast.ArrayTerm(history...)),
+			ast.Item(ast.StringTerm("bases"), ast.ArrayTerm(bases...)),
+		)
+		imageLineageCache.Store(cacheKey, term)
+		return term, nil
+	})
+
+	if err != nil || result == nil {
+		return nil, nil
+	}
+	return result.(*ast.Term), nil
+}
+
+// baseImageLayers returns the number of leading layers the image with the
+// given diff IDs shares with the base image, i.e. the number of layers of the
+// base image if the image is built on it, or 0 if it is not. For image indexes
+// the longest match of any of the images in the index is returned.
+func baseImageLayers(client oci.Client, uri string, diffIDs []v1.Hash) (int, error) {
+	_, ref, err := resolveIfNeeded(client, uri)
+	if err != nil {
+		return 0, err
+	}
+
+	descriptor, err := client.Head(ref)
+	if err != nil {
+		return 0, err
+	}
+
+	var images []v1.Image
+	if descriptor.MediaType.IsIndex() {
+		index, err := client.Index(ref)
+		if err != nil {
+			return 0, err
+		}
+
+		indexManifest, err := index.IndexManifest()
+		if err != nil {
+			return 0, err
+		}
+
+		for _, m := range indexManifest.Manifests {
+			if !m.MediaType.IsImage() {
+				continue
+			}
+			image, err := index.Image(m.Digest)
+			if err != nil {
+				return 0, err
+			}
+			images = append(images, image)
+		}
+	} else {
+		image, err := client.Image(ref)
+		if err != nil {
+			return 0, err
+		}
+		images = append(images, image)
+	}
+
+	layers := 0
+	for _, image := range images {
+		configFile, err := image.ConfigFile()
+		if err != nil {
+			return 0, err
+		}
+
+		baseDiffIDs := configFile.RootFS.DiffIDs
+		if len(baseDiffIDs) <= len(diffIDs) && slices.Equal(baseDiffIDs, diffIDs[:len(baseDiffIDs)]) {
+			layers = max(layers, len(baseDiffIDs))
+		}
+	}
+
+	return layers, nil
+}
+
+func newHistoryTerm(h v1.History) *ast.Term {
+	created := ""
+	if !h.Created.IsZero() {
+		created = h.Created.UTC().Format(time.RFC3339)
+	}
+
+	return ast.ObjectTerm(
+		ast.Item(ast.StringTerm("created"), ast.StringTerm(created)),
+		ast.Item(ast.StringTerm("created_by"), ast.StringTerm(h.CreatedBy)),
+		ast.Item(ast.StringTerm("author"), ast.StringTerm(h.Author)),
+		ast.Item(ast.StringTerm("comment"), ast.StringTerm(h.Comment)),
+		ast.Item(ast.StringTerm("empty_layer"), ast.BooleanTerm(h.EmptyLayer)),
+	)
+}
+
 // ociImageTagRefs discovers tag-based artifacts attached to an image using legacy cosign conventions.
 // It checks for .sig, .att, and .sbom suffixed tags and returns references to any that exist.
 // Returns nil if the reference cannot be resolved.
@@ -1471,27 +1969,12 @@ func ociImageReferrers(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error)
 
 	client := oci.NewClient(bctx.Context)
 
-	// Resolve to digest if needed
-	resolvedStr, ref, err := resolveIfNeeded(client, refStr)
+	digestRef, err := resolveDigestReference(client, refStr)
 	if err != nil {
 		logger.WithError(err).Error("failed to resolve reference")
 		return nil, nil
 	}
 
-	// Convert to digest reference (needed for the Referrers API)
-	var digestRef name.Digest
-	if d, ok := ref.(name.Digest); ok {
-		// Already a digest
-		digestRef = d
-	} else {
-		// Tag reference - parse the resolved string which includes the digest
-		digestRef, err = name.NewDigest(resolvedStr)
-		if err != nil {
-			logger.WithError(err).Error("failed to create digest reference from resolved string")
-			return nil, nil
-		}
-	}
-
 	// Use remote options from context
 	remoteOpts := oci.CreateRemoteOptions(bctx.Context)
 
@@ -1505,20 +1988,11 @@ func ociImageReferrers(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error)
 	var referrerDescriptors []*ast.Term
 	for _, descriptor := range indexManifest.Manifests {
 		// Build a simplified descriptor object with essential fields
-		referrerRef := fmt.Sprintf("%s@%s", ref.Context().Name(), descriptor.Digest.String())
-
-		descriptorTerm := ast.ObjectTerm(
-			ast.Item(ast.StringTerm("mediaType"), ast.StringTerm(string(descriptor.MediaType))),
-			ast.Item(ast.StringTerm("size"), ast.NumberTerm(json.Number(fmt.Sprintf("%d", descriptor.Size)))),
-			ast.Item(ast.StringTerm("digest"), ast.StringTerm(descriptor.Digest.String())),
-			ast.Item(ast.StringTerm("artifactType"), ast.StringTerm(descriptor.ArtifactType)),
-			ast.Item(ast.StringTerm("ref"), ast.StringTerm(referrerRef)),
-			ast.Item(ast.StringTerm("annotations"), newAnnotationsTerm(descriptor.Annotations)),
-		)
+		referrerRef := digestRef.Context().Digest(descriptor.Digest.String())
 
-		referrerDescriptors = append(referrerDescriptors, descriptorTerm)
+		referrerDescriptors = append(referrerDescriptors, ast.ObjectTerm(newReferrerItems(referrerRef, descriptor)...))
 		logger.WithFields(log.Fields{
-			"referrer": referrerRef,
+			"referrer": referrerRef.String(),
 			"type":     descriptor.ArtifactType,
 		}).Debug("found referrer via OCI Referrers API")
 	}
@@ -1527,6 +2001,184 @@ func ociImageReferrers(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error)
 	return ast.ArrayTerm(referrerDescriptors...), nil
 }
 
+// maxReferrersDepth bounds the depth of the referrer graph walked by
+// ociImageReferrersTree.
+const maxReferrersDepth = 10
+
+// ociImageReferrersTree walks the referrer graph of an image using the OCI
+// Referrers API, up to the given depth. Only referrers with one of the given
+// artifact types are included and followed, or all referrers when no artifact
+// types are given. Returns nil if the reference cannot be resolved or if any of
+// the Referrers API calls fail.
+func ociImageReferrersTree(bctx rego.BuiltinContext, refTerm *ast.Term, depthTerm *ast.Term, artifactTypesTerm *ast.Term) (*ast.Term, error) {
+	logger := log.WithField("function", ociImageReferrersTreeName)
+
+	uriValue, ok := refTerm.Value.(ast.String)
+	if !ok {
+		logger.Error("input is not a string")
+		return nil, nil
+	}
+	refStr := string(uriValue)
+	logger = logger.WithField("input_ref", refStr)
+
+	depth, err := builtins.IntOperand(depthTerm.Value, 2)
+	if err != nil {
+		logger.WithError(err).Error("failed to parse depth")
+		return nil, nil
+	}
+	if depth < 1 || depth > maxReferrersDepth {
+		logger.WithField("depth", depth).Errorf("depth must be between 1 and %d", maxReferrersDepth)
+		return nil, nil
+	}
+
+	artifactTypesArray, err := builtins.ArrayOperand(artifactTypesTerm.Value, 3)
+	if err != nil {
+		logger.WithError(err).Error("failed to parse artifact types")
+		return nil, nil
+	}
+	artifactTypes := make([]string, 0, artifactTypesArray.Len())
+	err = artifactTypesArray.Iter(func(t *ast.Term) error {
+		artifactType, ok := t.Value.(ast.String)
+		if !ok {
+			return fmt.Errorf("artifact type is not a string: %#v", t)
+		}
+		artifactTypes = append(artifactTypes, string(artifactType))
+		return nil
+	})
+	if err != nil {
+		logger.WithError(err).Error("failed to parse artifact types")
+		return nil, nil
+	}
+
+	digestRef, err := resolveDigestReference(oci.NewClient(bctx.Context), refStr)
+	if err != nil {
+		logger.WithError(err).Error("failed to resolve reference")
+		return nil, nil
+	}
+
+	w := referrersWalker{
+		bctx:          bctx,
+		artifactTypes: artifactTypes,
+		remoteOpts:    oci.CreateRemoteOptions(bctx.Context),
+		fetches:       make(chan struct{}, maxParallelManifestFetches),
+	}
+
+	referrers, err := w.walk(bctx.Context, digestRef, depth)
+	if err != nil {
+		logger.WithError(err).Error("failed to walk referrers via OCI Referrers API")
+		return nil, nil
+	}
+
+	logger.WithField("found_count", len(referrers)).Debug("OCI Referrers API graph discovery complete")
+	return ast.ArrayTerm(referrers...), nil
+}
+
+// referrersWalker walks the referrer graph concurrently. The referrers of each
+// subject are processed in parallel, with the number of in-flight registry
+// requests bounded by maxParallelManifestFetches across all levels.
+type referrersWalker struct {
+	bctx          rego.BuiltinContext
+	artifactTypes []string
+	remoteOpts    []remote.Option
+	// fetches is a semaphore limiting the concurrent registry requests
+	fetches chan struct{}
+}
+
+// fetch runs fn once a registry request slot is available.
+func (w *referrersWalker) fetch(ctx context.Context, fn func()) error {
+	select {
+	case w.fetches <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-w.fetches }()
+
+	fn()
+	return nil
+}
+
+// walk returns the referrers of the subject, descending depth levels.
+func (w *referrersWalker) walk(ctx context.Context, subject name.Digest, depth int) ([]*ast.Term, error) {
+	var index *v1.IndexManifest
+	var err error
+	if ferr := w.fetch(ctx, func() {
+		index, err = ociremote.Referrers(subject, "", ociremote.WithRemoteOptions(w.remoteOpts...))
+	}); ferr != nil {
+		return nil, ferr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching referrers of %q: %w", subject, err)
+	}
+
+	var descriptors []v1.Descriptor
+	for _, d := range index.Manifests {
+		if len(w.artifactTypes) == 0 || slices.Contains(w.artifactTypes, d.ArtifactType) {
+			descriptors = append(descriptors, d)
+		}
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	referrers := make([]*ast.Term, len(descriptors))
+	for i, d := range descriptors {
+		g.Go(func() error {
+			referrer, err := w.referrer(gctx, subject.Context().Digest(d.Digest.String()), d, depth)
+			referrers[i] = referrer
+			return err
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return referrers, nil
+}
+
+// referrer returns the referrer descriptor with its image manifest, if it is
+// an image, and the referrers attached to it.
+func (w *referrersWalker) referrer(ctx context.Context, ref name.Digest, d v1.Descriptor, depth int) (*ast.Term, error) {
+	items := newReferrerItems(ref, d)
+
+	if d.MediaType.IsImage() {
+		// fetched via ociImageManifest to make use of the manifest cache
+		var manifest *ast.Term
+		if err := w.fetch(ctx, func() {
+			bctx := w.bctx
+			bctx.Context = ctx
+			manifest, _ = ociImageManifest(bctx, ast.StringTerm(ref.String()))
+		}); err != nil {
+			return nil, err
+		}
+		if manifest != nil {
+			items = append(items, ast.Item(ast.StringTerm("manifest"), manifest))
+		}
+	}
+
+	referrers := []*ast.Term{}
+	if depth > 1 {
+		var err error
+		if referrers, err = w.walk(ctx, ref, depth-1); err != nil {
+			return nil, err
+		}
+	}
+	items = append(items, ast.Item(ast.StringTerm("referrers"), ast.ArrayTerm(referrers...)))
+
+	return ast.ObjectTerm(items...), nil
+}
+
+// newReferrerItems returns the items of the simplified descriptor object of a
+// referrer.
+func newReferrerItems(ref name.Digest, d v1.Descriptor) [][2]*ast.Term {
+	return [][2]*ast.Term{
+		ast.Item(ast.StringTerm("mediaType"), ast.StringTerm(string(d.MediaType))),
+		ast.Item(ast.StringTerm("size"), ast.NumberTerm(json.Number(fmt.Sprintf("%d", d.Size)))),
+		ast.Item(ast.StringTerm("digest"), ast.StringTerm(d.Digest.String())),
+		ast.Item(ast.StringTerm("artifactType"), ast.StringTerm(d.ArtifactType)),
+		ast.Item(ast.StringTerm("ref"), ast.StringTerm(ref.String())),
+		ast.Item(ast.StringTerm("annotations"), newAnnotationsTerm(d.Annotations)),
+	}
+}
+
 func newPlatformTerm(p v1.Platform) *ast.Term {
 	osFeatures := []*ast.Term{}
 	for _, f := range p.OSFeatures {
@@ -1601,6 +2253,26 @@ func resolveIfNeeded(client oci.Client, uri string) (string, name.Reference, err
 	return resolved, ref, nil
 }
 
+// resolveDigestReference resolves the reference to a digest reference, as
+// needed by the Referrers API.
+func resolveDigestReference(client oci.Client, uri string) (name.Digest, error) {
+	resolved, ref, err := resolveIfNeeded(client, uri)
+	if err != nil {
+		return name.Digest{}, err
+	}
+
+	if d, ok := ref.(name.Digest); ok {
+		return d, nil
+	}
+
+	// Tag reference - parse the resolved string which includes the digest
+	d, err := name.NewDigest(resolved)
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("unable to create digest reference: %w", err)
+	}
+	return d, nil
+}
+
 func parseReference(uri string) (name.Reference, error) {
 	// Try to parse as digest first, if that fails with ErrBadName, try as tag
 	ref, err := name.NewDigest(uri)
@@ -1637,9 +2309,12 @@ func init() {
 	registerOCIBlobFiles()
 	registerOCIDescriptor()
 	registerOCIImageFiles()
+	registerOCIImageLayerFiles()
 	registerOCIImageManifest()
 	registerOCIImageManifestsBatch()
 	registerOCIImageIndex()
+	registerOCIImageLineage()
 	registerOCIImageTagRefs()
 	registerOCIImageReferrers()
+	registerOCIImageReferrersTree()
 }
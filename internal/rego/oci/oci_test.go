@@ -23,18 +23,22 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gkampitakis/go-snaps/snaps"
 	"github.com/google/go-containerregistry/pkg/crane"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/registry"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
 	v1fake "github.com/google/go-containerregistry/pkg/v1/fake"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/partial"
@@ -1054,6 +1058,245 @@ func TestOCIImageFiles(t *testing.T) {
 	}
 }
 
+func TestOCIImageLayerFiles(t *testing.T) {
+	t.Cleanup(ClearCaches)
+	ClearCaches()
+
+	image, err := crane.Image(map[string][]byte{
+		"etc/passwd": []byte(`root:x:0:0:root:/root:/bin/bash`),
+	})
+	require.NoError(t, err)
+
+	layers, err := image.Layers()
+	require.NoError(t, err)
+	require.Len(t, layers, 1)
+	digest, err := layers[0].Digest()
+	require.NoError(t, err)
+	diffID, err := layers[0].DiffID()
+	require.NoError(t, err)
+
+	ref := "registry.local/spam@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b"
+
+	cases := []struct {
+		name      string
+		uri       *ast.Term
+		expected  string
+		remoteErr error
+	}{
+		{
+			name: "success",
+			uri:  ast.StringTerm(ref),
+			expected: fmt.Sprintf(`[{
+				"digest": %q,
+				"diffID": %q,
+				"mediaType": "application/vnd.docker.image.rootfs.diff.tar.gzip",
+				"files": [{"path": "/etc/passwd", "type": "file", "mode": 0, "size": 31, "uid": 0, "gid": 0, "link": ""}]
+			}]`, digest, diffID),
+		},
+		{
+			name: "non string URI",
+			uri:  ast.BooleanTerm(true),
+		},
+		{
+			name: "unpinned",
+			uri:  ast.StringTerm("registry.local/spam:latest"),
+		},
+		{
+			name:      "remote error",
+			uri:       ast.StringTerm(ref),
+			remoteErr: errors.New("kaboom!"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ClearCaches()
+
+			client := fake.FakeClient{}
+			if c.remoteErr != nil {
+				client.On("Image", mock.Anything).Return(nil, c.remoteErr)
+			} else {
+				client.On("Image", mock.Anything).Return(image, nil)
+			}
+
+			ctx := oci.WithClient(context.Background(), &client)
+			bctx := rego.BuiltinContext{Context: ctx}
+
+			result, err := ociImageLayerFiles(bctx, c.uri)
+			require.NoError(t, err)
+			if c.expected == "" {
+				require.Nil(t, result)
+			} else {
+				require.NotNil(t, result)
+				require.JSONEq(t, c.expected, result.String())
+			}
+		})
+	}
+}
+
+func TestOCIImageLineage(t *testing.T) {
+	t.Cleanup(ClearCaches)
+	ClearCaches()
+
+	created := v1.Time{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	layer := func() v1.Layer {
+		l, err := random.Layer(128, types.DockerLayer)
+		require.NoError(t, err)
+		return l
+	}
+
+	base, err := mutate.Append(empty.Image,
+		mutate.Addendum{Layer: layer(), History: v1.History{CreatedBy: "ADD rootfs.tar /", Created: created}},
+		mutate.Addendum{Layer: layer(), History: v1.History{CreatedBy: "RUN dnf install -y bash"}},
+	)
+	require.NoError(t, err)
+
+	image, err := mutate.Append(base,
+		mutate.Addendum{Layer: layer(), History: v1.History{CreatedBy: "COPY app /app", Author: "me", Comment: "app"}},
+	)
+	require.NoError(t, err)
+	configFile, err := image.ConfigFile()
+	require.NoError(t, err)
+	configFile = configFile.DeepCopy()
+	configFile.History = append(configFile.History, v1.History{CreatedBy: "ENTRYPOINT [\"/app\"]", EmptyLayer: true})
+	image, err = mutate.ConfigFile(image, configFile)
+	require.NoError(t, err)
+
+	other, err := random.Image(128, 2)
+	require.NoError(t, err)
+
+	baseIndex := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{Add: other, Descriptor: v1.Descriptor{MediaType: types.DockerManifestSchema2}},
+		mutate.IndexAddendum{Add: base, Descriptor: v1.Descriptor{MediaType: types.DockerManifestSchema2}},
+	)
+
+	const (
+		imageRef     = "registry.local/app@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b"
+		baseRef      = "registry.local/base@sha256:01ba4719c80b6fe911b091a7c05124b64eeece964e09c058ef8f9805daca546b"
+		baseIndexRef = "registry.local/base-index@sha256:01ba4719c80b6fe911b091a7c05124b64eeece964e09c058ef8f9805daca546b"
+		otherRef     = "registry.local/other@sha256:01ba4719c80b6fe911b091a7c05124b64eeece964e09c058ef8f9805daca546b"
+		missingRef   = "registry.local/missing@sha256:01ba4719c80b6fe911b091a7c05124b64eeece964e09c058ef8f9805daca546b"
+	)
+
+	digest := func(ref string) name.Digest {
+		d, err := name.NewDigest(ref)
+		require.NoError(t, err)
+		return d
+	}
+
+	imageConfig, err := image.ConfigFile()
+	require.NoError(t, err)
+	manifest, err := image.Manifest()
+	require.NoError(t, err)
+
+	layerJSON := func(i int, history string, bases ...string) string {
+		basesJSON, err := json.Marshal(append([]string{}, bases...))
+		require.NoError(t, err)
+		return fmt.Sprintf(`{"diffID": %q, "digest": %q, "history": %s, "bases": %s}`,
+			imageConfig.RootFS.DiffIDs[i], manifest.Layers[i].Digest, history, basesJSON)
+	}
+
+	const (
+		rootfsHistory = `{"created": "2024-01-02T03:04:05Z", "created_by": "ADD rootfs.tar /", "author": "", "comment": "", "empty_layer": false}`
+		dnfHistory    = `{"created": "", "created_by": "RUN dnf install -y bash", "author": "", "comment": "", "empty_layer": false}`
+		appHistory    = `{"created": "", "created_by": "COPY app /app", "author": "me", "comment": "app", "empty_layer": false}`
+		entryHistory  = `{"created": "", "created_by": "ENTRYPOINT [\"/app\"]", "author": "", "comment": "", "empty_layer": true}`
+	)
+	history := fmt.Sprintf(`[%s, %s, %s, %s]`, rootfsHistory, dnfHistory, appHistory, entryHistory)
+
+	cases := []struct {
+		name     string
+		uri      *ast.Term
+		bases    *ast.Term
+		expected string
+	}{
+		{
+			name:  "no base images",
+			uri:   ast.StringTerm(imageRef),
+			bases: ast.ArrayTerm(),
+			expected: fmt.Sprintf(`{"layers": [%s, %s, %s], "history": %s, "bases": []}`,
+				layerJSON(0, rootfsHistory), layerJSON(1, dnfHistory), layerJSON(2, appHistory), history),
+		},
+		{
+			name:  "base images",
+			uri:   ast.StringTerm(imageRef),
+			bases: ast.ArrayTerm(ast.StringTerm(baseRef), ast.StringTerm(baseIndexRef), ast.StringTerm(otherRef)),
+			expected: fmt.Sprintf(`{"layers": [%s, %s, %s], "history": %s, "bases": [
+				{"ref": %q, "match": true, "layers": 2},
+				{"ref": %q, "match": true, "layers": 2},
+				{"ref": %q, "match": false, "layers": 0}
+			]}`,
+				layerJSON(0, rootfsHistory, baseRef, baseIndexRef),
+				layerJSON(1, dnfHistory, baseRef, baseIndexRef),
+				layerJSON(2, appHistory),
+				history, baseRef, baseIndexRef, otherRef),
+		},
+		{
+			name:  "non string URI",
+			uri:   ast.BooleanTerm(true),
+			bases: ast.ArrayTerm(),
+		},
+		{
+			name:  "base refs not an array",
+			uri:   ast.StringTerm(imageRef),
+			bases: ast.StringTerm(baseRef),
+		},
+		{
+			name:  "base ref not a string",
+			uri:   ast.StringTerm(imageRef),
+			bases: ast.ArrayTerm(ast.BooleanTerm(true)),
+		},
+		{
+			name:  "missing base image",
+			uri:   ast.StringTerm(imageRef),
+			bases: ast.ArrayTerm(ast.StringTerm(missingRef), ast.StringTerm(baseRef)),
+			expected: fmt.Sprintf(`{"layers": [%s, %s, %s], "history": %s, "bases": [
+				{"ref": %q, "match": false, "layers": 0},
+				{"ref": %q, "match": true, "layers": 2}
+			]}`,
+				layerJSON(0, rootfsHistory, baseRef),
+				layerJSON(1, dnfHistory, baseRef),
+				layerJSON(2, appHistory),
+				history, missingRef, baseRef),
+		},
+		{
+			name:  "missing image",
+			uri:   ast.StringTerm(missingRef),
+			bases: ast.ArrayTerm(),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ClearCaches()
+
+			client := fake.FakeClient{}
+			client.On("Image", digest(imageRef)).Return(image, nil)
+			client.On("Head", digest(baseRef)).Return(&v1.Descriptor{MediaType: types.DockerManifestSchema2}, nil)
+			client.On("Image", digest(baseRef)).Return(base, nil)
+			client.On("Head", digest(baseIndexRef)).Return(&v1.Descriptor{MediaType: types.OCIImageIndex}, nil)
+			client.On("Index", digest(baseIndexRef)).Return(baseIndex, nil)
+			client.On("Head", digest(otherRef)).Return(&v1.Descriptor{MediaType: types.DockerManifestSchema2}, nil)
+			client.On("Image", digest(otherRef)).Return(other, nil)
+			client.On("Head", digest(missingRef)).Return(nil, errors.New("not found"))
+			client.On("Image", digest(missingRef)).Return(nil, errors.New("not found"))
+
+			ctx := oci.WithClient(context.Background(), &client)
+			bctx := rego.BuiltinContext{Context: ctx}
+
+			result, err := ociImageLineage(bctx, c.uri, c.bases)
+			require.NoError(t, err)
+			if c.expected == "" {
+				require.Nil(t, result)
+			} else {
+				require.NotNil(t, result)
+				require.JSONEq(t, c.expected, result.String())
+			}
+		})
+	}
+}
+
 func TestOCIImageIndex(t *testing.T) {
 	t.Cleanup(ClearCaches)
 	ClearCaches()
@@ -1253,11 +1496,14 @@ func TestFunctionsRegistered(t *testing.T) {
 		ociBlobFilesName,
 		ociDescriptorName,
 		ociImageFilesName,
+		ociImageLayerFilesName,
 		ociImageManifestName,
 		ociImageManifestsBatchName,
 		ociImageIndexName,
+		ociImageLineageName,
 		ociImageTagRefsName,
 		ociImageReferrersName,
+		ociImageReferrersTreeName,
 	}
 	for _, name := range names {
 		t.Run(name, func(t *testing.T) {
@@ -1805,3 +2051,224 @@ func TestOCIImageReferrers(t *testing.T) {
 		}
 	})
 }
+
+func TestOCIImageReferrersTree(t *testing.T) {
+	t.Cleanup(ClearCaches)
+	ClearCaches()
+
+	registryServer := httptest.NewServer(registry.New(
+		registry.WithReferrersSupport(true),
+	))
+	t.Cleanup(registryServer.Close)
+
+	u, err := url.Parse(registryServer.URL)
+	require.NoError(t, err)
+
+	repo := fmt.Sprintf("localhost:%s/test-repo/tree", u.Port())
+
+	// push attaches a random artifact with the given artifact type to the
+	// subject, if any
+	push := func(artifactType types.MediaType, subject v1.Image) v1.Image {
+		artifact, err := random.Image(512, 1)
+		require.NoError(t, err)
+		artifact = mutate.ConfigMediaType(artifact, artifactType)
+		artifact = mutate.Annotations(artifact, map[string]string{"kind": string(artifactType)}).(v1.Image)
+
+		if subject != nil {
+			subjectDescriptor, err := partial.Descriptor(subject)
+			require.NoError(t, err)
+			artifact = mutate.Subject(artifact, *subjectDescriptor).(v1.Image)
+		}
+
+		digest, err := artifact.Digest()
+		require.NoError(t, err)
+		ref, err := name.NewDigest(fmt.Sprintf("%s@%s", repo, digest))
+		require.NoError(t, err)
+		require.NoError(t, remote.Write(ref, artifact))
+
+		return artifact
+	}
+
+	refOf := func(img v1.Image) string {
+		digest, err := img.Digest()
+		require.NoError(t, err)
+		return fmt.Sprintf("%s@%s", repo, digest)
+	}
+
+	const (
+		scanType      = types.MediaType("application/vnd.example.scan+json")
+		sbomType      = types.MediaType("application/spdx+json")
+		signatureType = types.MediaType("application/vnd.dev.cosign.artifact.sig.v1+json")
+	)
+
+	img := push(types.OCIConfigJSON, nil)
+	scan := push(scanType, img)
+	sbom := push(sbomType, scan)
+	scanSignature := push(signatureType, scan)
+	signature := push(signatureType, img)
+
+	tag, err := name.NewTag(repo + ":latest")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(tag, img))
+
+	// tree is a simplified, order independent, representation of the result:
+	// artifact type and ref of the referrers with their nested referrers
+	type tree map[string]tree
+	var simplify func(t *testing.T, term *ast.Term) tree
+	simplify = func(t *testing.T, term *ast.Term) tree {
+		arr, ok := term.Value.(*ast.Array)
+		require.True(t, ok, "referrers should be an array")
+
+		result := tree{}
+		for i := 0; i < arr.Len(); i++ {
+			obj, ok := arr.Elem(i).Value.(ast.Object)
+			require.True(t, ok, "referrer should be an object")
+
+			artifactType := string(obj.Get(ast.StringTerm("artifactType")).Value.(ast.String))
+			ref := string(obj.Get(ast.StringTerm("ref")).Value.(ast.String))
+
+			_, ok = obj.Get(ast.StringTerm("annotations")).Value.(ast.Object)
+			require.True(t, ok, "annotations should be an object")
+
+			// the manifest of image referrers is included
+			manifest := obj.Get(ast.StringTerm("manifest"))
+			require.NotNil(t, manifest, "referrer should have manifest")
+			require.Equal(t, ast.StringTerm(artifactType), manifest.Get(ast.StringTerm("annotations")).Get(ast.StringTerm("kind")))
+
+			result[artifactType+" "+ref] = simplify(t, obj.Get(ast.StringTerm("referrers")))
+		}
+
+		return result
+	}
+
+	// node returns the tree of the given referrers
+	node := func(artifactType types.MediaType, img v1.Image, referrers tree) tree {
+		if referrers == nil {
+			referrers = tree{}
+		}
+		return tree{string(artifactType) + " " + refOf(img): referrers}
+	}
+	merge := func(trees ...tree) tree {
+		result := tree{}
+		for _, t := range trees {
+			maps.Copy(result, t)
+		}
+		return result
+	}
+
+	cases := []struct {
+		name          string
+		ref           *ast.Term
+		depth         *ast.Term
+		artifactTypes *ast.Term
+		want          tree
+	}{
+		{
+			name:          "single level",
+			ref:           ast.StringTerm(refOf(img)),
+			depth:         ast.IntNumberTerm(1),
+			artifactTypes: ast.ArrayTerm(),
+			want: merge(
+				node(scanType, scan, nil),
+				node(signatureType, signature, nil),
+			),
+		},
+		{
+			name:          "nested",
+			ref:           ast.StringTerm(refOf(img)),
+			depth:         ast.IntNumberTerm(2),
+			artifactTypes: ast.ArrayTerm(),
+			want: merge(
+				node(scanType, scan, merge(node(sbomType, sbom, nil), node(signatureType, scanSignature, nil))),
+				node(signatureType, signature, nil),
+			),
+		},
+		{
+			name:          "depth beyond graph",
+			ref:           ast.StringTerm(refOf(img)),
+			depth:         ast.IntNumberTerm(maxReferrersDepth),
+			artifactTypes: ast.ArrayTerm(),
+			want: merge(
+				node(scanType, scan, merge(node(sbomType, sbom, nil), node(signatureType, scanSignature, nil))),
+				node(signatureType, signature, nil),
+			),
+		},
+		{
+			name:          "artifact type filter",
+			ref:           ast.StringTerm(repo + ":latest"),
+			depth:         ast.IntNumberTerm(2),
+			artifactTypes: ast.ArrayTerm(ast.StringTerm(string(scanType)), ast.StringTerm(string(sbomType))),
+			want:          node(scanType, scan, node(sbomType, sbom, nil)),
+		},
+		{
+			name:          "filtered out subject",
+			ref:           ast.StringTerm(refOf(img)),
+			depth:         ast.IntNumberTerm(2),
+			artifactTypes: ast.ArrayTerm(ast.StringTerm(string(sbomType))),
+			want:          tree{},
+		},
+		{
+			name:          "invalid ref type",
+			ref:           ast.IntNumberTerm(42),
+			depth:         ast.IntNumberTerm(1),
+			artifactTypes: ast.ArrayTerm(),
+		},
+		{
+			name:          "invalid reference format",
+			ref:           ast.StringTerm("...invalid..."),
+			depth:         ast.IntNumberTerm(1),
+			artifactTypes: ast.ArrayTerm(),
+		},
+		{
+			name:          "zero depth",
+			ref:           ast.StringTerm(refOf(img)),
+			depth:         ast.IntNumberTerm(0),
+			artifactTypes: ast.ArrayTerm(),
+		},
+		{
+			name:          "depth too large",
+			ref:           ast.StringTerm(refOf(img)),
+			depth:         ast.IntNumberTerm(maxReferrersDepth + 1),
+			artifactTypes: ast.ArrayTerm(),
+		},
+		{
+			name:          "invalid artifact type",
+			ref:           ast.StringTerm(refOf(img)),
+			depth:         ast.IntNumberTerm(1),
+			artifactTypes: ast.ArrayTerm(ast.IntNumberTerm(42)),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ClearCaches()
+
+			bctx := rego.BuiltinContext{Context: context.Background()}
+
+			got, err := ociImageReferrersTree(bctx, c.ref, c.depth, c.artifactTypes)
+			require.NoError(t, err)
+
+			if c.want == nil {
+				require.Nil(t, got)
+				return
+			}
+
+			require.NotNil(t, got)
+			require.Equal(t, c.want, simplify(t, got))
+		})
+	}
+
+	t.Run("manifests are cached", func(t *testing.T) {
+		ClearCaches()
+
+		bctx := rego.BuiltinContext{Context: context.Background()}
+		got, err := ociImageReferrersTree(bctx, ast.StringTerm(refOf(img)), ast.IntNumberTerm(1), ast.ArrayTerm())
+		require.NoError(t, err)
+		require.NotNil(t, got)
+
+		for _, referrer := range []v1.Image{scan, signature} {
+			_, found := manifestCache.Load(refOf(referrer))
+			require.True(t, found, "manifest of %s should be cached", refOf(referrer))
+		}
+	})
+}
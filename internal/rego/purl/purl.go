@@ -21,6 +21,9 @@
 package rego
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/open-policy-agent/opa/v1/ast"
 	"github.com/open-policy-agent/opa/v1/rego"
 	"github.com/open-policy-agent/opa/v1/types"
@@ -31,6 +34,32 @@ import (
 const (
 	purlIsValidName = "ec.purl.is_valid"
 	purlParseName   = "ec.purl.parse"
+	purlCompareName = "ec.purl.compare"
+	purlInRangeName = "ec.purl.in_range"
+)
+
+// PURLObjectType is the type of the parsed PURL object, as returned by
+// ec.purl.parse.
+var PURLObjectType = types.NewObject(
+	[]*types.StaticProperty{
+		// Specifying the properties like this ensure the compiler catches typos when
+		// evaluating rego functions.
+		{Key: "type", Value: types.S},
+		{Key: "namespace", Value: types.S},
+		{Key: "name", Value: types.S},
+		{Key: "version", Value: types.S},
+		{Key: "qualifiers", Value: types.NewArray(
+			nil, types.NewObject(
+				[]*types.StaticProperty{
+					{Key: "key", Value: types.S},
+					{Key: "value", Value: types.S},
+				},
+				nil,
+			),
+		)},
+		{Key: "subpath", Value: types.S},
+	},
+	nil,
 )
 
 func registerPURLIsValid() {
@@ -67,27 +96,7 @@ func registerPURLParse() {
 			types.Args(
 				types.Named("purl", types.S).Description("the PURL"),
 			),
-			types.Named("object", types.NewObject(
-				[]*types.StaticProperty{
-					// Specifying the properties like this ensure the compiler catches typos when
-					// evaluating rego functions.
-					{Key: "type", Value: types.S},
-					{Key: "namespace", Value: types.S},
-					{Key: "name", Value: types.S},
-					{Key: "version", Value: types.S},
-					{Key: "qualifiers", Value: types.NewArray(
-						nil, types.NewObject(
-							[]*types.StaticProperty{
-								{Key: "key", Value: types.S},
-								{Key: "value", Value: types.S},
-							},
-							nil,
-						),
-					)},
-					{Key: "subpath", Value: types.S},
-				},
-				nil,
-			)).Description("the parsed PURL object"),
+			types.Named("object", PURLObjectType).Description("the parsed PURL object"),
 		),
 		// As per the documentation, enable memoization to ensure function evaluation is
 		// deterministic.
@@ -107,6 +116,62 @@ func registerPURLParse() {
 	})
 }
 
+func registerPURLCompare() {
+	decl := rego.Function{
+		Name: purlCompareName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("a", types.S).Description("the first PURL"),
+				types.Named("b", types.S).Description("the second PURL"),
+			),
+			types.Named("result", types.N).Description("-1, 0 or 1 if the version of the first PURL is lower than, equal to or greater than the version of the second PURL"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic.
+		Memoize:          true,
+		Nondeterministic: false,
+	}
+
+	rego.RegisterBuiltin2(&decl, purlCompare)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      "Compare the versions of two PURLs of the same type using the versioning scheme of the type: RPM for rpm, Debian for deb, Maven for maven, PEP 440 for pypi and semantic versioning for cargo, golang, npm and nuget PURLs.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
+func registerPURLInRange() {
+	decl := rego.Function{
+		Name: purlInRangeName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("purl", types.S).Description("the PURL"),
+				types.Named("range", types.S).Description("the version range in the vers format, e.g. vers:rpm/>=3.0.0|<3.0.7"),
+			),
+			types.Named("result", types.B).Description("true if the version of the PURL is within the range"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic.
+		Memoize:          true,
+		Nondeterministic: false,
+	}
+
+	rego.RegisterBuiltin2(&decl, purlInRange)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      "Determine whether or not the version of a PURL is within a vers version range. The versioning scheme of the range must match the PURL type.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
 func purlIsValid(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 	logger := log.WithField("function", purlIsValidName)
 
@@ -136,13 +201,132 @@ func purlParse(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 	}
 	logger = logger.WithField("purl", string(uri))
 
-	instance, err := packageurl.FromString(string(uri))
+	term, err := ParsePURL(string(uri))
 	if err != nil {
 		logger.WithField("error", err).Error("failed to parse PURL")
 		return nil, nil
 	}
 
 	logger.Debug("successfully parsed PURL")
+	return term, nil
+}
+
+func purlCompare(bctx rego.BuiltinContext, a, b *ast.Term) (*ast.Term, error) {
+	logger := log.WithField("function", purlCompareName)
+
+	uriA, ok := a.Value.(ast.String)
+	if !ok {
+		logger.Debug("first input is not a string")
+		return nil, nil
+	}
+	uriB, ok := b.Value.(ast.String)
+	if !ok {
+		logger.Debug("second input is not a string")
+		return nil, nil
+	}
+	logger = logger.WithFields(log.Fields{"a": string(uriA), "b": string(uriB)})
+
+	schemeA, versionA, err := purlVersion(string(uriA))
+	if err != nil {
+		logger.WithField("error", err).Error("failed to parse PURL")
+		return nil, nil
+	}
+	schemeB, versionB, err := purlVersion(string(uriB))
+	if err != nil {
+		logger.WithField("error", err).Error("failed to parse PURL")
+		return nil, nil
+	}
+
+	if schemeA != schemeB {
+		logger.Error("PURLs use different versioning schemes")
+		return nil, nil
+	}
+
+	result, err := comparators[schemeA](versionA, versionB)
+	if err != nil {
+		logger.WithField("error", err).Error("failed to compare PURL versions")
+		return nil, nil
+	}
+
+	return ast.IntNumberTerm(result), nil
+}
+
+func purlInRange(bctx rego.BuiltinContext, a, b *ast.Term) (*ast.Term, error) {
+	logger := log.WithField("function", purlInRangeName)
+
+	uri, ok := a.Value.(ast.String)
+	if !ok {
+		logger.Debug("purl input is not a string")
+		return nil, nil
+	}
+	vers, ok := b.Value.(ast.String)
+	if !ok {
+		logger.Debug("range input is not a string")
+		return nil, nil
+	}
+	logger = logger.WithFields(log.Fields{"purl": string(uri), "range": string(vers)})
+
+	contains, err := InRange(string(uri), string(vers))
+	if err != nil {
+		logger.WithField("error", err).Error("failed to match the PURL version")
+		return nil, nil
+	}
+
+	return ast.BooleanTerm(contains), nil
+}
+
+// InRange determines whether or not the version of the PURL is within the
+// version range given in the vers format.
+func InRange(uri, vers string) (bool, error) {
+	scheme, version, err := purlVersion(uri)
+	if err != nil {
+		return false, err
+	}
+
+	versionRange, err := parseVers(vers)
+	if err != nil {
+		return false, err
+	}
+
+	if versionRange.scheme != scheme {
+		return false, fmt.Errorf("version range %q does not apply to PURL %q", vers, uri)
+	}
+
+	return versionRange.contains(version)
+}
+
+// purlVersion returns the versioning scheme and the version of the PURL.
+func purlVersion(uri string) (string, string, error) {
+	instance, err := packageurl.FromString(uri)
+	if err != nil {
+		return "", "", err
+	}
+
+	if instance.Version == "" {
+		return "", "", fmt.Errorf("PURL %q has no version", uri)
+	}
+
+	scheme, err := versioningScheme(instance.Type)
+	if err != nil {
+		return "", "", err
+	}
+
+	version := instance.Version
+	// RPM PURLs provide the epoch as a qualifier
+	if epoch := instance.Qualifiers.Map()["epoch"]; scheme == schemeRPM && epoch != "" && !strings.Contains(version, ":") {
+		version = epoch + ":" + version
+	}
+
+	return scheme, version, nil
+}
+
+// ParsePURL parses the PURL into the object returned by ec.purl.parse.
+func ParsePURL(uri string) (*ast.Term, error) {
+	instance, err := packageurl.FromString(uri)
+	if err != nil {
+		return nil, err
+	}
+
 	qualifiers := ast.NewArray()
 	for _, q := range instance.Qualifiers {
 		o := ast.NewObject(
@@ -164,4 +348,6 @@ func purlParse(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 func init() {
 	registerPURLIsValid()
 	registerPURLParse()
+	registerPURLCompare()
+	registerPURLInRange()
 }
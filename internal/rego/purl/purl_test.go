@@ -107,6 +107,8 @@ func TestFunctionsRegistered(t *testing.T) {
 	names := []string{
 		purlIsValidName,
 		purlParseName,
+		purlCompareName,
+		purlInRangeName,
 	}
 	for _, name := range names {
 		t.Run(name, func(t *testing.T) {
@@ -119,3 +121,142 @@ func TestFunctionsRegistered(t *testing.T) {
 		})
 	}
 }
+
+func TestPURLCompare(t *testing.T) {
+	cases := []struct {
+		name     string
+		a        *ast.Term
+		b        *ast.Term
+		expected *ast.Term
+	}{
+		{
+			name:     "rpm lower",
+			a:        ast.StringTerm("pkg:rpm/redhat/openssl@3.0.1-43.el9?arch=x86_64"),
+			b:        ast.StringTerm("pkg:rpm/redhat/openssl@3.0.7-1.el9"),
+			expected: ast.IntNumberTerm(-1),
+		},
+		{
+			name:     "rpm epoch",
+			a:        ast.StringTerm("pkg:rpm/redhat/openssl@1:3.0.1-43.el9"),
+			b:        ast.StringTerm("pkg:rpm/redhat/openssl@3.0.7-1.el9"),
+			expected: ast.IntNumberTerm(1),
+		},
+		{
+			name:     "rpm epoch qualifier",
+			a:        ast.StringTerm("pkg:rpm/redhat/openssl@3.0.1-43.el9?arch=x86_64&epoch=1"),
+			b:        ast.StringTerm("pkg:rpm/redhat/openssl@1:3.0.1-43.el9"),
+			expected: ast.IntNumberTerm(0),
+		},
+		{
+			name:     "npm equal",
+			a:        ast.StringTerm("pkg:npm/lodash@4.17.21"),
+			b:        ast.StringTerm("pkg:npm/lodash@4.17.21"),
+			expected: ast.IntNumberTerm(0),
+		},
+		{
+			name:     "pypi greater",
+			a:        ast.StringTerm("pkg:pypi/django@4.2"),
+			b:        ast.StringTerm("pkg:pypi/django@4.2rc1"),
+			expected: ast.IntNumberTerm(1),
+		},
+		{
+			name: "different types",
+			a:    ast.StringTerm("pkg:npm/lodash@4.17.21"),
+			b:    ast.StringTerm("pkg:pypi/django@4.2"),
+		},
+		{
+			name: "unsupported type",
+			a:    ast.StringTerm("pkg:generic/curl@7.50.3"),
+			b:    ast.StringTerm("pkg:generic/curl@7.50.4"),
+		},
+		{
+			name: "missing version",
+			a:    ast.StringTerm("pkg:npm/lodash"),
+			b:    ast.StringTerm("pkg:npm/lodash@4.17.21"),
+		},
+		{
+			name: "invalid version",
+			a:    ast.StringTerm("pkg:npm/lodash@latest"),
+			b:    ast.StringTerm("pkg:npm/lodash@4.17.21"),
+		},
+		{
+			name: "unexpected type",
+			a:    ast.IntNumberTerm(42),
+			b:    ast.StringTerm("pkg:npm/lodash@4.17.21"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bctx := rego.BuiltinContext{Context: context.Background()}
+
+			result, err := purlCompare(bctx, c.a, c.b)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, result)
+		})
+	}
+}
+
+func TestPURLInRange(t *testing.T) {
+	cases := []struct {
+		name     string
+		purl     *ast.Term
+		vers     *ast.Term
+		expected *ast.Term
+	}{
+		{
+			name:     "rpm below",
+			purl:     ast.StringTerm("pkg:rpm/redhat/openssl@3.0.1-43.el9?arch=x86_64"),
+			vers:     ast.StringTerm("vers:rpm/<3.0.7"),
+			expected: ast.BooleanTerm(true),
+		},
+		{
+			name:     "rpm not below",
+			purl:     ast.StringTerm("pkg:rpm/redhat/openssl@3.0.7-1.el9"),
+			vers:     ast.StringTerm("vers:rpm/<3.0.7"),
+			expected: ast.BooleanTerm(false),
+		},
+		{
+			name:     "maven interval",
+			purl:     ast.StringTerm("pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"),
+			vers:     ast.StringTerm("vers:maven/>=2.0-beta9|<2.15.0"),
+			expected: ast.BooleanTerm(true),
+		},
+		{
+			name:     "golang any",
+			purl:     ast.StringTerm("pkg:golang/golang.org/x/net@v0.17.0"),
+			vers:     ast.StringTerm("vers:golang/*"),
+			expected: ast.BooleanTerm(true),
+		},
+		{
+			name: "mismatched scheme",
+			purl: ast.StringTerm("pkg:rpm/redhat/openssl@3.0.1-43.el9"),
+			vers: ast.StringTerm("vers:deb/<3.0.7"),
+		},
+		{
+			name: "malformed range",
+			purl: ast.StringTerm("pkg:rpm/redhat/openssl@3.0.1-43.el9"),
+			vers: ast.StringTerm("rpm/<3.0.7"),
+		},
+		{
+			name: "malformed PURL",
+			purl: ast.StringTerm("pkg::rpm//fedora/curl7.50.3-1.fc25?arch=i386&distro=fedora-"),
+			vers: ast.StringTerm("vers:rpm/<3.0.7"),
+		},
+		{
+			name: "unexpected type",
+			purl: ast.StringTerm("pkg:rpm/redhat/openssl@3.0.1-43.el9"),
+			vers: ast.IntNumberTerm(42),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bctx := rego.BuiltinContext{Context: context.Background()}
+
+			result, err := purlInRange(bctx, c.purl, c.vers)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, result)
+		})
+	}
+}
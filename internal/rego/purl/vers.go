@@ -0,0 +1,202 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rego
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// versConstraint is a single constraint of a vers version range, e.g. ">=1.2.3"
+type versConstraint struct {
+	comparator string
+	version    string
+}
+
+// versRange is a parsed version range in the vers format, see
+// https://github.com/package-url/purl-spec/blob/main/VERSION-RANGE-SPEC.rst
+type versRange struct {
+	scheme string
+	// any is set for the "*" range that matches all versions
+	any         bool
+	constraints []versConstraint
+	compare     versionComparator
+}
+
+// versComparators in the order they need to be matched, i.e. two character
+// comparators before their one character prefixes.
+var versComparators = []string{">=", "<=", "!=", "<", ">", "="}
+
+func parseVers(vers string) (*versRange, error) {
+	// whitespace is not significant in vers
+	vers = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, vers)
+
+	spec, ok := strings.CutPrefix(vers, "vers:")
+	if !ok {
+		return nil, fmt.Errorf("version range %q does not start with vers:", vers)
+	}
+
+	versioning, constraints, ok := strings.Cut(spec, "/")
+	if !ok || versioning == "" {
+		return nil, fmt.Errorf("version range %q is missing the versioning scheme", vers)
+	}
+
+	scheme, err := versioningScheme(versioning)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &versRange{
+		scheme:  scheme,
+		compare: comparators[scheme],
+	}
+
+	if constraints == "*" {
+		r.any = true
+		return r, nil
+	}
+
+	for _, c := range strings.Split(constraints, "|") {
+		if c == "" {
+			return nil, fmt.Errorf("version range %q contains an empty constraint", vers)
+		}
+
+		constraint := versConstraint{comparator: "="}
+		for _, comparator := range versComparators {
+			if v, ok := strings.CutPrefix(c, comparator); ok {
+				constraint.comparator = comparator
+				c = v
+				break
+			}
+		}
+
+		if constraint.version, err = url.PathUnescape(c); err != nil {
+			return nil, fmt.Errorf("invalid version in range %q: %w", vers, err)
+		}
+
+		if constraint.version == "" {
+			return nil, fmt.Errorf("version range %q contains a constraint without a version", vers)
+		}
+
+		r.constraints = append(r.constraints, constraint)
+	}
+
+	// validates the versions of all constraints as a side effect of sorting
+	var errs []error
+	slices.SortStableFunc(r.constraints, func(a, b versConstraint) int {
+		c, err := r.compare(a.version, b.version)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return c
+	})
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return r, nil
+}
+
+// contains checks if the version is within the range, following the algorithm
+// given by the vers specification.
+func (r *versRange) contains(version string) (bool, error) {
+	if r.any {
+		return true, nil
+	}
+
+	compare := func(c versConstraint) (int, error) {
+		return r.compare(version, c.version)
+	}
+
+	intervals := make([]versConstraint, 0, len(r.constraints))
+	for _, c := range r.constraints {
+		switch c.comparator {
+		case "=", "!=":
+			cmp, err := compare(c)
+			if err != nil {
+				return false, err
+			}
+			if cmp == 0 {
+				return c.comparator == "=", nil
+			}
+		default:
+			intervals = append(intervals, c)
+		}
+	}
+
+	satisfies := func(c versConstraint) (bool, error) {
+		cmp, err := compare(c)
+		if err != nil {
+			return false, err
+		}
+
+		switch c.comparator {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		case ">=":
+			return cmp >= 0, nil
+		}
+		return false, nil
+	}
+
+	isLower := func(c versConstraint) bool {
+		return c.comparator == ">" || c.comparator == ">="
+	}
+
+	for i, c := range intervals {
+		ok, err := satisfies(c)
+		if err != nil {
+			return false, err
+		}
+
+		switch {
+		// unbounded below
+		case i == 0 && !isLower(c):
+			if ok {
+				return true, nil
+			}
+		// unbounded above
+		case i == len(intervals)-1 && isLower(c):
+			if ok {
+				return true, nil
+			}
+		// bounded on both sides
+		case isLower(c) && !isLower(intervals[i+1]):
+			if !ok {
+				continue
+			}
+			if ok, err = satisfies(intervals[i+1]); err != nil || ok {
+				return ok, err
+			}
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,752 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rego
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// versionComparator compares two versions, returning -1, 0 or 1 if the first
+// version is lower than, equal to or greater than the second version.
+type versionComparator func(a, b string) (int, error)
+
+const (
+	schemeSemver = "semver"
+	schemeRPM    = "rpm"
+	schemeDebian = "deb"
+	schemeMaven  = "maven"
+	schemePEP440 = "pep440"
+)
+
+var comparators = map[string]versionComparator{
+	schemeSemver: compareSemver,
+	schemeRPM:    compareRPM,
+	schemeDebian: compareDebian,
+	schemeMaven:  compareMaven,
+	schemePEP440: comparePEP440,
+}
+
+// versioningSchemes maps the PURL types, and the versioning schemes used in
+// vers version ranges, to the semantics used to compare their versions.
+var versioningSchemes = map[string]string{
+	"cargo":  schemeSemver,
+	"deb":    schemeDebian,
+	"golang": schemeSemver,
+	"maven":  schemeMaven,
+	"npm":    schemeSemver,
+	"nuget":  schemeSemver,
+	"pypi":   schemePEP440,
+	"rpm":    schemeRPM,
+	"semver": schemeSemver,
+}
+
+// versioningScheme returns the scheme used to compare versions of the given
+// PURL type, or vers versioning scheme.
+func versioningScheme(typ string) (string, error) {
+	scheme, ok := versioningSchemes[strings.ToLower(typ)]
+	if !ok {
+		return "", fmt.Errorf("unsupported versioning scheme for %q", typ)
+	}
+
+	return scheme, nil
+}
+
+func sign(i int) int {
+	switch {
+	case i < 0:
+		return -1
+	case i > 0:
+		return 1
+	}
+	return 0
+}
+
+// compareSemver compares semantic versions, the "v" prefix is optional
+func compareSemver(a, b string) (int, error) {
+	va, vb := a, b
+	if !strings.HasPrefix(va, "v") {
+		va = "v" + va
+	}
+	if !strings.HasPrefix(vb, "v") {
+		vb = "v" + vb
+	}
+
+	if !semver.IsValid(va) {
+		return 0, fmt.Errorf("invalid semantic version %q", a)
+	}
+	if !semver.IsValid(vb) {
+		return 0, fmt.Errorf("invalid semantic version %q", b)
+	}
+
+	return semver.Compare(va, vb), nil
+}
+
+// splitEpoch splits the optional numeric epoch, i.e. "epoch:", prefix from the
+// version, as used by RPM and Debian versions.
+func splitEpoch(v string) (int, string, error) {
+	e, rest, ok := strings.Cut(v, ":")
+	if !ok {
+		return 0, v, nil
+	}
+
+	epoch, err := strconv.Atoi(e)
+	if err != nil || epoch < 0 {
+		return 0, "", fmt.Errorf("invalid epoch in version %q", v)
+	}
+
+	return epoch, rest, nil
+}
+
+// compareRPM compares RPM [epoch:]version[-release] versions. As with RPM
+// dependencies, the releases are compared only if both versions include the
+// release, i.e. 3.0.7 is equal to 3.0.7-1.el9.
+func compareRPM(a, b string) (int, error) {
+	ea, ra, err := splitEpoch(a)
+	if err != nil {
+		return 0, err
+	}
+	eb, rb, err := splitEpoch(b)
+	if err != nil {
+		return 0, err
+	}
+
+	if ea != eb {
+		return sign(ea - eb), nil
+	}
+
+	va, relA, _ := strings.Cut(ra, "-")
+	vb, relB, _ := strings.Cut(rb, "-")
+
+	if c := rpmvercmp(va, vb); c != 0 || relA == "" || relB == "" {
+		return c, nil
+	}
+
+	return rpmvercmp(relA, relB), nil
+}
+
+func isASCIIDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isASCIIAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// rpmvercmp is a port of the rpmvercmp function from the RPM library
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for i < len(a) && !isASCIIDigit(a[i]) && !isASCIIAlpha(a[i]) && a[i] != '~' && a[i] != '^' {
+			i++
+		}
+		for j < len(b) && !isASCIIDigit(b[j]) && !isASCIIAlpha(b[j]) && b[j] != '~' && b[j] != '^' {
+			j++
+		}
+
+		// tilde sorts before everything else, even the end of the version
+		if (i < len(a) && a[i] == '~') || (j < len(b) && b[j] == '~') {
+			if i >= len(a) || a[i] != '~' {
+				return 1
+			}
+			if j >= len(b) || b[j] != '~' {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		// caret sorts after the end of the version, but before everything else
+		if (i < len(a) && a[i] == '^') || (j < len(b) && b[j] == '^') {
+			if i >= len(a) {
+				return -1
+			}
+			if j >= len(b) {
+				return 1
+			}
+			if a[i] != '^' {
+				return 1
+			}
+			if b[j] != '^' {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		if i >= len(a) || j >= len(b) {
+			break
+		}
+
+		isNum := isASCIIDigit(a[i])
+		segment := isASCIIAlpha
+		if isNum {
+			segment = isASCIIDigit
+		}
+
+		si, sj := i, j
+		for i < len(a) && segment(a[i]) {
+			i++
+		}
+		for j < len(b) && segment(b[j]) {
+			j++
+		}
+
+		sa, sb := a[si:i], b[sj:j]
+		if sb == "" {
+			// numeric segments are newer than alpha segments
+			if isNum {
+				return 1
+			}
+			return -1
+		}
+
+		if isNum {
+			sa = strings.TrimLeft(sa, "0")
+			sb = strings.TrimLeft(sb, "0")
+			if len(sa) != len(sb) {
+				return sign(len(sa) - len(sb))
+			}
+		}
+
+		if c := strings.Compare(sa, sb); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case i >= len(a) && j >= len(b):
+		return 0
+	case i >= len(a):
+		return -1
+	}
+	return 1
+}
+
+// compareDebian compares Debian [epoch:]upstream_version[-debian_revision]
+// versions as dpkg does.
+func compareDebian(a, b string) (int, error) {
+	ea, ra, err := splitEpoch(a)
+	if err != nil {
+		return 0, err
+	}
+	eb, rb, err := splitEpoch(b)
+	if err != nil {
+		return 0, err
+	}
+
+	if ea != eb {
+		return sign(ea - eb), nil
+	}
+
+	va, revA := splitDebianRevision(ra)
+	vb, revB := splitDebianRevision(rb)
+
+	if va == "" || !isASCIIDigit(va[0]) {
+		return 0, fmt.Errorf("invalid Debian version %q", a)
+	}
+	if vb == "" || !isASCIIDigit(vb[0]) {
+		return 0, fmt.Errorf("invalid Debian version %q", b)
+	}
+
+	if c := verrevcmp(va, vb); c != 0 {
+		return sign(c), nil
+	}
+
+	return sign(verrevcmp(revA, revB)), nil
+}
+
+// splitDebianRevision splits the Debian revision, following the last hyphen,
+// from the upstream version.
+func splitDebianRevision(v string) (string, string) {
+	if i := strings.LastIndex(v, "-"); i >= 0 {
+		return v[:i], v[i+1:]
+	}
+
+	return v, ""
+}
+
+// debianOrder is the sort weight of a character in a non-digit part of the
+// Debian version: tilde sorts before everything, even the end of the part,
+// then letters and then all other characters.
+func debianOrder(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+
+	switch c := s[i]; {
+	case isASCIIDigit(c):
+		return 0
+	case isASCIIAlpha(c):
+		return int(c)
+	case c == '~':
+		return -1
+	default:
+		return int(c) + 256
+	}
+}
+
+// verrevcmp is a port of the verrevcmp function from dpkg
+func verrevcmp(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		firstDiff := 0
+
+		for (i < len(a) && !isASCIIDigit(a[i])) || (j < len(b) && !isASCIIDigit(b[j])) {
+			ac, bc := debianOrder(a, i), debianOrder(b, j)
+			if ac != bc {
+				return ac - bc
+			}
+			i++
+			j++
+		}
+
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+
+		for i < len(a) && j < len(b) && isASCIIDigit(a[i]) && isASCIIDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+
+		if i < len(a) && isASCIIDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isASCIIDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+
+	return 0
+}
+
+// mavenItem is an item of a parsed Maven version, one of mavenInt, mavenString
+// or mavenList.
+type mavenItem interface {
+	// compareTo compares the item to the other item, nil standing for a
+	// missing item when the versions differ in length.
+	compareTo(other mavenItem) int
+	isNull() bool
+}
+
+// mavenInt holds the digits of a numeric item without leading zeros, so that
+// arbitrarily large numbers can be compared.
+type mavenInt string
+
+// mavenQualifiers are the well-known qualifiers in ascending order, the
+// empty qualifier denotes a release.
+var mavenQualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+var mavenAliases = map[string]string{
+	"ga":      "",
+	"final":   "",
+	"release": "",
+	"cr":      "rc",
+}
+
+type mavenString string
+
+type mavenList []mavenItem
+
+func (i mavenInt) isNull() bool {
+	return i == ""
+}
+
+func (i mavenInt) compareTo(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if i.isNull() {
+			return 0
+		}
+		return 1
+	case mavenInt:
+		if len(i) != len(o) {
+			return sign(len(i) - len(o))
+		}
+		return strings.Compare(string(i), string(o))
+	}
+	// numbers are greater than qualifiers and lists
+	return 1
+}
+
+func newMavenString(s string, followedByDigit bool) mavenString {
+	if followedByDigit && len(s) == 1 {
+		switch s {
+		case "a":
+			s = "alpha"
+		case "b":
+			s = "beta"
+		case "m":
+			s = "milestone"
+		}
+	}
+
+	if alias, ok := mavenAliases[s]; ok {
+		s = alias
+	}
+
+	return mavenString(s)
+}
+
+// comparable returns a string that sorts the qualifiers according to
+// mavenQualifiers, with unknown qualifiers sorted lexically after the known
+// ones.
+func (s mavenString) comparable() string {
+	for i, q := range mavenQualifiers {
+		if q == string(s) {
+			return strconv.Itoa(i)
+		}
+	}
+
+	return fmt.Sprintf("%d-%s", len(mavenQualifiers), s)
+}
+
+func (s mavenString) isNull() bool {
+	return s == ""
+}
+
+func (s mavenString) compareTo(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		return strings.Compare(s.comparable(), mavenString("").comparable())
+	case mavenString:
+		return strings.Compare(s.comparable(), o.comparable())
+	case mavenInt, mavenList:
+		return -1
+	}
+	return 0
+}
+
+func (l mavenList) isNull() bool {
+	return len(l) == 0
+}
+
+func (l mavenList) compareTo(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if len(l) == 0 {
+			return 0
+		}
+		return l[0].compareTo(nil)
+	case mavenInt:
+		return -1
+	case mavenString:
+		return 1
+	case mavenList:
+		for i := 0; i < len(l) || i < len(o); i++ {
+			var c int
+			switch {
+			case i >= len(l):
+				c = -o[i].compareTo(nil)
+			case i >= len(o):
+				c = l[i].compareTo(nil)
+			default:
+				c = l[i].compareTo(o[i])
+			}
+			if c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// normalize removes the trailing null items, e.g. "1.0" is equal to "1".
+func (l mavenList) normalize() mavenList {
+	for i := len(l) - 1; i >= 0; i-- {
+		if l[i].isNull() {
+			l = slices.Delete(l, i, i+1)
+		} else if _, ok := l[i].(mavenList); !ok {
+			break
+		}
+	}
+	return l
+}
+
+func newMavenItem(isDigit bool, s string) mavenItem {
+	if isDigit {
+		return mavenInt(strings.TrimLeft(s, "0"))
+	}
+	return newMavenString(s, false)
+}
+
+// parseMaven parses the version as Maven's ComparableVersion does: items are
+// separated by dots, hyphens or transitions between digits and letters, each
+// hyphen and transition starting a nested list.
+func parseMaven(version string) mavenList {
+	version = strings.ToLower(version)
+
+	// stack of the lists being parsed, the last one is the current list
+	stack := []mavenList{{}}
+	add := func(item mavenItem) {
+		stack[len(stack)-1] = append(stack[len(stack)-1], item)
+	}
+
+	isDigit := false
+	start := 0
+	for i := 0; i < len(version); i++ {
+		c := version[i]
+		switch {
+		case c == '.':
+			if i == start {
+				add(mavenInt(""))
+			} else {
+				add(newMavenItem(isDigit, version[start:i]))
+			}
+			start = i + 1
+		case c == '-':
+			if i == start {
+				add(mavenInt(""))
+			} else {
+				add(newMavenItem(isDigit, version[start:i]))
+			}
+			start = i + 1
+			stack = append(stack, mavenList{})
+		case isASCIIDigit(c):
+			if !isDigit && i > start {
+				add(newMavenString(version[start:i], true))
+				start = i
+				stack = append(stack, mavenList{})
+			}
+			isDigit = true
+		default:
+			if isDigit && i > start {
+				add(newMavenItem(true, version[start:i]))
+				start = i
+				stack = append(stack, mavenList{})
+			}
+			isDigit = false
+		}
+	}
+
+	if len(version) > start {
+		add(newMavenItem(isDigit, version[start:]))
+	}
+
+	// fold the nested lists into their parents, normalizing each
+	for len(stack) > 1 {
+		list := stack[len(stack)-1].normalize()
+		stack = stack[:len(stack)-1]
+		add(list)
+	}
+
+	return stack[0].normalize()
+}
+
+// compareMaven compares Maven versions following the ordering of Maven's
+// ComparableVersion, any string is a valid Maven version.
+func compareMaven(a, b string) (int, error) {
+	return sign(parseMaven(a).compareTo(parseMaven(b))), nil
+}
+
+// pep440Pattern is the version pattern from the PEP 440 specification
+var pep440Pattern = regexp.MustCompile(`(?i)^\s*v?` +
+	`(?:(?P<epoch>[0-9]+)!)?` +
+	`(?P<release>[0-9]+(?:\.[0-9]+)*)` +
+	`(?:[-_.]?(?P<pre_l>a|b|c|rc|alpha|beta|pre|preview)[-_.]?(?P<pre_n>[0-9]+)?)?` +
+	`(?:-(?P<post_n1>[0-9]+)|[-_.]?(?P<post_l>post|rev|r)[-_.]?(?P<post_n2>[0-9]+)?)?` +
+	`(?:[-_.]?(?P<dev_l>dev)[-_.]?(?P<dev_n>[0-9]+)?)?` +
+	`(?:\+(?P<local>[a-z0-9]+(?:[-_.][a-z0-9]+)*))?\s*$`)
+
+type pep440Version struct {
+	epoch   int
+	release []int
+	// preLabel is one of "a", "b" or "rc", or empty if not a pre-release
+	preLabel string
+	pre      int
+	hasPost  bool
+	post     int
+	hasDev   bool
+	dev      int
+	local    []string
+}
+
+func parsePEP440(v string) (pep440Version, error) {
+	m := pep440Pattern.FindStringSubmatch(v)
+	if m == nil {
+		return pep440Version{}, fmt.Errorf("invalid PEP 440 version %q", v)
+	}
+
+	group := func(name string) string {
+		return m[pep440Pattern.SubexpIndex(name)]
+	}
+
+	number := func(s string) int {
+		// the pattern guarantees digits, overflows are treated as the
+		// largest value
+		n, err := strconv.Atoi(s)
+		if err != nil && s != "" {
+			return int(^uint(0) >> 1)
+		}
+		return n
+	}
+
+	parsed := pep440Version{
+		epoch: number(group("epoch")),
+	}
+
+	for _, r := range strings.Split(group("release"), ".") {
+		parsed.release = append(parsed.release, number(r))
+	}
+	for len(parsed.release) > 1 && parsed.release[len(parsed.release)-1] == 0 {
+		parsed.release = parsed.release[:len(parsed.release)-1]
+	}
+
+	switch l := strings.ToLower(group("pre_l")); l {
+	case "":
+	case "alpha":
+		parsed.preLabel = "a"
+	case "beta":
+		parsed.preLabel = "b"
+	case "c", "pre", "preview":
+		parsed.preLabel = "rc"
+	default:
+		parsed.preLabel = l
+	}
+	parsed.pre = number(group("pre_n"))
+
+	if group("post_n1") != "" || group("post_l") != "" {
+		parsed.hasPost = true
+		parsed.post = number(group("post_n1") + group("post_n2"))
+	}
+
+	if group("dev_l") != "" {
+		parsed.hasDev = true
+		parsed.dev = number(group("dev_n"))
+	}
+
+	if local := group("local"); local != "" {
+		parsed.local = strings.FieldsFunc(strings.ToLower(local), func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		})
+	}
+
+	return parsed, nil
+}
+
+// preRank orders the pre-release part: development releases of the final
+// release sort before any pre-release, and final releases sort after them.
+func (v pep440Version) preRank() (int, string, int) {
+	switch {
+	case v.preLabel == "" && !v.hasPost && v.hasDev:
+		return -1, "", 0
+	case v.preLabel == "":
+		return 1, "", 0
+	}
+	return 0, v.preLabel, v.pre
+}
+
+func comparePEP440(a, b string) (int, error) {
+	va, err := parsePEP440(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parsePEP440(b)
+	if err != nil {
+		return 0, err
+	}
+
+	if va.epoch != vb.epoch {
+		return sign(va.epoch - vb.epoch), nil
+	}
+
+	if c := slices.Compare(va.release, vb.release); c != 0 {
+		return c, nil
+	}
+
+	rankA, labelA, preA := va.preRank()
+	rankB, labelB, preB := vb.preRank()
+	if rankA != rankB {
+		return sign(rankA - rankB), nil
+	}
+	if c := strings.Compare(labelA, labelB); c != 0 {
+		return c, nil
+	}
+	if preA != preB {
+		return sign(preA - preB), nil
+	}
+
+	// no post-release sorts before any post-release
+	if va.hasPost != vb.hasPost {
+		if va.hasPost {
+			return 1, nil
+		}
+		return -1, nil
+	}
+	if va.post != vb.post {
+		return sign(va.post - vb.post), nil
+	}
+
+	// no development release sorts after any development release
+	if va.hasDev != vb.hasDev {
+		if va.hasDev {
+			return -1, nil
+		}
+		return 1, nil
+	}
+	if va.dev != vb.dev {
+		return sign(va.dev - vb.dev), nil
+	}
+
+	return compareLocal(va.local, vb.local), nil
+}
+
+// compareLocal compares the local version labels, numeric segments sort after
+// alphanumeric ones and, when all segments match, the longer label sorts last.
+func compareLocal(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		na, errA := strconv.Atoi(a[i])
+		nb, errB := strconv.Atoi(b[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return sign(na - nb)
+			}
+		case errA == nil:
+			return 1
+		case errB == nil:
+			return -1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return sign(len(a) - len(b))
+}
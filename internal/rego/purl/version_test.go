@@ -0,0 +1,174 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package rego
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		scheme   string
+		a        string
+		b        string
+		expected int
+	}{
+		{schemeSemver, "1.2.3", "1.2.3", 0},
+		{schemeSemver, "v1.2.3", "1.10.0", -1},
+		{schemeSemver, "1.0.0", "1.0.0-rc.1", 1},
+		{schemeSemver, "0.0.0-20241115175113-a2b48b605b42", "0.1.0", -1},
+		{schemeRPM, "3.0.7-1.el9", "3.0.7-1.el9", 0},
+		{schemeRPM, "3.0.1-43.el9", "3.0.7", -1},
+		{schemeRPM, "3.0.7", "3.0.7-1.el9", 0},
+		{schemeRPM, "1:1.0", "2.0", 1},
+		{schemeRPM, "1.0~rc1", "1.0", -1},
+		{schemeRPM, "1.0^git1", "1.0", 1},
+		{schemeRPM, "1.0^git1", "1.0.1", -1},
+		{schemeRPM, "1.0a", "1.0.1", -1},
+		{schemeRPM, "1.010", "1.9", 1},
+		{schemeRPM, "2.0-1.el9", "2.0-1.el9_2", -1},
+		{schemeDebian, "1.0-1", "1.0-1", 0},
+		{schemeDebian, "1.0~rc1-1", "1.0-1", -1},
+		{schemeDebian, "1:0.9", "2.0", 1},
+		{schemeDebian, "1.0", "1.0-0", 0},
+		{schemeDebian, "1.0+dfsg-1", "1.0-1", 1},
+		{schemeDebian, "2.36-9+deb12u4", "2.36-9+deb12u10", -1},
+		{schemeMaven, "1.0", "1", 0},
+		{schemeMaven, "1.0.0-final", "1", 0},
+		{schemeMaven, "1-alpha-1", "1-beta-1", -1},
+		{schemeMaven, "1.0-alpha1", "1.0-a1", 0},
+		{schemeMaven, "2.0-beta9", "2.0", -1},
+		{schemeMaven, "1.0-rc1", "1.0-cr1", 0},
+		{schemeMaven, "1.0-SNAPSHOT", "1.0", -1},
+		{schemeMaven, "1.0-sp1", "1.0", 1},
+		{schemeMaven, "1.0.1", "1.0-sp1", 1},
+		{schemeMaven, "2.15.0", "2.14.1", 1},
+		{schemeMaven, "1.0-foo", "1.0-sp", 1},
+		{schemeMaven, "12345678901234567890", "12345678901234567891", -1},
+		{schemePEP440, "1.0", "1.0.0", 0},
+		{schemePEP440, "1.0.dev1", "1.0a1", -1},
+		{schemePEP440, "1.0a1", "1.0b1", -1},
+		{schemePEP440, "1.0rc1", "1.0c1", 0},
+		{schemePEP440, "1.0", "1.0.post1", -1},
+		{schemePEP440, "1.0-1", "1.0.post1", 0},
+		{schemePEP440, "1.0.post1.dev1", "1.0.post1", -1},
+		{schemePEP440, "1!0.1", "2.0", 1},
+		{schemePEP440, "1.0+abc", "1.0+1", -1},
+		{schemePEP440, "1.0", "1.0+local", -1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.scheme+"/"+c.a+"/"+c.b, func(t *testing.T) {
+			result, err := comparators[c.scheme](c.a, c.b)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, result)
+
+			reversed, err := comparators[c.scheme](c.b, c.a)
+			require.NoError(t, err)
+			assert.Equal(t, -c.expected, reversed)
+		})
+	}
+}
+
+func TestCompareInvalidVersions(t *testing.T) {
+	cases := []struct {
+		scheme string
+		a      string
+		err    string
+	}{
+		{schemeSemver, "latest", `invalid semantic version "latest"`},
+		{schemeRPM, "x:1.0", `invalid epoch in version "x:1.0"`},
+		{schemeDebian, "abc", `invalid Debian version "abc"`},
+		{schemePEP440, "1.0-foo", `invalid PEP 440 version "1.0-foo"`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.scheme+"/"+c.a, func(t *testing.T) {
+			_, err := comparators[c.scheme](c.a, "1.0.0")
+			assert.EqualError(t, err, c.err)
+		})
+	}
+}
+
+func TestVersRange(t *testing.T) {
+	cases := []struct {
+		vers     string
+		version  string
+		expected bool
+	}{
+		{"vers:npm/*", "1.0.0", true},
+		{"vers:npm/1.0.0", "1.0.0", true},
+		{"vers:npm/1.0.0", "1.0.1", false},
+		{"vers:npm/<1.0.0", "0.9.0", true},
+		{"vers:npm/<1.0.0", "1.0.0", false},
+		{"vers:npm/<=1.0.0", "1.0.0", true},
+		{"vers:npm/>=1.0.0", "1.0.0", true},
+		{"vers:npm/>1.0.0", "1.0.0", false},
+		{"vers:npm/>=1.0.0|<2.0.0", "1.5.0", true},
+		{"vers:npm/>=1.0.0|<2.0.0", "2.0.0", false},
+		{"vers:npm/<2.0.0|>=1.0.0", "0.5.0", false},
+		{"vers:npm/>=1.0.0|<2.0.0|!=1.5.0", "1.5.0", false},
+		{"vers:npm/>=1.0.0|<2.0.0|>=3.0.0", "3.1.0", true},
+		{"vers:npm/>=1.0.0|<2.0.0|>=3.0.0", "2.5.0", false},
+		{"vers:npm/<1.0.0|>=2.0.0|<3.0.0", "0.1.0", true},
+		{"vers:npm/<1.0.0|>=2.0.0|<3.0.0", "2.1.0", true},
+		{"vers:npm/<1.0.0|>=2.0.0|<3.0.0", "1.1.0", false},
+		{"vers:npm/1.0.0|>=2.0.0", "1.0.0", true},
+		{"vers:npm / >= 1.0.0 | < 2.0.0", "1.5.0", true},
+		{"vers:rpm/<3.0.7", "3.0.1-43.el9", true},
+		{"vers:pypi/>=4.0|<4.2.8", "4.2rc1", true},
+		{"vers:deb/<2.36-9+deb12u4", "2.36-9+deb12u10", false},
+		{"vers:pypi/1.0%2Blocal", "1.0+local", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.vers+"/"+c.version, func(t *testing.T) {
+			r, err := parseVers(c.vers)
+			require.NoError(t, err)
+
+			contains, err := r.contains(c.version)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, contains)
+		})
+	}
+}
+
+func TestInvalidVersRange(t *testing.T) {
+	cases := []struct {
+		vers string
+		err  string
+	}{
+		{"npm/<1.0.0", `version range "npm/<1.0.0" does not start with vers:`},
+		{"vers:<1.0.0", `version range "vers:<1.0.0" is missing the versioning scheme`},
+		{"vers:generic/<1.0.0", `unsupported versioning scheme for "generic"`},
+		{"vers:npm/<1.0.0||>2.0.0", `version range "vers:npm/<1.0.0||>2.0.0" contains an empty constraint`},
+		{"vers:npm/<", `version range "vers:npm/<" contains a constraint without a version`},
+		{"vers:npm/<1.0.0|>latest", `invalid semantic version "latest"`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.vers, func(t *testing.T) {
+			_, err := parseVers(c.vers)
+			assert.EqualError(t, err, c.err)
+		})
+	}
+}
@@ -0,0 +1,329 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// IMPORTANT: The rego functions in this file never return an error. Instead, they return no value
+// when an error is encountered. If they did return an error, opa would exit abruptly and it would
+// not produce a report of which policy rules succeeded/failed.
+
+package sbom
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/open-policy-agent/opa/v1/types"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/conforma/cli/internal/attestation"
+	regooci "github.com/conforma/cli/internal/rego/oci"
+	regopurl "github.com/conforma/cli/internal/rego/purl"
+)
+
+const sbomPackagesName = "ec.sbom.packages"
+
+func registerSBOMPackages() {
+	pkg := types.NewObject(
+		[]*types.StaticProperty{
+			{Key: "ref", Value: types.S},
+			{Key: "name", Value: types.S},
+			{Key: "version", Value: types.S},
+			{Key: "purl", Value: types.S},
+			{Key: "parsed_purl", Value: regopurl.PURLObjectType},
+			{Key: "licenses", Value: types.NewArray(nil, types.S)},
+			{Key: "hashes", Value: types.NewObject(nil, types.NewDynamicProperty(types.S, types.S))},
+			{Key: "dependencies", Value: types.NewArray(nil, types.S)},
+		},
+		nil,
+	)
+
+	decl := rego.Function{
+		Name: sbomPackagesName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("sbom", types.NewAny(
+					types.S,
+					types.NewObject(nil, types.NewDynamicProperty(types.S, types.A)),
+				)).Description("the SPDX or CycloneDX SBOM document, or the OCI blob reference of one"),
+			),
+			types.Named("packages", types.NewArray(nil, pkg)).Description("the de-duplicated list of packages"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic. But also mark it as non-deterministic because it does rely on external
+		// entities, i.e. OCI registry. https://www.openpolicyagent.org/docs/latest/extensions/
+		Memoize:          true,
+		Nondeterministic: true,
+	}
+
+	rego.RegisterBuiltin1(&decl, sbomPackages)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      "List the packages of an SPDX or CycloneDX SBOM, de-duplicated by their PURL, with their licenses, hashes and dependencies.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
+// The packages of an SBOM are cached per component, the same way the OCI
+// builtins cache blobs and image files: SBOMs are large and unique per
+// component, so caching them across components would only accumulate dead
+// data. defaultComponentCache serves as the fallback when no ComponentCache is
+// set in the context.
+var defaultComponentCache = &ComponentCache{}
+
+// ClearCaches clears the fallback cache. This is primarily used for testing to
+// ensure tests don't interfere with each other via cached values.
+func ClearCaches() {
+	defaultComponentCache = &ComponentCache{}
+}
+
+// ComponentCache holds per-component cache of the SBOM packages, keyed by the
+// OCI blob reference or the digest of the SBOM document.
+type ComponentCache struct {
+	packagesCache  sync.Map
+	packagesFlight singleflight.Group
+}
+
+type componentCacheKey struct{}
+
+// WithComponentCache returns a new context with a fresh component-scoped cache.
+// When the context goes out of scope, the cached data becomes eligible for GC.
+func WithComponentCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, componentCacheKey{}, &ComponentCache{})
+}
+
+// componentCacheFromContext returns the ComponentCache from the context,
+// falling back to the global default if none is set.
+func componentCacheFromContext(ctx context.Context) *ComponentCache {
+	if cc, ok := ctx.Value(componentCacheKey{}).(*ComponentCache); ok && cc != nil {
+		return cc
+	}
+	return defaultComponentCache
+}
+
+func sbomPackages(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
+	logger := log.WithField("function", sbomPackagesName)
+
+	var key string
+	var document []byte
+	switch v := a.Value.(type) {
+	case ast.String:
+		key = string(v)
+		logger = logger.WithField("ref", key)
+	case ast.Object:
+		doc, err := ast.JSON(v)
+		if err != nil {
+			logger.WithField("error", err).Error("unable to convert the SBOM document")
+			return nil, nil
+		}
+
+		if document, err = json.Marshal(doc); err != nil {
+			logger.WithField("error", err).Error("unable to marshal the SBOM document")
+			return nil, nil
+		}
+		key = fmt.Sprintf("sha256:%x", sha256.Sum256(document))
+		logger = logger.WithField("digest", key)
+	default:
+		logger.Error("input is neither a string nor an object")
+		return nil, nil
+	}
+
+	cc := componentCacheFromContext(bctx.Context)
+
+	if cached, found := cc.packagesCache.Load(key); found {
+		logger.Debug("SBOM packages served from cache")
+		return cached.(*ast.Term), nil
+	}
+
+	result, _, _ := cc.packagesFlight.Do(key, func() (any, error) {
+		if cached, found := cc.packagesCache.Load(key); found {
+			logger.Debug("SBOM packages served from cache (after singleflight)")
+			return cached, nil
+		}
+
+		if document == nil {
+			blob := regooci.Blob(bctx, key)
+			if blob == nil {
+				logger.Error("unable to fetch the SBOM blob")
+				return nil, nil
+			}
+
+			s, ok := blob.Value.(ast.String)
+			if !ok {
+				logger.Error("SBOM blob is not a string")
+				return nil, nil
+			}
+			document = []byte(s)
+		}
+
+		sbom, err := attestation.ParseSBOM(document)
+		if err != nil {
+			logger.WithField("error", err).Error("unable to parse the SBOM")
+			return nil, nil
+		}
+
+		packages := deduplicate(sbom.Packages)
+		logger.WithFields(log.Fields{
+			"format":   sbom.Format,
+			"packages": len(packages),
+		}).Debug("Successfully listed SBOM packages")
+
+		term := packagesTerm(packages)
+		cc.packagesCache.Store(key, term)
+		return term, nil
+	})
+
+	if result == nil {
+		return nil, nil
+	}
+	return result.(*ast.Term), nil
+}
+
+// sbomPackage is a package of the SBOM merged from all SBOM packages sharing
+// the same ref.
+type sbomPackage struct {
+	ref          string
+	name         string
+	version      string
+	purl         string
+	licenses     []string
+	hashes       map[string]string
+	dependencies []string
+}
+
+// packageRef identifies the package: the PURL if there is one, otherwise the
+// name and the version.
+func packageRef(p attestation.SBOMPackage) string {
+	if p.PURL != "" {
+		return p.PURL
+	}
+
+	if p.Version != "" {
+		return p.Name + "@" + p.Version
+	}
+
+	return p.Name
+}
+
+// deduplicate merges the SBOM packages with the same ref, keeping the order in
+// which they're first listed. The dependencies, given as package IDs within
+// the SBOM, are translated to refs.
+func deduplicate(packages []attestation.SBOMPackage) []*sbomPackage {
+	refs := make(map[string]string, len(packages))
+	for _, p := range packages {
+		if p.ID != "" {
+			refs[p.ID] = packageRef(p)
+		}
+	}
+
+	byRef := make(map[string]*sbomPackage, len(packages))
+	result := make([]*sbomPackage, 0, len(packages))
+	for _, p := range packages {
+		ref := packageRef(p)
+		merged, ok := byRef[ref]
+		if !ok {
+			merged = &sbomPackage{
+				ref:     ref,
+				name:    p.Name,
+				version: p.Version,
+				purl:    p.PURL,
+				hashes:  map[string]string{},
+			}
+			byRef[ref] = merged
+			result = append(result, merged)
+		}
+
+		for _, l := range p.Licenses {
+			if !slices.Contains(merged.licenses, l) {
+				merged.licenses = append(merged.licenses, l)
+			}
+		}
+
+		for alg, h := range p.Hashes {
+			if _, ok := merged.hashes[alg]; !ok {
+				merged.hashes[alg] = h
+			}
+		}
+
+		for _, id := range p.Dependencies {
+			dependency, ok := refs[id]
+			if ok && dependency != ref && !slices.Contains(merged.dependencies, dependency) {
+				merged.dependencies = append(merged.dependencies, dependency)
+			}
+		}
+	}
+
+	return result
+}
+
+func packagesTerm(packages []*sbomPackage) *ast.Term {
+	terms := make([]*ast.Term, 0, len(packages))
+	for _, p := range packages {
+		licenses := make([]*ast.Term, 0, len(p.licenses))
+		for _, l := range p.licenses {
+			licenses = append(licenses, ast.StringTerm(l))
+		}
+
+		hashes := ast.NewObject()
+		for alg, h := range p.hashes {
+			hashes.Insert(ast.StringTerm(alg), ast.StringTerm(h))
+		}
+
+		dependencies := make([]*ast.Term, 0, len(p.dependencies))
+		for _, d := range p.dependencies {
+			dependencies = append(dependencies, ast.StringTerm(d))
+		}
+
+		items := [][2]*ast.Term{
+			ast.Item(ast.StringTerm("ref"), ast.StringTerm(p.ref)),
+			ast.Item(ast.StringTerm("name"), ast.StringTerm(p.name)),
+			ast.Item(ast.StringTerm("version"), ast.StringTerm(p.version)),
+			ast.Item(ast.StringTerm("purl"), ast.StringTerm(p.purl)),
+			ast.Item(ast.StringTerm("licenses"), ast.ArrayTerm(licenses...)),
+			ast.Item(ast.StringTerm("hashes"), ast.NewTerm(hashes)),
+			ast.Item(ast.StringTerm("dependencies"), ast.ArrayTerm(dependencies...)),
+		}
+
+		if p.purl != "" {
+			if parsed, err := regopurl.ParsePURL(p.purl); err == nil {
+				items = append(items, ast.Item(ast.StringTerm("parsed_purl"), parsed))
+			} else {
+				log.WithFields(log.Fields{
+					"function": sbomPackagesName,
+					"purl":     p.purl,
+					"error":    err,
+				}).Debug("unable to parse the package PURL")
+			}
+		}
+
+		terms = append(terms, ast.ObjectTerm(items...))
+	}
+
+	return ast.ArrayTerm(terms...)
+}
+
+func init() {
+	registerSBOMPackages()
+}
@@ -0,0 +1,290 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+//go:build unit
+
+package sbom
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	regooci "github.com/conforma/cli/internal/rego/oci"
+	"github.com/conforma/cli/internal/utils/oci"
+	"github.com/conforma/cli/internal/utils/oci/fake"
+)
+
+const spdxDocument = `{
+	"spdxVersion": "SPDX-2.3",
+	"dataLicense": "CC0-1.0",
+	"SPDXID": "SPDXRef-DOCUMENT",
+	"name": "registry.local/image",
+	"packages": [
+		{
+			"SPDXID": "SPDXRef-Package-openssl",
+			"name": "openssl",
+			"versionInfo": "3.0.7",
+			"licenseConcluded": "Apache-2.0",
+			"checksums": [{"algorithm": "SHA256", "checksumValue": "abc"}],
+			"externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:rpm/redhat/openssl@3.0.7?arch=x86_64"}]
+		},
+		{
+			"SPDXID": "SPDXRef-Package-zlib",
+			"name": "zlib",
+			"versionInfo": "1.3"
+		},
+		{
+			"SPDXID": "SPDXRef-Package-openssl-again",
+			"name": "openssl",
+			"versionInfo": "3.0.7",
+			"licenseDeclared": "OpenSSL",
+			"checksums": [{"algorithm": "SHA1", "checksumValue": "def"}],
+			"externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:rpm/redhat/openssl@3.0.7?arch=x86_64"}]
+		}
+	],
+	"relationships": [
+		{"spdxElementId": "SPDXRef-Package-openssl", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-Package-zlib"},
+		{"spdxElementId": "SPDXRef-Package-openssl-again", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-Package-zlib"},
+		{"spdxElementId": "SPDXRef-Package-openssl-again", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-Package-openssl"}
+	]
+}`
+
+const expectedSPDXPackages = `[
+	{
+		"ref": "pkg:rpm/redhat/openssl@3.0.7?arch=x86_64",
+		"name": "openssl",
+		"version": "3.0.7",
+		"purl": "pkg:rpm/redhat/openssl@3.0.7?arch=x86_64",
+		"parsed_purl": {
+			"type": "rpm",
+			"namespace": "redhat",
+			"name": "openssl",
+			"version": "3.0.7",
+			"qualifiers": [{"key": "arch", "value": "x86_64"}],
+			"subpath": ""
+		},
+		"licenses": ["Apache-2.0", "OpenSSL"],
+		"hashes": {"sha256": "abc", "sha1": "def"},
+		"dependencies": ["zlib@1.3"]
+	},
+	{
+		"ref": "zlib@1.3",
+		"name": "zlib",
+		"version": "1.3",
+		"purl": "",
+		"licenses": [],
+		"hashes": {},
+		"dependencies": []
+	}
+]`
+
+const cycloneDXDocument = `{
+	"bomFormat": "CycloneDX",
+	"specVersion": "1.5",
+	"version": 1,
+	"components": [
+		{
+			"bom-ref": "app",
+			"type": "application",
+			"name": "app",
+			"components": [
+				{
+					"bom-ref": "lib",
+					"type": "library",
+					"name": "lib",
+					"version": "1.0.0",
+					"purl": "pkg:golang/example.com/lib@1.0.0",
+					"licenses": [{"license": {"id": "MIT"}}],
+					"hashes": [{"alg": "SHA-256", "content": "abc"}]
+				}
+			]
+		},
+		{
+			"type": "library",
+			"name": "invalid-purl",
+			"purl": "not-a-purl"
+		}
+	],
+	"dependencies": [
+		{"ref": "app", "dependsOn": ["lib"]}
+	]
+}`
+
+const expectedCycloneDXPackages = `[
+	{
+		"ref": "app",
+		"name": "app",
+		"version": "",
+		"purl": "",
+		"licenses": [],
+		"hashes": {},
+		"dependencies": ["pkg:golang/example.com/lib@1.0.0"]
+	},
+	{
+		"ref": "pkg:golang/example.com/lib@1.0.0",
+		"name": "lib",
+		"version": "1.0.0",
+		"purl": "pkg:golang/example.com/lib@1.0.0",
+		"parsed_purl": {
+			"type": "golang",
+			"namespace": "example.com",
+			"name": "lib",
+			"version": "1.0.0",
+			"qualifiers": [],
+			"subpath": ""
+		},
+		"licenses": ["MIT"],
+		"hashes": {"sha256": "abc"},
+		"dependencies": []
+	},
+	{
+		"ref": "not-a-purl",
+		"name": "invalid-purl",
+		"version": "",
+		"purl": "not-a-purl",
+		"licenses": [],
+		"hashes": {},
+		"dependencies": []
+	}
+]`
+
+func requireJSONEq(t *testing.T, expected string, actual *ast.Term) {
+	t.Helper()
+	require.NotNil(t, actual)
+
+	value, err := ast.JSON(actual.Value)
+	require.NoError(t, err)
+
+	j, err := json.Marshal(value)
+	require.NoError(t, err)
+
+	require.JSONEq(t, expected, string(j))
+}
+
+func blobRef(data string) string {
+	return fmt.Sprintf("registry.local/sbom@sha256:%x", sha256.Sum256([]byte(data)))
+}
+
+func TestSBOMPackages(t *testing.T) {
+	cases := []struct {
+		name     string
+		sbom     *ast.Term
+		blob     string
+		expected string
+	}{
+		{
+			name:     "SPDX document",
+			sbom:     ast.MustParseTerm(spdxDocument),
+			expected: expectedSPDXPackages,
+		},
+		{
+			name:     "CycloneDX document",
+			sbom:     ast.MustParseTerm(cycloneDXDocument),
+			expected: expectedCycloneDXPackages,
+		},
+		{
+			name:     "SPDX blob",
+			sbom:     ast.StringTerm(blobRef(spdxDocument)),
+			blob:     spdxDocument,
+			expected: expectedSPDXPackages,
+		},
+		{
+			name:     "CycloneDX blob",
+			sbom:     ast.StringTerm(blobRef(cycloneDXDocument)),
+			blob:     cycloneDXDocument,
+			expected: expectedCycloneDXPackages,
+		},
+		{
+			name: "unexpected input type",
+			sbom: ast.IntNumberTerm(42),
+		},
+		{
+			name: "not an SBOM",
+			sbom: ast.MustParseTerm(`{"_type": "https://in-toto.io/Statement/v1"}`),
+		},
+		{
+			name: "malformed SBOM",
+			sbom: ast.MustParseTerm(`{"spdxVersion": "SPDX-2.3"}`),
+		},
+		{
+			name: "unsupported SBOM",
+			sbom: ast.MustParseTerm(`{"bomFormat": "CycloneDX", "specVersion": "1.4"}`),
+		},
+		{
+			name: "blob not JSON",
+			sbom: ast.StringTerm(blobRef("spam")),
+			blob: "spam",
+		},
+		{
+			name: "blob digest mismatch",
+			sbom: ast.StringTerm(blobRef(spdxDocument)),
+			blob: cycloneDXDocument,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := fake.FakeClient{}
+			client.On("Layer", mock.Anything, mock.Anything).Return(static.NewLayer([]byte(c.blob), types.OCIUncompressedLayer), nil)
+			ctx := WithComponentCache(regooci.WithComponentCache(oci.WithClient(context.Background(), &client)))
+			bctx := rego.BuiltinContext{Context: ctx}
+
+			packages, err := sbomPackages(bctx, c.sbom)
+			require.NoError(t, err)
+			if c.expected == "" {
+				require.Nil(t, packages)
+			} else {
+				requireJSONEq(t, c.expected, packages)
+			}
+		})
+	}
+}
+
+func TestSBOMPackagesCache(t *testing.T) {
+	t.Cleanup(ClearCaches)
+	t.Cleanup(regooci.ClearCaches)
+
+	ref := ast.StringTerm(blobRef(cycloneDXDocument))
+
+	client := fake.FakeClient{}
+	client.On("Layer", mock.Anything, mock.Anything).Return(static.NewLayer([]byte(cycloneDXDocument), types.OCIUncompressedLayer), nil).Once()
+	ctx := oci.WithClient(context.Background(), &client)
+
+	componentCtx := WithComponentCache(ctx)
+	for range 2 {
+		packages, err := sbomPackages(rego.BuiltinContext{Context: componentCtx}, ref)
+		require.NoError(t, err)
+		requireJSONEq(t, expectedCycloneDXPackages, packages)
+	}
+	client.AssertNumberOfCalls(t, "Layer", 1)
+
+	// a different component does not see the cached packages, the blob is
+	// fetched again
+	client.On("Layer", mock.Anything, mock.Anything).Return(static.NewLayer([]byte(cycloneDXDocument), types.OCIUncompressedLayer), nil).Once()
+	packages, err := sbomPackages(rego.BuiltinContext{Context: regooci.WithComponentCache(WithComponentCache(ctx))}, ref)
+	require.NoError(t, err)
+	requireJSONEq(t, expectedCycloneDXPackages, packages)
+	client.AssertNumberOfCalls(t, "Layer", 2)
+}
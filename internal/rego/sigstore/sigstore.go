@@ -21,10 +21,17 @@
 package sigstore
 
 import (
+	"bytes"
 	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/open-policy-agent/opa/v1/ast"
@@ -33,6 +40,11 @@ import (
 	"github.com/open-policy-agent/opa/v1/types"
 	"github.com/sigstore/cosign/v3/pkg/cosign"
 	"github.com/sigstore/cosign/v3/pkg/oci"
+	"github.com/sigstore/cosign/v3/pkg/oci/static"
+	sgbundle "github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/tuf"
 	log "github.com/sirupsen/logrus"
 
@@ -45,17 +57,27 @@ import (
 const (
 	sigstoreVerifyImageName       = "ec.sigstore.verify_image"
 	sigstoreVerifyAttestationName = "ec.sigstore.verify_attestation"
+	sigstoreVerifyBlobName        = "ec.sigstore.verify_blob"
 )
 
 const (
-	certificateIdentityAttribute         = "certificate_identity"
-	certificateIdentityRegExpAttribute   = "certificate_identity_regexp"
-	certificateOIDCIssuerAttribute       = "certificate_oidc_issuer"
-	certificateOIDCIssuerRegExpAttribute = "certificate_oidc_issuer_regexp"
-	ignoreRekorAttribute                 = "ignore_rekor"
-	publicKeyAttribute                   = "public_key"
-	rekorURLAttribute                    = "rekor_url"
-	rekorPublicKeyAttribute              = "rekor_public_key"
+	certificateIdentityAttribute                 = "certificate_identity"
+	certificateIdentityRegExpAttribute           = "certificate_identity_regexp"
+	certificateOIDCIssuerAttribute               = "certificate_oidc_issuer"
+	certificateOIDCIssuerRegExpAttribute         = "certificate_oidc_issuer_regexp"
+	certificateGithubWorkflowNameAttribute       = "certificate_github_workflow_name"
+	certificateGithubWorkflowRefAttribute        = "certificate_github_workflow_ref"
+	certificateGithubWorkflowRepositoryAttribute = "certificate_github_workflow_repository"
+	certificateGithubWorkflowSHAAttribute        = "certificate_github_workflow_sha"
+	certificateGithubWorkflowTriggerAttribute    = "certificate_github_workflow_trigger"
+	ctLogPublicKeyAttribute                      = "ctlog_public_key"
+	fulcioRootsAttribute                         = "fulcio_roots"
+	fulcioIntermediatesAttribute                 = "fulcio_intermediates"
+	ignoreRekorAttribute                         = "ignore_rekor"
+	publicKeyAttribute                           = "public_key"
+	rekorURLAttribute                            = "rekor_url"
+	rekorPublicKeyAttribute                      = "rekor_public_key"
+	tsaCertificateChainAttribute                 = "tsa_certificate_chain"
 )
 
 var ociImageReferenceParameter = types.Named("ref", types.S).Description("OCI image reference")
@@ -80,9 +102,18 @@ var sigstoreOptsParameter = types.Named("opts",
 			certificateIdentityRegExpAttribute,
 			certificateOIDCIssuerAttribute,
 			certificateOIDCIssuerRegExpAttribute,
+			certificateGithubWorkflowNameAttribute,
+			certificateGithubWorkflowRefAttribute,
+			certificateGithubWorkflowRepositoryAttribute,
+			certificateGithubWorkflowSHAAttribute,
+			certificateGithubWorkflowTriggerAttribute,
+			ctLogPublicKeyAttribute,
+			fulcioRootsAttribute,
+			fulcioIntermediatesAttribute,
 			publicKeyAttribute,
 			rekorURLAttribute,
 			rekorPublicKeyAttribute,
+			tsaCertificateChainAttribute,
 		}, "`, `"),
 	))
 
@@ -239,6 +270,160 @@ func sigstoreVerifyAttestation(bctx rego.BuiltinContext, refTerm *ast.Term, opts
 	return attestationResult(attestations, useBundles, nil)
 }
 
+func registerSigstoreVerifyBlob() {
+	result := types.Named(
+		"result",
+		types.NewObject([]*types.StaticProperty{
+			{Key: "success", Value: types.Named("success", types.B).Description("true when verification is successful")},
+			{Key: "errors", Value: types.Named("errors", types.NewArray([]types.Type{types.S}, nil)).Description("verification errors")},
+			{Key: "signatures", Value: types.Named("signatures", types.NewArray([]types.Type{signatureType}, nil)).Description("matching signatures")},
+		}, nil),
+	).Description("the result of the verification request")
+
+	decl := rego.Function{
+		Name:        sigstoreVerifyBlobName,
+		Description: "Use sigstore to verify the detached signature of a blob.",
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("blob", types.S).Description("the signed content"),
+				types.Named("signature", types.S).Description("the base64 encoded signature, or the cosign or Sigstore bundle in JSON"),
+				sigstoreOptsParameter,
+			),
+			result,
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic. But also mark it as non-deterministic because it does rely on external
+		// entities, i.e. Rekor. https://www.openpolicyagent.org/docs/latest/extensions/
+		Memoize:          true,
+		Nondeterministic: true,
+	}
+	rego.RegisterBuiltin3(&decl, sigstoreVerifyBlob)
+}
+
+func sigstoreVerifyBlob(bctx rego.BuiltinContext, blobTerm *ast.Term, signatureTerm *ast.Term, optsTerm *ast.Term) (*ast.Term, error) {
+	logger := log.WithField("function", sigstoreVerifyBlobName)
+	ctx := bctx.Context
+
+	blob, err := builtins.StringOperand(blobTerm.Value, 0)
+	if err != nil {
+		logger.WithField("error", err).Debug("failed to get blob parameter")
+		return signatureFailedResult(fmt.Errorf("blob parameter: %w", err))
+	}
+
+	sig, err := builtins.StringOperand(signatureTerm.Value, 1)
+	if err != nil {
+		logger.WithField("error", err).Debug("failed to get signature parameter")
+		return signatureFailedResult(fmt.Errorf("signature parameter: %w", err))
+	}
+
+	checkOpts, err := parseCheckOpts(ctx, optsTerm)
+	if err != nil {
+		logger.WithField("error", err).Debug("failed to parse check opts")
+		return signatureFailedResult(fmt.Errorf("opts parameter: %w", err))
+	}
+
+	logger.Debug("verifying blob signature")
+	signature, err := verifyBlob(ctx, []byte(blob), strings.TrimSpace(string(sig)), checkOpts)
+	if err != nil {
+		logger.WithField("error", err).Debug("failed to verify blob signature")
+		return signatureFailedResult(fmt.Errorf("verify blob signature: %w", err))
+	}
+
+	logger.Debug("blob signature verification complete")
+	return signatureResult([]oci.Signature{signature}, nil)
+}
+
+// verifyBlob verifies the blob with the signature given either as the base64
+// encoded signature, the cosign bundle, as created by `cosign sign-blob
+// --bundle`, or the Sigstore bundle.
+func verifyBlob(ctx context.Context, blob []byte, sig string, checkOpts *cosign.CheckOpts) (oci.Signature, error) {
+	if !strings.HasPrefix(sig, "{") {
+		return verifyBlobSignature(ctx, blob, cosign.LocalSignedPayload{Base64Signature: sig}, checkOpts)
+	}
+
+	var bundle struct {
+		MediaType string `json:"mediaType"`
+	}
+	if err := json.Unmarshal([]byte(sig), &bundle); err != nil {
+		return nil, fmt.Errorf("malformed bundle: %w", err)
+	}
+
+	if strings.HasPrefix(bundle.MediaType, "application/vnd.dev.sigstore.bundle") {
+		return verifyBlobSigstoreBundle(ctx, blob, []byte(sig), checkOpts)
+	}
+
+	var payload cosign.LocalSignedPayload
+	if err := json.Unmarshal([]byte(sig), &payload); err != nil {
+		return nil, fmt.Errorf("malformed bundle: %w", err)
+	}
+
+	return verifyBlobSignature(ctx, blob, payload, checkOpts)
+}
+
+func verifyBlobSignature(ctx context.Context, blob []byte, payload cosign.LocalSignedPayload, checkOpts *cosign.CheckOpts) (oci.Signature, error) {
+	if payload.Base64Signature == "" {
+		return nil, errors.New("no signature provided")
+	}
+
+	var opts []static.Option
+	if payload.Cert != "" {
+		cert, err := base64.StdEncoding.DecodeString(payload.Cert)
+		if err != nil {
+			return nil, fmt.Errorf("malformed certificate: %w", err)
+		}
+		opts = append(opts, static.WithCertChain(cert, nil))
+	}
+
+	if payload.Bundle != nil {
+		opts = append(opts, static.WithBundle(payload.Bundle))
+	}
+
+	signature, err := static.NewSignature(blob, payload.Base64Signature, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := cosign.VerifyBlobSignature(ctx, signature, checkOpts); err != nil {
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+func verifyBlobSigstoreBundle(ctx context.Context, blob []byte, content []byte, checkOpts *cosign.CheckOpts) (oci.Signature, error) {
+	var b sgbundle.Bundle
+	if err := b.UnmarshalJSON(content); err != nil {
+		return nil, fmt.Errorf("malformed Sigstore bundle: %w", err)
+	}
+
+	if _, err := cosign.VerifyNewBundle(ctx, checkOpts, verify.WithArtifact(bytes.NewReader(blob)), &b); err != nil {
+		return nil, err
+	}
+
+	// the verified signature, with the certificate if present, in the same
+	// form as the signatures of the other verification builtins
+	sigContent, err := b.SignatureContent()
+	if err != nil {
+		return nil, err
+	}
+
+	verificationContent, err := b.VerificationContent()
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []static.Option
+	if cert := verificationContent.Certificate(); cert != nil {
+		certPEM, err := cryptoutils.MarshalCertificateToPEM(cert)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, static.WithCertChain(certPEM, nil))
+	}
+
+	return static.NewSignature(blob, base64.StdEncoding.EncodeToString(sigContent.Signature()), opts...)
+}
+
 func parseCheckOpts(ctx context.Context, optsTerm *ast.Term) (*cosign.CheckOpts, error) {
 	if _, err := builtins.ObjectOperand(optsTerm.Value, 1); err != nil {
 		return nil, fmt.Errorf("opts parameter: %s", err)
@@ -278,18 +463,237 @@ func parseCheckOpts(ctx context.Context, optsTerm *ast.Term) (*cosign.CheckOpts,
 		checkOpts.RekorPubKeys = &rekorPublicKeys
 	}
 
+	if opts.hasCustomTrust() {
+		if err := applyCustomTrust(checkOpts, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	checkOpts.CertGithubWorkflowName = opts.certificateGithubWorkflowName
+	checkOpts.CertGithubWorkflowRef = opts.certificateGithubWorkflowRef
+	checkOpts.CertGithubWorkflowRepository = opts.certificateGithubWorkflowRepository
+	checkOpts.CertGithubWorkflowSha = opts.certificateGithubWorkflowSHA
+	checkOpts.CertGithubWorkflowTrigger = opts.certificateGithubWorkflowTrigger
+
 	return checkOpts, nil
 }
 
+// applyCustomTrust replaces the parts of the trusted material for which Fulcio
+// certificates, a CT log or Rekor public key, or a TSA certificate chain are
+// provided. The remaining parts are kept from the Sigstore TUF trusted root or,
+// when it is not available, from the individually fetched public keys.
+func applyCustomTrust(checkOpts *cosign.CheckOpts, opts options) error {
+	var defaults root.TrustedMaterial = &root.BaseTrustedMaterial{}
+	if checkOpts.TrustedMaterial != nil {
+		defaults = checkOpts.TrustedMaterial
+	}
+
+	authorities := defaults.FulcioCertificateAuthorities()
+	if opts.fulcioRoots != "" || opts.fulcioIntermediates != "" {
+		var err error
+		if authorities, err = certificateAuthorities(authorities, opts); err != nil {
+			return err
+		}
+	}
+
+	if opts.publicKey == "" && len(authorities) == 0 {
+		return fmt.Errorf("%s must be provided when the Sigstore trusted root is not available", fulcioRootsAttribute)
+	}
+
+	ctLogs := defaults.CTLogs()
+	if opts.ctLogPublicKey != "" {
+		ctLogPublicKeys := cosign.NewTrustedTransparencyLogPubKeys()
+		if err := ctLogPublicKeys.AddTransparencyLogPubKey([]byte(opts.ctLogPublicKey), tuf.Active); err != nil {
+			return fmt.Errorf("adding CT log public key: %w", err)
+		}
+		checkOpts.CTLogPubKeys = &ctLogPublicKeys
+	}
+	if checkOpts.CTLogPubKeys != nil {
+		ctLogs = transparencyLogs(checkOpts.CTLogPubKeys)
+	}
+
+	// RekorPubKeys holds either the rekor_public_key option or the keys
+	// fetched when the Sigstore TUF trusted root is not available
+	rekorLogs := defaults.RekorLogs()
+	if checkOpts.RekorPubKeys != nil {
+		rekorLogs = transparencyLogs(checkOpts.RekorPubKeys)
+	}
+
+	tsas := defaults.TimestampingAuthorities()
+	if opts.tsaCertificateChain != "" {
+		var err error
+		if tsas, err = timestampingAuthorities(opts.tsaCertificateChain); err != nil {
+			return fmt.Errorf("%s: %w", tsaCertificateChainAttribute, err)
+		}
+		checkOpts.UseSignedTimestamps = true
+	}
+
+	trustedRoot, err := root.NewTrustedRoot(root.TrustedRootMediaType01, authorities, ctLogs, tsas, rekorLogs)
+	if err != nil {
+		return err
+	}
+
+	// the trusted material is exclusive with the individual certificates and
+	// public keys
+	checkOpts.TrustedMaterial = trustedRoot
+	checkOpts.RootCerts = nil
+	checkOpts.IntermediateCerts = nil
+	checkOpts.CTLogPubKeys = nil
+	checkOpts.RekorPubKeys = nil
+
+	return nil
+}
+
+// certificateAuthorities returns the Fulcio certificate authorities for the
+// provided root and intermediate certificates. When only intermediates are
+// provided they replace the intermediates of the given certificate
+// authorities.
+func certificateAuthorities(authorities []root.CertificateAuthority, opts options) ([]root.CertificateAuthority, error) {
+	var intermediates []*x509.Certificate
+	if opts.fulcioIntermediates != "" {
+		var err error
+		if intermediates, err = certificates(opts.fulcioIntermediates); err != nil {
+			return nil, fmt.Errorf("%s: %w", fulcioIntermediatesAttribute, err)
+		}
+	}
+
+	if opts.fulcioRoots == "" {
+		replaced := make([]root.CertificateAuthority, 0, len(authorities))
+		for _, a := range authorities {
+			if ca, ok := a.(*root.FulcioCertificateAuthority); ok {
+				a = &root.FulcioCertificateAuthority{
+					Root:                ca.Root,
+					Intermediates:       intermediates,
+					ValidityPeriodStart: ca.ValidityPeriodStart,
+					ValidityPeriodEnd:   ca.ValidityPeriodEnd,
+					URI:                 ca.URI,
+				}
+			}
+			replaced = append(replaced, a)
+		}
+
+		return replaced, nil
+	}
+
+	roots, err := certificates(opts.fulcioRoots)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fulcioRootsAttribute, err)
+	}
+
+	custom := make([]root.CertificateAuthority, 0, len(roots))
+	for _, r := range roots {
+		custom = append(custom, &root.FulcioCertificateAuthority{
+			Root:          r,
+			Intermediates: intermediates,
+		})
+	}
+
+	return custom, nil
+}
+
+func certificates(pem string) ([]*x509.Certificate, error) {
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(pem))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+
+	return certs, nil
+}
+
+// transparencyLogs converts the public keys of transparency logs, indexed by
+// the hex encoded log ID, to the form used by the trusted material. The keys
+// carry no validity period, so they are trusted at any time.
+func transparencyLogs(keys *cosign.TrustedTransparencyLogPubKeys) map[string]*root.TransparencyLog {
+	logs := make(map[string]*root.TransparencyLog, len(keys.Keys))
+	for id, key := range keys.Keys {
+		logID, err := hex.DecodeString(id)
+		if err != nil {
+			log.Debugf("Skipping transparency log with malformed log ID %q: %v", id, err)
+			continue
+		}
+
+		logs[id] = &root.TransparencyLog{
+			ID: logID,
+			// Rekor log entries are rejected if the start is not set
+			ValidityPeriodStart: time.Unix(0, 0),
+			HashFunc:            crypto.SHA256,
+			PublicKey:           key.PubKey,
+			SignatureHashFunc:   crypto.SHA256,
+		}
+	}
+
+	return logs
+}
+
+// timestampingAuthorities returns the timestamping authorities used to verify
+// RFC3161 timestamps from the PEM encoded chain: the TSA certificate, any
+// intermediates and the root certificate.
+func timestampingAuthorities(pem string) ([]root.TimestampingAuthority, error) {
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(pem))
+	if err != nil {
+		return nil, err
+	}
+
+	var leaves, intermediates, roots []*x509.Certificate
+	for _, c := range certs {
+		switch {
+		case !c.IsCA:
+			leaves = append(leaves, c)
+		case bytes.Equal(c.RawSubject, c.RawIssuer):
+			// root certificates are self-signed
+			roots = append(roots, c)
+		default:
+			intermediates = append(intermediates, c)
+		}
+	}
+
+	if len(leaves) != 1 {
+		return nil, fmt.Errorf("expected exactly one TSA certificate, found %d", len(leaves))
+	}
+
+	if len(roots) == 0 {
+		return nil, errors.New("no root certificate found")
+	}
+
+	authorities := make([]root.TimestampingAuthority, 0, len(roots))
+	for _, r := range roots {
+		authorities = append(authorities, &root.SigstoreTimestampingAuthority{
+			Root:          r,
+			Intermediates: intermediates,
+			Leaf:          leaves[0],
+		})
+	}
+
+	return authorities, nil
+}
+
 type options struct {
-	certificateIdentity         string
-	certificateIdentityRegExp   string
-	certificateOIDCIssuer       string
-	certificateOIDCIssuerRegExp string
-	ignoreRekor                 bool
-	publicKey                   string
-	rekorURL                    string
-	rekorPublicKey              string
+	certificateIdentity                 string
+	certificateIdentityRegExp           string
+	certificateOIDCIssuer               string
+	certificateOIDCIssuerRegExp         string
+	certificateGithubWorkflowName       string
+	certificateGithubWorkflowRef        string
+	certificateGithubWorkflowRepository string
+	certificateGithubWorkflowSHA        string
+	certificateGithubWorkflowTrigger    string
+	ctLogPublicKey                      string
+	fulcioRoots                         string
+	fulcioIntermediates                 string
+	ignoreRekor                         bool
+	publicKey                           string
+	rekorURL                            string
+	rekorPublicKey                      string
+	tsaCertificateChain                 string
+}
+
+// hasCustomTrust returns true if any of the trusted material is provided
+func (o options) hasCustomTrust() bool {
+	return o.fulcioRoots != "" || o.fulcioIntermediates != "" || o.ctLogPublicKey != "" || o.tsaCertificateChain != ""
 }
 
 func (o options) toTerm() *ast.Term {
@@ -298,10 +702,19 @@ func (o options) toTerm() *ast.Term {
 		ast.Item(ast.StringTerm(certificateIdentityRegExpAttribute), ast.StringTerm(o.certificateIdentityRegExp)),
 		ast.Item(ast.StringTerm(certificateOIDCIssuerAttribute), ast.StringTerm(o.certificateOIDCIssuer)),
 		ast.Item(ast.StringTerm(certificateOIDCIssuerRegExpAttribute), ast.StringTerm(o.certificateOIDCIssuerRegExp)),
+		ast.Item(ast.StringTerm(certificateGithubWorkflowNameAttribute), ast.StringTerm(o.certificateGithubWorkflowName)),
+		ast.Item(ast.StringTerm(certificateGithubWorkflowRefAttribute), ast.StringTerm(o.certificateGithubWorkflowRef)),
+		ast.Item(ast.StringTerm(certificateGithubWorkflowRepositoryAttribute), ast.StringTerm(o.certificateGithubWorkflowRepository)),
+		ast.Item(ast.StringTerm(certificateGithubWorkflowSHAAttribute), ast.StringTerm(o.certificateGithubWorkflowSHA)),
+		ast.Item(ast.StringTerm(certificateGithubWorkflowTriggerAttribute), ast.StringTerm(o.certificateGithubWorkflowTrigger)),
+		ast.Item(ast.StringTerm(ctLogPublicKeyAttribute), ast.StringTerm(o.ctLogPublicKey)),
+		ast.Item(ast.StringTerm(fulcioRootsAttribute), ast.StringTerm(o.fulcioRoots)),
+		ast.Item(ast.StringTerm(fulcioIntermediatesAttribute), ast.StringTerm(o.fulcioIntermediates)),
 		ast.Item(ast.StringTerm(ignoreRekorAttribute), ast.BooleanTerm(o.ignoreRekor)),
 		ast.Item(ast.StringTerm(publicKeyAttribute), ast.StringTerm(o.publicKey)),
 		ast.Item(ast.StringTerm(rekorURLAttribute), ast.StringTerm(o.rekorURL)),
 		ast.Item(ast.StringTerm(rekorPublicKeyAttribute), ast.StringTerm(o.rekorPublicKey)),
+		ast.Item(ast.StringTerm(tsaCertificateChainAttribute), ast.StringTerm(o.tsaCertificateChain)),
 	)
 }
 
@@ -312,9 +725,18 @@ func optionsFromTerm(term *ast.Term) options {
 	opts.certificateIdentityRegExp = stringPropertyFromTerm(term, certificateIdentityRegExpAttribute)
 	opts.certificateOIDCIssuer = stringPropertyFromTerm(term, certificateOIDCIssuerAttribute)
 	opts.certificateOIDCIssuerRegExp = stringPropertyFromTerm(term, certificateOIDCIssuerRegExpAttribute)
+	opts.certificateGithubWorkflowName = stringPropertyFromTerm(term, certificateGithubWorkflowNameAttribute)
+	opts.certificateGithubWorkflowRef = stringPropertyFromTerm(term, certificateGithubWorkflowRefAttribute)
+	opts.certificateGithubWorkflowRepository = stringPropertyFromTerm(term, certificateGithubWorkflowRepositoryAttribute)
+	opts.certificateGithubWorkflowSHA = stringPropertyFromTerm(term, certificateGithubWorkflowSHAAttribute)
+	opts.certificateGithubWorkflowTrigger = stringPropertyFromTerm(term, certificateGithubWorkflowTriggerAttribute)
+	opts.ctLogPublicKey = stringPropertyFromTerm(term, ctLogPublicKeyAttribute)
+	opts.fulcioRoots = stringPropertyFromTerm(term, fulcioRootsAttribute)
+	opts.fulcioIntermediates = stringPropertyFromTerm(term, fulcioIntermediatesAttribute)
 	opts.publicKey = stringPropertyFromTerm(term, publicKeyAttribute)
 	opts.rekorPublicKey = stringPropertyFromTerm(term, rekorPublicKeyAttribute)
 	opts.rekorURL = stringPropertyFromTerm(term, rekorURLAttribute)
+	opts.tsaCertificateChain = stringPropertyFromTerm(term, tsaCertificateChainAttribute)
 
 	// nil check not required because this attribute is a static property. It will always have a value.
 	if v, ok := term.Get(ast.StringTerm(ignoreRekorAttribute)).Value.(ast.Boolean); ok {
@@ -443,4 +865,5 @@ func attestationResult(attestations []oci.Signature, useBundles bool, err error)
 func init() {
 	registerSigstoreVerifyImage()
 	registerSigstoreVerifyAttestation()
+	registerSigstoreVerifyBlob()
 }
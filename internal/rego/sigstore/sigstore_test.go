@@ -20,12 +20,25 @@ package sigstore
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"testing"
+	"time"
 
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+	ctx509 "github.com/google/certificate-transparency-go/x509"
+	ctx509util "github.com/google/certificate-transparency-go/x509util"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/open-policy-agent/opa/v1/ast"
@@ -34,6 +47,8 @@ import (
 	"github.com/sigstore/cosign/v3/pkg/oci"
 	"github.com/sigstore/cosign/v3/pkg/oci/static"
 	cosignTypes "github.com/sigstore/cosign/v3/pkg/types"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
@@ -137,6 +152,106 @@ func TestSigstoreVerifyImage(t *testing.T) {
 				require.Equal(t, checkOpts.Identities, identities)
 			},
 		},
+		{
+			name:    "custom trusted material",
+			success: ast.BooleanTerm(true),
+			errors:  ast.ArrayTerm(),
+			uri:     ast.StringTerm(goodImage.String()),
+			opts: options{
+				certificateIdentity:   "subject",
+				certificateOIDCIssuer: "issuer",
+				fulcioRoots:           utils.TestFulcioRootCert,
+				fulcioIntermediates:   utils.TestFulcioRootIntermediate,
+				ctLogPublicKey:        utils.TestCTLogPublicKey,
+				rekorPublicKey:        utils.TestRekorPublicKey,
+				tsaCertificateChain:   tsaCertificateChain(t),
+			},
+			optsVerifier: func(args mock.Arguments) {
+				checkOpts := args.Get(1).(*cosign.CheckOpts)
+				require.NotNil(t, checkOpts)
+				require.NotNil(t, checkOpts.TrustedMaterial)
+				require.Nil(t, checkOpts.RootCerts)
+				require.Nil(t, checkOpts.CTLogPubKeys)
+				require.Nil(t, checkOpts.RekorPubKeys)
+
+				fulcioRoot, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(utils.TestFulcioRootCert))
+				require.NoError(t, err)
+				fulcioIntermediate, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(utils.TestFulcioRootIntermediate))
+				require.NoError(t, err)
+				authorities := checkOpts.TrustedMaterial.FulcioCertificateAuthorities()
+				require.Len(t, authorities, 1)
+				require.Equal(t, fulcioRoot[0], authorities[0].(*root.FulcioCertificateAuthority).Root)
+				require.Equal(t, fulcioIntermediate, authorities[0].(*root.FulcioCertificateAuthority).Intermediates)
+
+				ctLogs := checkOpts.TrustedMaterial.CTLogs()
+				require.Len(t, ctLogs, 1)
+				for _, ctLog := range ctLogs {
+					publicKey, err := cryptoutils.MarshalPublicKeyToPEM(ctLog.PublicKey)
+					require.NoError(t, err)
+					require.Equal(t, utils.TestCTLogPublicKey, string(publicKey))
+				}
+
+				rekorLogs := checkOpts.TrustedMaterial.RekorLogs()
+				require.Len(t, rekorLogs, 1)
+				require.Contains(t, rekorLogs, utils.TestRekorURLLogID)
+
+				tsas := checkOpts.TrustedMaterial.TimestampingAuthorities()
+				require.Len(t, tsas, 1)
+				require.NotNil(t, tsas[0].(*root.SigstoreTimestampingAuthority).Leaf)
+				require.Len(t, tsas[0].(*root.SigstoreTimestampingAuthority).Intermediates, 1)
+				require.True(t, checkOpts.UseSignedTimestamps)
+			},
+		},
+		{
+			name:    "github workflow certificate extensions",
+			success: ast.BooleanTerm(true),
+			errors:  ast.ArrayTerm(),
+			uri:     ast.StringTerm(goodImage.String()),
+			opts: options{
+				certificateIdentityRegExp:           `^https://github.com/org/repo/`,
+				certificateOIDCIssuer:               "https://token.actions.githubusercontent.com",
+				certificateGithubWorkflowName:       "release",
+				certificateGithubWorkflowRef:        "refs/heads/main",
+				certificateGithubWorkflowRepository: "org/repo",
+				certificateGithubWorkflowSHA:        "d2b8c7e5f2a9b3c4d5e6f7a8b9c0d1e2f3a4b5c6",
+				certificateGithubWorkflowTrigger:    "push",
+			},
+			optsVerifier: func(args mock.Arguments) {
+				checkOpts := args.Get(1).(*cosign.CheckOpts)
+				require.NotNil(t, checkOpts)
+				require.Equal(t, "release", checkOpts.CertGithubWorkflowName)
+				require.Equal(t, "refs/heads/main", checkOpts.CertGithubWorkflowRef)
+				require.Equal(t, "org/repo", checkOpts.CertGithubWorkflowRepository)
+				require.Equal(t, "d2b8c7e5f2a9b3c4d5e6f7a8b9c0d1e2f3a4b5c6", checkOpts.CertGithubWorkflowSha)
+				require.Equal(t, "push", checkOpts.CertGithubWorkflowTrigger)
+			},
+		},
+		{
+			name:    "bad fulcio roots",
+			success: ast.BooleanTerm(false),
+			errors: ast.ArrayTerm(
+				ast.StringTerm("opts parameter: fulcio_roots: error during PEM decoding"),
+			),
+			uri: ast.StringTerm(goodImage.String()),
+			opts: options{
+				certificateIdentity:   "subject",
+				certificateOIDCIssuer: "issuer",
+				fulcioRoots:           "not a certificate",
+			},
+		},
+		{
+			name:    "TSA certificate chain without TSA certificate",
+			success: ast.BooleanTerm(false),
+			errors: ast.ArrayTerm(
+				ast.StringTerm("opts parameter: tsa_certificate_chain: expected exactly one TSA certificate, found 0"),
+			),
+			uri: ast.StringTerm(goodImage.String()),
+			opts: options{
+				publicKey:           utils.TestPublicKey,
+				ignoreRekor:         true,
+				tsaCertificateChain: utils.TestFulcioRootCert,
+			},
+		},
 		{
 			name:    "bad public key",
 			success: ast.BooleanTerm(false),
@@ -687,3 +802,378 @@ func TestSigstoreVerifyImageBundleFallback(t *testing.T) {
 		})
 	}
 }
+
+// tsaCertificateChain returns a PEM encoded certificate chain of a TSA
+// certificate, an intermediate and a root certificate.
+func tsaCertificateChain(t *testing.T) string {
+	t.Helper()
+
+	issue := func(template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		if parent == nil {
+			parent, parentKey = template, key
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+		require.NoError(t, err)
+
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+
+		return cert, key
+	}
+
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := time.Now().Add(time.Hour)
+
+	root, rootKey := issue(&x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "TSA root"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+
+	intermediate, intermediateKey := issue(&x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "TSA intermediate"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, root, rootKey)
+
+	leaf, _ := issue(&x509.Certificate{
+		SerialNumber:          big.NewInt(3),
+		Subject:               pkix.Name{CommonName: "TSA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		BasicConstraintsValid: true,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}, intermediate, intermediateKey)
+
+	chain, err := cryptoutils.MarshalCertificatesToPEM([]*x509.Certificate{leaf, intermediate, root})
+	require.NoError(t, err)
+
+	return string(chain)
+}
+
+// keylessSigstoreBundle returns a Sigstore bundle with the signature of the
+// blob made with a key certified for the identity and issuer by a custom
+// Fulcio root. The certificate contains an embedded SCT of a custom CT log.
+// The PEM encoded Fulcio root and CT log public key are returned as well.
+func keylessSigstoreBundle(t *testing.T, blob []byte, identity, issuer string) (bundle string, fulcioRoot string, ctLogPublicKey string) {
+	t.Helper()
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Fulcio root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	require.NoError(t, err)
+	rootCert, err := x509.ParseCertificate(rootDER)
+	require.NoError(t, err)
+
+	ctLogKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ctLogKeyDER, err := x509.MarshalPKIXPublicKey(&ctLogKey.PublicKey)
+	require.NoError(t, err)
+
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	issuerExtension, err := asn1.Marshal(issuer)
+	require.NoError(t, err)
+
+	leafTemplate := &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		NotBefore:      time.Now().Add(-time.Minute),
+		NotAfter:       time.Now().Add(10 * time.Minute),
+		EmailAddresses: []string{identity},
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}, Value: issuerExtension},
+		},
+	}
+
+	// the SCT is issued for the certificate without the SCT extension
+	preCertDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, rootCert, &signingKey.PublicKey, rootKey)
+	require.NoError(t, err)
+	preCert, err := x509.ParseCertificate(preCertDER)
+	require.NoError(t, err)
+
+	sct := ct.SignedCertificateTimestamp{
+		SCTVersion: ct.V1,
+		LogID:      ct.LogID{KeyID: sha256.Sum256(ctLogKeyDER)},
+		Timestamp:  uint64(time.Now().UnixMilli()),
+	}
+	sctInput, err := ct.SerializeSCTSignatureInput(sct, ct.LogEntry{
+		Leaf: ct.MerkleTreeLeaf{
+			Version:  ct.V1,
+			LeafType: ct.TimestampedEntryLeafType,
+			TimestampedEntry: &ct.TimestampedEntry{
+				Timestamp: sct.Timestamp,
+				EntryType: ct.PrecertLogEntryType,
+				PrecertEntry: &ct.PreCert{
+					IssuerKeyHash:  sha256.Sum256(rootCert.RawSubjectPublicKeyInfo),
+					TBSCertificate: preCert.RawTBSCertificate,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	sctDigest := sha256.Sum256(sctInput)
+	sctSignature, err := ecdsa.SignASN1(rand.Reader, ctLogKey, sctDigest[:])
+	require.NoError(t, err)
+	sct.Signature = ct.DigitallySigned{
+		Algorithm: tls.SignatureAndHashAlgorithm{Hash: tls.SHA256, Signature: tls.ECDSA},
+		Signature: sctSignature,
+	}
+
+	sctList, err := ctx509util.MarshalSCTsIntoSCTList([]*ct.SignedCertificateTimestamp{&sct})
+	require.NoError(t, err)
+	sctListBytes, err := tls.Marshal(*sctList)
+	require.NoError(t, err)
+	sctExtension, err := asn1.Marshal(sctListBytes)
+	require.NoError(t, err)
+
+	leafTemplate.ExtraExtensions = append(leafTemplate.ExtraExtensions, pkix.Extension{
+		Id:    asn1.ObjectIdentifier(ctx509.OIDExtensionCTSCT),
+		Value: sctExtension,
+	})
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, rootCert, &signingKey.PublicKey, rootKey)
+	require.NoError(t, err)
+
+	digest := sha256.Sum256(blob)
+	rawSignature, err := ecdsa.SignASN1(rand.Reader, signingKey, digest[:])
+	require.NoError(t, err)
+
+	bundle = fmt.Sprintf(`{
+		"mediaType": "application/vnd.dev.sigstore.bundle.v0.3+json",
+		"verificationMaterial": {"certificate": {"rawBytes": %q}},
+		"messageSignature": {
+			"messageDigest": {"algorithm": "SHA2_256", "digest": %q},
+			"signature": %q
+		}
+	}`, base64.StdEncoding.EncodeToString(leafDER), base64.StdEncoding.EncodeToString(digest[:]), base64.StdEncoding.EncodeToString(rawSignature))
+
+	rootPEM, err := cryptoutils.MarshalCertificateToPEM(rootCert)
+	require.NoError(t, err)
+
+	ctLogPEM, err := cryptoutils.MarshalPublicKeyToPEM(&ctLogKey.PublicKey)
+	require.NoError(t, err)
+
+	return bundle, string(rootPEM), string(ctLogPEM)
+}
+
+func TestSigstoreVerifyBlob(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	publicKey, err := cryptoutils.MarshalPublicKeyToPEM(&key.PublicKey)
+	require.NoError(t, err)
+
+	blob := []byte("hello world")
+	digest := sha256.Sum256(blob)
+	rawSignature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	signature := base64.StdEncoding.EncodeToString(rawSignature)
+
+	cosignBundle, err := json.Marshal(cosign.LocalSignedPayload{Base64Signature: signature})
+	require.NoError(t, err)
+
+	sigstoreBundle := fmt.Sprintf(`{
+		"mediaType": "application/vnd.dev.sigstore.bundle.v0.3+json",
+		"verificationMaterial": {"publicKey": {"hint": "test"}},
+		"messageSignature": {
+			"messageDigest": {"algorithm": "SHA2_256", "digest": %q},
+			"signature": %q
+		}
+	}`, base64.StdEncoding.EncodeToString(digest[:]), signature)
+
+	opts := options{ignoreRekor: true, publicKey: string(publicKey)}
+
+	cases := []struct {
+		name      string
+		blob      string
+		signature string
+		opts      options
+		success   bool
+		err       string
+	}{
+		{
+			name:      "base64 signature",
+			blob:      string(blob),
+			signature: signature,
+			opts:      opts,
+			success:   true,
+		},
+		{
+			name:      "cosign bundle",
+			blob:      string(blob),
+			signature: string(cosignBundle),
+			opts:      opts,
+			success:   true,
+		},
+		{
+			name:      "sigstore bundle",
+			blob:      string(blob),
+			signature: sigstoreBundle,
+			opts:      opts,
+			success:   true,
+		},
+		{
+			name:      "tampered blob",
+			blob:      "goodbye world",
+			signature: signature,
+			opts:      opts,
+			err:       "verify blob signature: ",
+		},
+		{
+			name:      "tampered blob with sigstore bundle",
+			blob:      "goodbye world",
+			signature: sigstoreBundle,
+			opts:      opts,
+			err:       "verify blob signature: ",
+		},
+		{
+			name:      "wrong key",
+			blob:      string(blob),
+			signature: signature,
+			opts:      options{ignoreRekor: true, publicKey: utils.TestPublicKey},
+			err:       "verify blob signature: ",
+		},
+		{
+			name:      "malformed bundle",
+			blob:      string(blob),
+			signature: "{",
+			opts:      opts,
+			err:       "verify blob signature: malformed bundle: ",
+		},
+		{
+			name:      "malformed sigstore bundle",
+			blob:      string(blob),
+			signature: `{"mediaType": "application/vnd.dev.sigstore.bundle.v0.3+json"}`,
+			opts:      opts,
+			err:       "verify blob signature: malformed Sigstore bundle: ",
+		},
+		{
+			name:      "bundle without signature",
+			blob:      string(blob),
+			signature: `{}`,
+			opts:      opts,
+			err:       "verify blob signature: no signature provided",
+		},
+		{
+			name:      "missing verification material",
+			blob:      string(blob),
+			signature: signature,
+			opts:      options{ignoreRekor: true},
+			err:       "opts parameter: ",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			utils.SetTestRekorPublicKey(t)
+			utils.SetTestFulcioRoots(t)
+			utils.SetTestCTLogPublicKey(t)
+
+			bctx := rego.BuiltinContext{Context: context.Background()}
+
+			result, err := sigstoreVerifyBlob(bctx, ast.StringTerm(tt.blob), ast.StringTerm(tt.signature), tt.opts.toTerm())
+			require.NoError(t, err)
+			require.NotNil(t, result)
+			require.Equal(t, ast.BooleanTerm(tt.success), result.Get(ast.StringTerm("success")))
+
+			errs := result.Get(ast.StringTerm("errors")).Value.(*ast.Array)
+			signatures := result.Get(ast.StringTerm("signatures")).Value.(*ast.Array)
+			if tt.success {
+				require.Equal(t, 0, errs.Len())
+				require.Equal(t, 1, signatures.Len())
+				require.Equal(t, ast.StringTerm(signature), signatures.Elem(0).Get(ast.StringTerm("signature")))
+			} else {
+				require.Equal(t, 1, errs.Len())
+				require.Contains(t, string(errs.Elem(0).Value.(ast.String)), tt.err)
+				require.Equal(t, 0, signatures.Len())
+			}
+		})
+	}
+}
+
+func TestSigstoreVerifyBlobKeylessBundle(t *testing.T) {
+	blob := []byte("hello world")
+	bundle, fulcioRoot, ctLogPublicKey := keylessSigstoreBundle(t, blob, "jane@example.com", "https://issuer.example.com")
+	_, otherFulcioRoot, otherCTLogPublicKey := keylessSigstoreBundle(t, blob, "jane@example.com", "https://issuer.example.com")
+
+	opts := options{
+		certificateIdentity:   "jane@example.com",
+		certificateOIDCIssuer: "https://issuer.example.com",
+		ignoreRekor:           true,
+		fulcioRoots:           fulcioRoot,
+		ctLogPublicKey:        ctLogPublicKey,
+	}
+
+	untrustedRoot := opts
+	untrustedRoot.fulcioRoots = otherFulcioRoot
+
+	untrustedCTLog := opts
+	untrustedCTLog.ctLogPublicKey = otherCTLogPublicKey
+
+	otherIdentity := opts
+	otherIdentity.certificateIdentity = "john@example.com"
+
+	cases := []struct {
+		name    string
+		blob    string
+		opts    options
+		success bool
+	}{
+		{name: "custom trust", blob: string(blob), opts: opts, success: true},
+		{name: "tampered blob", blob: "goodbye world", opts: opts},
+		{name: "untrusted Fulcio root", blob: string(blob), opts: untrustedRoot},
+		{name: "untrusted CT log", blob: string(blob), opts: untrustedCTLog},
+		{name: "other identity", blob: string(blob), opts: otherIdentity},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			utils.SetTestRekorPublicKey(t)
+			utils.SetTestFulcioRoots(t)
+			utils.SetTestCTLogPublicKey(t)
+
+			bctx := rego.BuiltinContext{Context: context.Background()}
+
+			result, err := sigstoreVerifyBlob(bctx, ast.StringTerm(tt.blob), ast.StringTerm(bundle), tt.opts.toTerm())
+			require.NoError(t, err)
+			require.NotNil(t, result)
+
+			errs := result.Get(ast.StringTerm("errors")).Value.(*ast.Array)
+			signatures := result.Get(ast.StringTerm("signatures")).Value.(*ast.Array)
+			if tt.success {
+				require.Equal(t, 0, errs.Len(), "%v", errs)
+				require.Equal(t, ast.BooleanTerm(true), result.Get(ast.StringTerm("success")))
+				require.Equal(t, 1, signatures.Len())
+				require.NotNil(t, signatures.Elem(0).Get(ast.StringTerm("certificate")))
+			} else {
+				require.Equal(t, ast.BooleanTerm(false), result.Get(ast.StringTerm("success")))
+				require.Equal(t, 1, errs.Len())
+				require.Equal(t, 0, signatures.Len())
+			}
+		})
+	}
+}
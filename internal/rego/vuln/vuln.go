@@ -0,0 +1,487 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// IMPORTANT: The rego functions in this file never return an error. Instead, they return no value
+// when an error is encountered. If they did return an error, opa would exit abruptly and it would
+// not produce a report of which policy rules succeeded/failed.
+
+package vuln
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/open-policy-agent/opa/v1/types"
+	"github.com/package-url/packageurl-go"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"golang.org/x/sync/singleflight"
+
+	regopurl "github.com/conforma/cli/internal/rego/purl"
+	"github.com/conforma/cli/internal/utils"
+)
+
+const vulnMatchName = "ec.vuln.match"
+
+// DatabaseDir is the name of the directory, at the root of a policy data
+// source, holding the OSV vulnerability database.
+const DatabaseDir = "osv"
+
+func registerVulnMatch() {
+	match := types.NewObject(
+		[]*types.StaticProperty{
+			{Key: "purl", Value: types.S},
+			{Key: "id", Value: types.S},
+			{Key: "aliases", Value: types.NewArray(nil, types.S)},
+			{Key: "summary", Value: types.S},
+			{Key: "ranges", Value: types.NewArray(nil, types.S)},
+			{Key: "fixed", Value: types.NewArray(nil, types.S)},
+		},
+		nil,
+	)
+
+	decl := rego.Function{
+		Name: vulnMatchName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("purls", types.NewAny(
+					types.S,
+					types.NewArray(nil, types.S),
+				)).Description("the PURL, or list of PURLs, to match"),
+			),
+			types.Named("matches", types.NewArray(nil, match)).Description("the advisories affecting the PURLs, with the affected version ranges in the vers format and the fixed versions"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic. But also mark it as non-deterministic because it does rely on external
+		// entities, i.e. the vulnerability database on disk.
+		// https://www.openpolicyagent.org/docs/latest/extensions/
+		Memoize:          true,
+		Nondeterministic: true,
+	}
+
+	rego.RegisterBuiltin1(&decl, vulnMatch)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      "Match PURLs against the OSV vulnerability database provided in the osv directory of a policy data source.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
+type databaseDirsKey struct{}
+
+// WithDatabaseDirs returns a new context with the directories holding the OSV
+// vulnerability database.
+func WithDatabaseDirs(ctx context.Context, dirs []string) context.Context {
+	return context.WithValue(ctx, databaseDirsKey{}, dirs)
+}
+
+func databaseDirsFromContext(ctx context.Context) []string {
+	if dirs, ok := ctx.Value(databaseDirsKey{}).([]string); ok {
+		return dirs
+	}
+	return nil
+}
+
+// The OSV databases are pinned by the policy data sources and are the same for
+// all components, so they're loaded once per directory.
+var (
+	databases      sync.Map
+	databaseFlight singleflight.Group
+)
+
+// ClearCaches clears the loaded databases. This is primarily used for testing
+// to ensure tests don't interfere with each other via cached values.
+func ClearCaches() {
+	databases = sync.Map{}
+}
+
+// osvRecord holds the parts of the OSV record we're interested in, see
+// https://ossf.github.io/osv-schema/
+type osvRecord struct {
+	ID        string        `json:"id"`
+	Aliases   []string      `json:"aliases"`
+	Summary   string        `json:"summary"`
+	Withdrawn string        `json:"withdrawn"`
+	Affected  []osvAffected `json:"affected"`
+}
+
+type osvAffected struct {
+	Package  osvPackage `json:"package"`
+	Ranges   []osvRange `json:"ranges"`
+	Versions []string   `json:"versions"`
+}
+
+type osvPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	PURL      string `json:"purl"`
+}
+
+type osvRange struct {
+	Type   string     `json:"type"`
+	Events []osvEvent `json:"events"`
+}
+
+type osvEvent struct {
+	Introduced   string `json:"introduced"`
+	Fixed        string `json:"fixed"`
+	LastAffected string `json:"last_affected"`
+	Limit        string `json:"limit"`
+}
+
+// affectedPackage is a package affected by the vulnerability of the record
+type affectedPackage struct {
+	record   *osvRecord
+	affected osvAffected
+}
+
+// database holds the affected packages of all records, keyed by packageKey.
+type database map[string][]affectedPackage
+
+// ecosystems maps the OSV ecosystems to the PURL type and, for the
+// distributions, the PURL namespace. This is used for the records that do not
+// provide the PURL of the affected package.
+var ecosystems = map[string][2]string{
+	"crates.io": {"cargo", ""},
+	"Debian":    {"deb", "debian"},
+	"Go":        {"golang", ""},
+	"Maven":     {"maven", ""},
+	"npm":       {"npm", ""},
+	"NuGet":     {"nuget", ""},
+	"PyPI":      {"pypi", ""},
+	"Red Hat":   {"rpm", "redhat"},
+	"RubyGems":  {"gem", ""},
+	"Ubuntu":    {"deb", "ubuntu"},
+}
+
+// packageKey identifies the package regardless of its version, qualifiers and
+// subpath.
+func packageKey(p packageurl.PackageURL) string {
+	typ := strings.ToLower(p.Type)
+	name := p.Name
+	if typ == packageurl.TypePyPi {
+		// https://packaging.python.org/en/latest/specifications/name-normalization/
+		name = strings.NewReplacer("_", "-", ".", "-").Replace(strings.ToLower(name))
+	}
+
+	return typ + "/" + p.Namespace + "/" + name
+}
+
+// affectedPackageKey returns the packageKey of the affected package, or an
+// empty string if the package is in an unsupported ecosystem without a PURL.
+func affectedPackageKey(p osvPackage) string {
+	if p.PURL != "" {
+		instance, err := packageurl.FromString(p.PURL)
+		if err != nil {
+			return ""
+		}
+		return packageKey(instance)
+	}
+
+	// ecosystems can have a suffix, e.g. Debian:12
+	ecosystem, _, _ := strings.Cut(p.Ecosystem, ":")
+	purlType, ok := ecosystems[ecosystem]
+	if !ok || p.Name == "" {
+		return ""
+	}
+
+	instance := packageurl.PackageURL{Type: purlType[0], Namespace: purlType[1], Name: p.Name}
+	switch instance.Type {
+	case packageurl.TypeMaven:
+		instance.Namespace, instance.Name, _ = strings.Cut(p.Name, ":")
+	case packageurl.TypeGolang, packageurl.TypeNPM:
+		if i := strings.LastIndex(p.Name, "/"); i >= 0 {
+			instance.Namespace, instance.Name = p.Name[:i], p.Name[i+1:]
+		}
+	}
+
+	return packageKey(instance)
+}
+
+// loadDatabase loads all OSV records, i.e. JSON files, from the directory.
+func loadDatabase(fs afero.Fs, dir string) (database, error) {
+	db := database{}
+	err := afero.Walk(fs, dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		content, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return err
+		}
+
+		var record osvRecord
+		if err := json.Unmarshal(content, &record); err != nil {
+			return fmt.Errorf("malformed OSV record %s: %w", path, err)
+		}
+
+		if record.Withdrawn != "" {
+			return nil
+		}
+
+		for _, a := range record.Affected {
+			if key := affectedPackageKey(a.Package); key != "" {
+				db[key] = append(db[key], affectedPackage{record: &record, affected: a})
+			}
+		}
+
+		return nil
+	})
+
+	return db, err
+}
+
+func databaseFor(fs afero.Fs, dir string) (database, error) {
+	if cached, found := databases.Load(dir); found {
+		return cached.(database), nil
+	}
+
+	db, err, _ := databaseFlight.Do(dir, func() (any, error) {
+		if cached, found := databases.Load(dir); found {
+			return cached, nil
+		}
+
+		db, err := loadDatabase(fs, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		databases.Store(dir, db)
+		return db, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return db.(database), nil
+}
+
+// versEscaper escapes the characters with a special meaning in vers
+var versEscaper = strings.NewReplacer("%", "%25", "|", "%7C")
+
+// versRange converts the OSV range into a vers version range, returns an
+// empty string for the ranges without version semantics, i.e. GIT ranges.
+func versRange(purlType string, r osvRange) string {
+	scheme := purlType
+	switch r.Type {
+	case "ECOSYSTEM":
+	case "SEMVER":
+		scheme = "semver"
+	default:
+		return ""
+	}
+
+	constraints := make([]string, 0, len(r.Events))
+	unbounded := false
+	for _, e := range r.Events {
+		switch {
+		case e.Introduced == "0":
+			unbounded = true
+		case e.Introduced != "":
+			constraints = append(constraints, ">="+versEscaper.Replace(e.Introduced))
+		case e.Fixed != "":
+			constraints = append(constraints, "<"+versEscaper.Replace(e.Fixed))
+		case e.LastAffected != "":
+			constraints = append(constraints, "<="+versEscaper.Replace(e.LastAffected))
+		case e.Limit != "":
+			constraints = append(constraints, "<"+versEscaper.Replace(e.Limit))
+		}
+	}
+
+	if len(constraints) == 0 {
+		if !unbounded {
+			return ""
+		}
+		return fmt.Sprintf("vers:%s/*", scheme)
+	}
+
+	return fmt.Sprintf("vers:%s/%s", scheme, strings.Join(constraints, "|"))
+}
+
+// match is an advisory affecting the PURL
+type match struct {
+	purl   string
+	record *osvRecord
+	ranges []string
+	fixed  []string
+}
+
+func (m *match) term() *ast.Term {
+	stringsTerm := func(values []string) *ast.Term {
+		terms := make([]*ast.Term, 0, len(values))
+		for _, v := range values {
+			terms = append(terms, ast.StringTerm(v))
+		}
+		return ast.ArrayTerm(terms...)
+	}
+
+	return ast.ObjectTerm(
+		ast.Item(ast.StringTerm("purl"), ast.StringTerm(m.purl)),
+		ast.Item(ast.StringTerm("id"), ast.StringTerm(m.record.ID)),
+		ast.Item(ast.StringTerm("aliases"), stringsTerm(m.record.Aliases)),
+		ast.Item(ast.StringTerm("summary"), ast.StringTerm(m.record.Summary)),
+		ast.Item(ast.StringTerm("ranges"), stringsTerm(m.ranges)),
+		ast.Item(ast.StringTerm("fixed"), stringsTerm(m.fixed)),
+	)
+}
+
+// matchPURL returns the advisories from the database affecting the PURL, the
+// PURL is affected if its version is listed as affected or is within one of
+// the affected version ranges.
+func matchPURL(db database, uri string) ([]*match, error) {
+	instance, err := packageurl.FromString(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if instance.Version == "" {
+		return nil, fmt.Errorf("PURL %q has no version", uri)
+	}
+
+	logger := log.WithFields(log.Fields{"function": vulnMatchName, "purl": uri})
+
+	var matches []*match
+	byID := map[string]*match{}
+	for _, a := range db[packageKey(instance)] {
+		affected := slices.Contains(a.affected.Versions, instance.Version)
+
+		var ranges, fixed []string
+		for _, r := range a.affected.Ranges {
+			vers := versRange(instance.Type, r)
+			if vers == "" {
+				continue
+			}
+			ranges = append(ranges, vers)
+
+			for _, e := range r.Events {
+				if e.Fixed != "" && !slices.Contains(fixed, e.Fixed) {
+					fixed = append(fixed, e.Fixed)
+				}
+			}
+
+			if affected {
+				continue
+			}
+
+			if affected, err = regopurl.InRange(uri, vers); err != nil {
+				logger.WithFields(log.Fields{"id": a.record.ID, "range": vers, "error": err}).Debug("unable to match the version range")
+				affected = false
+			}
+		}
+
+		if !affected {
+			continue
+		}
+
+		m, ok := byID[a.record.ID]
+		if !ok {
+			m = &match{purl: uri, record: a.record}
+			byID[a.record.ID] = m
+			matches = append(matches, m)
+		}
+
+		for _, r := range ranges {
+			if !slices.Contains(m.ranges, r) {
+				m.ranges = append(m.ranges, r)
+			}
+		}
+		for _, f := range fixed {
+			if !slices.Contains(m.fixed, f) {
+				m.fixed = append(m.fixed, f)
+			}
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b *match) int {
+		return strings.Compare(a.record.ID, b.record.ID)
+	})
+
+	return matches, nil
+}
+
+func vulnMatch(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
+	logger := log.WithField("function", vulnMatchName)
+
+	var purls []string
+	switch v := a.Value.(type) {
+	case ast.String:
+		purls = []string{string(v)}
+	case *ast.Array:
+		for i := 0; i < v.Len(); i++ {
+			s, ok := v.Elem(i).Value.(ast.String)
+			if !ok {
+				logger.Error("input array contains a non-string value")
+				return nil, nil
+			}
+			purls = append(purls, string(s))
+		}
+	default:
+		logger.Error("input is neither a string nor an array")
+		return nil, nil
+	}
+
+	dirs := databaseDirsFromContext(bctx.Context)
+	if len(dirs) == 0 {
+		logger.Error("no OSV database provided, expecting an osv directory in a policy data source")
+		return nil, nil
+	}
+
+	fs := utils.FS(bctx.Context)
+	terms := []*ast.Term{}
+	for _, dir := range dirs {
+		db, err := databaseFor(fs, dir)
+		if err != nil {
+			logger.WithFields(log.Fields{"dir": dir, "error": err}).Error("unable to load the OSV database")
+			return nil, nil
+		}
+
+		for _, uri := range purls {
+			matches, err := matchPURL(db, uri)
+			if err != nil {
+				logger.WithFields(log.Fields{"purl": uri, "error": err}).Error("unable to match the PURL")
+				return nil, nil
+			}
+
+			for _, m := range matches {
+				terms = append(terms, m.term())
+			}
+		}
+	}
+
+	logger.WithField("matches", len(terms)).Debug("Successfully matched PURLs")
+	return ast.ArrayTerm(terms...), nil
+}
+
+func init() {
+	registerVulnMatch()
+}
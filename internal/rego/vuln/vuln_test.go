@@ -0,0 +1,331 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package vuln
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conforma/cli/internal/utils"
+)
+
+const databaseDir = "/data/source/osv"
+
+var records = map[string]string{
+	"npm/GHSA-35jh-r3h4-6jhm.json": `{
+		"id": "GHSA-35jh-r3h4-6jhm",
+		"aliases": ["CVE-2021-23337"],
+		"summary": "Command Injection in lodash",
+		"affected": [{
+			"package": {"ecosystem": "npm", "name": "lodash"},
+			"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "4.17.21"}]}]
+		}]
+	}`,
+	"npm/GHSA-withdrawn.json": `{
+		"id": "GHSA-withdrawn",
+		"withdrawn": "2024-01-01T00:00:00Z",
+		"affected": [{
+			"package": {"ecosystem": "npm", "name": "lodash"},
+			"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}]}]
+		}]
+	}`,
+	"PyPI/PYSEC-2023-100.json": `{
+		"id": "PYSEC-2023-100",
+		"summary": "Django denial of service",
+		"affected": [{
+			"package": {"ecosystem": "PyPI", "name": "Django"},
+			"ranges": [
+				{"type": "ECOSYSTEM", "events": [{"introduced": "3.2"}, {"fixed": "3.2.20"}, {"introduced": "4.0"}, {"fixed": "4.1.10"}]},
+				{"type": "GIT", "repo": "https://github.com/django/django", "events": [{"introduced": "0"}, {"fixed": "abc123"}]}
+			],
+			"versions": ["4.2rc1"]
+		}]
+	}`,
+	"Maven/GHSA-jfh8-c2jp-5v3q.json": `{
+		"id": "GHSA-jfh8-c2jp-5v3q",
+		"aliases": ["CVE-2021-44228"],
+		"summary": "Remote code injection in Log4j",
+		"affected": [{
+			"package": {"ecosystem": "Maven", "name": "org.apache.logging.log4j:log4j-core"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "2.0-beta9"}, {"fixed": "2.15.0"}]}]
+		}]
+	}`,
+	"Debian/DSA-5417-1.json": `{
+		"id": "DSA-5417-1",
+		"affected": [{
+			"package": {"ecosystem": "Debian:12", "name": "openssl"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "3.0.9-1"}]}]
+		}]
+	}`,
+	"Red Hat/RHSA-2023:1405.json": `{
+		"id": "RHSA-2023:1405",
+		"affected": [{
+			"package": {"ecosystem": "Red Hat:enterprise_linux:9::appstream", "name": "openssl", "purl": "pkg:rpm/redhat/openssl"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "1:3.0.7-6.el9_2"}]}]
+		}, {
+			"package": {"ecosystem": "Red Hat:enterprise_linux:9::baseos", "name": "openssl", "purl": "pkg:rpm/redhat/openssl"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "1:3.0.7-6.el9_2"}]}]
+		}]
+	}`,
+}
+
+func setup(t *testing.T) context.Context {
+	t.Helper()
+	ClearCaches()
+
+	fs := afero.NewMemMapFs()
+	for name, content := range records {
+		path := filepath.Join(databaseDir, name)
+		require.NoError(t, fs.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, afero.WriteFile(fs, path, []byte(content), 0644))
+	}
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(databaseDir, "README.md"), []byte("# OSV"), 0644))
+
+	ctx := utils.WithFS(context.Background(), fs)
+	return WithDatabaseDirs(ctx, []string{databaseDir})
+}
+
+func requireJSONEq(t *testing.T, expected string, actual *ast.Term) {
+	t.Helper()
+	require.NotNil(t, actual)
+
+	value, err := ast.JSON(actual.Value)
+	require.NoError(t, err)
+
+	j, err := json.Marshal(value)
+	require.NoError(t, err)
+
+	require.JSONEq(t, expected, string(j))
+}
+
+func TestVulnMatch(t *testing.T) {
+	cases := []struct {
+		name     string
+		purls    *ast.Term
+		expected string
+	}{
+		{
+			name:  "semver range",
+			purls: ast.StringTerm("pkg:npm/lodash@4.17.20"),
+			expected: `[{
+				"purl": "pkg:npm/lodash@4.17.20",
+				"id": "GHSA-35jh-r3h4-6jhm",
+				"aliases": ["CVE-2021-23337"],
+				"summary": "Command Injection in lodash",
+				"ranges": ["vers:semver/<4.17.21"],
+				"fixed": ["4.17.21"]
+			}]`,
+		},
+		{
+			name:     "fixed version",
+			purls:    ast.StringTerm("pkg:npm/lodash@4.17.21"),
+			expected: `[]`,
+		},
+		{
+			name:  "ecosystem ranges with normalized name",
+			purls: ast.StringTerm("pkg:pypi/django@4.0.5"),
+			expected: `[{
+				"purl": "pkg:pypi/django@4.0.5",
+				"id": "PYSEC-2023-100",
+				"aliases": [],
+				"summary": "Django denial of service",
+				"ranges": ["vers:pypi/>=3.2|<3.2.20|>=4.0|<4.1.10"],
+				"fixed": ["3.2.20", "4.1.10"]
+			}]`,
+		},
+		{
+			name:     "between ranges",
+			purls:    ast.StringTerm("pkg:pypi/django@3.2.20"),
+			expected: `[]`,
+		},
+		{
+			name:  "listed version",
+			purls: ast.StringTerm("pkg:pypi/django@4.2rc1"),
+			expected: `[{
+				"purl": "pkg:pypi/django@4.2rc1",
+				"id": "PYSEC-2023-100",
+				"aliases": [],
+				"summary": "Django denial of service",
+				"ranges": ["vers:pypi/>=3.2|<3.2.20|>=4.0|<4.1.10"],
+				"fixed": ["3.2.20", "4.1.10"]
+			}]`,
+		},
+		{
+			name: "multiple PURLs",
+			purls: ast.ArrayTerm(
+				ast.StringTerm("pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"),
+				ast.StringTerm("pkg:deb/debian/openssl@3.0.8-1?arch=amd64"),
+				ast.StringTerm("pkg:deb/debian/curl@7.88.1-10"),
+			),
+			expected: `[{
+				"purl": "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+				"id": "GHSA-jfh8-c2jp-5v3q",
+				"aliases": ["CVE-2021-44228"],
+				"summary": "Remote code injection in Log4j",
+				"ranges": ["vers:maven/>=2.0-beta9|<2.15.0"],
+				"fixed": ["2.15.0"]
+			}, {
+				"purl": "pkg:deb/debian/openssl@3.0.8-1?arch=amd64",
+				"id": "DSA-5417-1",
+				"aliases": [],
+				"summary": "",
+				"ranges": ["vers:deb/<3.0.9-1"],
+				"fixed": ["3.0.9-1"]
+			}]`,
+		},
+		{
+			name:  "merged affected packages",
+			purls: ast.StringTerm("pkg:rpm/redhat/openssl@3.0.7-2.el9?arch=x86_64&epoch=1"),
+			expected: `[{
+				"purl": "pkg:rpm/redhat/openssl@3.0.7-2.el9?arch=x86_64&epoch=1",
+				"id": "RHSA-2023:1405",
+				"aliases": [],
+				"summary": "",
+				"ranges": ["vers:rpm/<1:3.0.7-6.el9_2"],
+				"fixed": ["1:3.0.7-6.el9_2"]
+			}]`,
+		},
+		{
+			name:     "fixed epoch",
+			purls:    ast.StringTerm("pkg:rpm/redhat/openssl@3.0.7-27.el9?arch=x86_64&epoch=1"),
+			expected: `[]`,
+		},
+		{
+			name:     "unknown package",
+			purls:    ast.StringTerm("pkg:npm/left-pad@1.0.0"),
+			expected: `[]`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bctx := rego.BuiltinContext{Context: setup(t)}
+
+			result, err := vulnMatch(bctx, c.purls)
+			require.NoError(t, err)
+			requireJSONEq(t, c.expected, result)
+		})
+	}
+}
+
+func TestVulnMatchErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		ctx   func(*testing.T) context.Context
+		purls *ast.Term
+	}{
+		{
+			name:  "no database",
+			ctx:   func(*testing.T) context.Context { return context.Background() },
+			purls: ast.StringTerm("pkg:npm/lodash@4.17.20"),
+		},
+		{
+			name: "malformed record",
+			ctx: func(t *testing.T) context.Context {
+				ctx := setup(t)
+				require.NoError(t, afero.WriteFile(utils.FS(ctx), filepath.Join(databaseDir, "bad.json"), []byte("{"), 0644))
+				return ctx
+			},
+			purls: ast.StringTerm("pkg:npm/lodash@4.17.20"),
+		},
+		{
+			name:  "missing version",
+			ctx:   setup,
+			purls: ast.StringTerm("pkg:npm/lodash"),
+		},
+		{
+			name:  "invalid PURL",
+			ctx:   setup,
+			purls: ast.StringTerm("lodash@4.17.20"),
+		},
+		{
+			name:  "unexpected type",
+			ctx:   setup,
+			purls: ast.ArrayTerm(ast.IntNumberTerm(42)),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bctx := rego.BuiltinContext{Context: c.ctx(t)}
+
+			result, err := vulnMatch(bctx, c.purls)
+			require.NoError(t, err)
+			assert.Nil(t, result)
+		})
+	}
+}
+
+func TestVersRange(t *testing.T) {
+	cases := []struct {
+		name     string
+		r        osvRange
+		expected string
+	}{
+		{
+			name:     "all versions",
+			r:        osvRange{Type: "ECOSYSTEM", Events: []osvEvent{{Introduced: "0"}}},
+			expected: "vers:npm/*",
+		},
+		{
+			name:     "last affected",
+			r:        osvRange{Type: "ECOSYSTEM", Events: []osvEvent{{Introduced: "1.0.0"}, {LastAffected: "1.2.3"}}},
+			expected: "vers:npm/>=1.0.0|<=1.2.3",
+		},
+		{
+			name:     "escaped",
+			r:        osvRange{Type: "SEMVER", Events: []osvEvent{{Introduced: "0"}, {Fixed: "1.0.0-a|b"}}},
+			expected: "vers:semver/<1.0.0-a%7Cb",
+		},
+		{
+			name: "git",
+			r:    osvRange{Type: "GIT", Events: []osvEvent{{Introduced: "0"}, {Fixed: "abc123"}}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, versRange("npm", c.r))
+		})
+	}
+}
+
+func TestFunctionsRegistered(t *testing.T) {
+	names := []string{
+		vulnMatchName,
+	}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			for _, builtin := range ast.Builtins {
+				if builtin.Name == name {
+					return
+				}
+			}
+			t.Fatalf("%s builtin not registered", name)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sarif renders evaluation results in the Static Analysis Results
+// Interchange Format (SARIF).
+package sarif
+
+import (
+	"bytes"
+	"fmt"
+
+	gosarif "github.com/owenrumney/go-sarif/v2/sarif"
+
+	"github.com/conforma/cli/internal/evaluator"
+	"github.com/conforma/cli/internal/opa/rule"
+)
+
+const (
+	toolName       = "Conforma"
+	informationURI = "https://conforma.dev"
+	// unknownRule is the rule id used for results without a code
+	unknownRule = "unknown"
+
+	levelError   = "error"
+	levelWarning = "warning"
+)
+
+// Artifact holds the violations and warnings reported for a single artifact,
+// e.g. an image or an input file.
+type Artifact struct {
+	URI        string
+	Violations []evaluator.Result
+	Warnings   []evaluator.Result
+}
+
+// Marshal returns the violations and warnings of the given artifacts as a
+// SARIF 2.1.0 log with a single run. Each result references the rule
+// identified by its code, the rules are described using the result metadata.
+func Marshal(version string, artifacts []Artifact) ([]byte, error) {
+	report, err := gosarif.New(gosarif.Version210)
+	if err != nil {
+		return nil, err
+	}
+
+	run := gosarif.NewRunWithInformationURI(toolName, informationURI)
+	if version != "" {
+		run.Tool.Driver.WithVersion(version)
+	}
+
+	for _, a := range artifacts {
+		for _, r := range a.Violations {
+			addResult(run, a.URI, r, levelError)
+		}
+		for _, r := range a.Warnings {
+			addResult(run, a.URI, r, levelWarning)
+		}
+	}
+
+	report.AddRun(run)
+
+	var buff bytes.Buffer
+	if err := report.Write(&buff); err != nil {
+		return nil, err
+	}
+
+	return buff.Bytes(), nil
+}
+
+// addResult adds the result to the run, along with the rule it references
+// if not already present. The level is determined by the severity of the
+// result, falling back to the provided level if the result doesn't specify one.
+func addResult(run *gosarif.Run, uri string, r evaluator.Result, level string) {
+	id := unknownRule
+	if code, ok := r.Metadata["code"]; ok {
+		id = fmt.Sprint(code)
+	}
+
+	if _, err := run.GetRuleById(id); err != nil {
+		addRule(run, id, r)
+	}
+
+	switch evaluator.GetSeverity(r) {
+	case evaluator.SeverityFailure:
+		level = levelError
+	case evaluator.SeverityWarning:
+		level = levelWarning
+	}
+
+	result := run.CreateResultForRule(id).
+		WithLevel(level).
+		WithMessage(gosarif.NewTextMessage(r.Message))
+
+	if uri != "" {
+		result.AddLocation(gosarif.NewLocationWithPhysicalLocation(
+			gosarif.NewPhysicalLocation().WithArtifactLocation(gosarif.NewSimpleArtifactLocation(uri)),
+		))
+	}
+}
+
+func addRule(run *gosarif.Run, id string, r evaluator.Result) {
+	descriptor := run.AddRule(id)
+
+	// The short description is always rendered, use the id when there is no
+	// title to describe the rule with
+	title := metadataString(r, "title")
+	if title == "" {
+		title = id
+	}
+	descriptor.WithShortDescription(gosarif.NewMultiformatMessageString(title))
+	if description := metadataString(r, "description"); description != "" {
+		descriptor.WithFullDescription(gosarif.NewMultiformatMessageString(description))
+	}
+	if solution := metadataString(r, "solution"); solution != "" {
+		descriptor.WithTextHelp(solution)
+	}
+	if id != unknownRule {
+		if url := rule.DocumentationUrlFromCode(id); url != "" {
+			descriptor.WithHelpURI(url)
+		}
+	}
+}
+
+func metadataString(r evaluator.Result, key string) string {
+	if v, ok := r.Metadata[key]; ok {
+		return fmt.Sprint(v)
+	}
+
+	return ""
+}
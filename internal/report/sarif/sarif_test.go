@@ -0,0 +1,189 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package sarif
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conforma/cli/internal/evaluator"
+)
+
+func TestMarshal(t *testing.T) {
+	data, err := Marshal("v1.2.3", []Artifact{
+		{
+			URI: "registry.io/repository/image@sha256:abc",
+			Violations: []evaluator.Result{
+				{
+					Message: "violation1",
+					Metadata: map[string]any{
+						"code":        "release.pkg.rule1",
+						"title":       "Rule one",
+						"description": "The first rule",
+						"solution":    "Fix it",
+					},
+				},
+				{
+					Message: "violation2",
+					Metadata: map[string]any{
+						"code": "release.pkg.rule1",
+					},
+				},
+				{
+					Message: "no code",
+				},
+			},
+			Warnings: []evaluator.Result{
+				{
+					Message: "warning1",
+					Metadata: map[string]any{
+						"code": "release.pkg.rule2",
+					},
+				},
+				{
+					Message: "warning with failure severity",
+					Metadata: map[string]any{
+						"code":     "release.pkg.rule3",
+						"severity": "failure",
+					},
+				},
+			},
+		},
+		{
+			URI: "registry.io/repository/other@sha256:def",
+			Violations: []evaluator.Result{
+				{
+					Message: "violation with warning severity",
+					Metadata: map[string]any{
+						"code":     "release.pkg.rule1",
+						"severity": "warning",
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"version": "2.1.0",
+		"$schema": "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/main/sarif-2.1/schema/sarif-schema-2.1.0.json",
+		"runs": [
+			{
+				"tool": {
+					"driver": {
+						"name": "Conforma",
+						"version": "v1.2.3",
+						"informationUri": "https://conforma.dev",
+						"rules": [
+							{
+								"id": "release.pkg.rule1",
+								"shortDescription": {"text": "Rule one"},
+								"fullDescription": {"text": "The first rule"},
+								"help": {"text": "Fix it"},
+								"helpUri": "https://conforma.dev/docs/policy/packages/release_pkg.html#pkg__rule1"
+							},
+							{
+								"id": "unknown",
+								"shortDescription": {"text": "unknown"}
+							},
+							{
+								"id": "release.pkg.rule2",
+								"shortDescription": {"text": "release.pkg.rule2"},
+								"helpUri": "https://conforma.dev/docs/policy/packages/release_pkg.html#pkg__rule2"
+							},
+							{
+								"id": "release.pkg.rule3",
+								"shortDescription": {"text": "release.pkg.rule3"},
+								"helpUri": "https://conforma.dev/docs/policy/packages/release_pkg.html#pkg__rule3"
+							}
+						]
+					}
+				},
+				"results": [
+					{
+						"ruleId": "release.pkg.rule1",
+						"ruleIndex": 0,
+						"level": "error",
+						"message": {"text": "violation1"},
+						"locations": [{"physicalLocation": {"artifactLocation": {"uri": "registry.io/repository/image@sha256:abc"}}}]
+					},
+					{
+						"ruleId": "release.pkg.rule1",
+						"ruleIndex": 0,
+						"level": "error",
+						"message": {"text": "violation2"},
+						"locations": [{"physicalLocation": {"artifactLocation": {"uri": "registry.io/repository/image@sha256:abc"}}}]
+					},
+					{
+						"ruleId": "unknown",
+						"ruleIndex": 1,
+						"level": "error",
+						"message": {"text": "no code"},
+						"locations": [{"physicalLocation": {"artifactLocation": {"uri": "registry.io/repository/image@sha256:abc"}}}]
+					},
+					{
+						"ruleId": "release.pkg.rule2",
+						"ruleIndex": 2,
+						"level": "warning",
+						"message": {"text": "warning1"},
+						"locations": [{"physicalLocation": {"artifactLocation": {"uri": "registry.io/repository/image@sha256:abc"}}}]
+					},
+					{
+						"ruleId": "release.pkg.rule3",
+						"ruleIndex": 3,
+						"level": "error",
+						"message": {"text": "warning with failure severity"},
+						"locations": [{"physicalLocation": {"artifactLocation": {"uri": "registry.io/repository/image@sha256:abc"}}}]
+					},
+					{
+						"ruleId": "release.pkg.rule1",
+						"ruleIndex": 0,
+						"level": "warning",
+						"message": {"text": "violation with warning severity"},
+						"locations": [{"physicalLocation": {"artifactLocation": {"uri": "registry.io/repository/other@sha256:def"}}}]
+					}
+				]
+			}
+		]
+	}`, string(data))
+}
+
+func TestMarshalNoResults(t *testing.T) {
+	data, err := Marshal("", nil)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"version": "2.1.0",
+		"$schema": "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/main/sarif-2.1/schema/sarif-schema-2.1.0.json",
+		"runs": [
+			{
+				"tool": {
+					"driver": {
+						"name": "Conforma",
+						"informationUri": "https://conforma.dev",
+						"rules": []
+					}
+				},
+				"results": []
+			}
+		]
+	}`, string(data))
+}
@@ -26,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	echttp "github.com/conforma/cli/internal/http"
+	ecoci "github.com/conforma/cli/internal/utils/oci"
 )
 
 type Client interface {
@@ -53,10 +54,21 @@ func NewClient(ctx context.Context) Client {
 type exoClient struct{}
 
 func (c exoClient) GetTektonObject(ctx context.Context, bundle, kind, name string) (o runtime.Object, err error) {
-	o, _, err = oci.NewResolver(bundle, nil).Get(ctx, kind, name)
+	// The Tekton resolver does not accept a transport, so the references from
+	// the registries configuration are tried in turn
+	refs, err := ecoci.RegistryEndpoints(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range refs {
+		if o, _, err = oci.NewResolver(ref, nil).Get(ctx, kind, name); err == nil {
+			return
+		}
+	}
 	return
 }
 
 func (c exoClient) GetImage(ctx context.Context, ref name.Reference) (v1.Image, error) {
-	return remote.Image(ref, remote.WithContext(ctx), remote.WithTransport(echttp.NewRetryTransport(remote.DefaultTransport)))
+	return remote.Image(ref, remote.WithContext(ctx), remote.WithTransport(echttp.NewRetryTransport(ecoci.NewRegistriesTransport(remote.DefaultTransport))))
 }
@@ -30,6 +30,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/types"
 
 	echttp "github.com/conforma/cli/internal/http"
+	ecoci "github.com/conforma/cli/internal/utils/oci"
 )
 
 const (
@@ -50,11 +51,12 @@ type registry interface {
 type containerRegistry struct{}
 
 func (containerRegistry) write(ref name.Reference, image v1.Image, options ...remote.Option) error {
+	options = append(options, remote.WithTransport(ecoci.NewRegistriesTransport(remote.DefaultTransport)))
 	return remote.Write(ref, image, options...)
 }
 
 func (containerRegistry) read(ref name.Reference, options ...remote.Option) (v1.Image, error) {
-	options = append(options, remote.WithTransport(echttp.NewRetryTransport(remote.DefaultTransport)))
+	options = append(options, remote.WithTransport(echttp.NewRetryTransport(ecoci.NewRegistriesTransport(remote.DefaultTransport))))
 	return remote.Image(ref, options...)
 }
 
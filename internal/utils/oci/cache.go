@@ -0,0 +1,511 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/trace"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The manifests and blobs fetched by digest are immutable, so they can be
+// cached on disk and reused across invocations. The cache is opt-in, enabled
+// by setting the cache directory via SetCacheConfig, and is implemented as a
+// transport, part of the remote options from CreateRemoteOptions. This way
+// it is used by the Client, the rego builtins and the signature and
+// attestation fetches alike.
+//
+// The cache is content-addressed, each entry is stored as:
+//
+//	<dir>/blobs/sha256/<hex>      the content
+//	<dir>/manifests/sha256/<hex>  the media type, for manifests only
+//
+// Note that the content is served from the cache without consulting the
+// registry, i.e. without authorization, the cache directory should not be
+// shared between users with different access to the registries.
+
+// DefaultCacheMaxSize is the default maximum size of the cache in bytes.
+const DefaultCacheMaxSize int64 = 5 << 30
+
+// CacheConfig holds the configuration of the persistent OCI content cache.
+type CacheConfig struct {
+	// Dir is the directory holding the cache, the cache is disabled if empty
+	Dir string
+	// MaxSize is the maximum size of the cached content in bytes, the least
+	// recently used entries are evicted when it is exceeded
+	MaxSize int64
+}
+
+// CacheStats holds the cache statistics of the current process.
+type CacheStats struct {
+	Hits      int64
+	Misses    int64
+	HitBytes  int64
+	Evictions int64
+}
+
+var diskCache atomic.Pointer[contentCache]
+
+// SetCacheConfig configures the persistent OCI content cache, an empty Dir
+// disables the cache.
+func SetCacheConfig(config CacheConfig) {
+	if config.Dir == "" {
+		diskCache.Store(nil)
+		return
+	}
+
+	if config.MaxSize <= 0 {
+		config.MaxSize = DefaultCacheMaxSize
+	}
+
+	diskCache.Store(&contentCache{
+		dir:     config.Dir,
+		maxSize: config.MaxSize,
+	})
+}
+
+// GetCacheConfig returns the current configuration of the persistent OCI
+// content cache.
+func GetCacheConfig() CacheConfig {
+	c := diskCache.Load()
+	if c == nil {
+		return CacheConfig{}
+	}
+
+	return CacheConfig{Dir: c.dir, MaxSize: c.maxSize}
+}
+
+// GetCacheStats returns the statistics of the persistent OCI content cache
+// and true, or false if the cache is disabled.
+func GetCacheStats() (CacheStats, bool) {
+	c := diskCache.Load()
+	if c == nil {
+		return CacheStats{}, false
+	}
+
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		HitBytes:  c.hitBytes.Load(),
+		Evictions: c.evictions.Load(),
+	}, true
+}
+
+// cacheableRequest matches the requests for content by its digest
+var cacheableRequest = regexp.MustCompile(`^/v2/.+/(blobs|manifests)/sha256:([a-f0-9]{64})$`)
+
+// maxRedirects matches the limit the http.Client uses
+const maxRedirects = 10
+
+// newCacheTransport returns the transport serving the content from the
+// persistent cache if the cache is enabled, or the next transport if not.
+func newCacheTransport(next http.RoundTripper) http.RoundTripper {
+	c := diskCache.Load()
+	if c == nil {
+		return next
+	}
+
+	return &cacheTransport{cache: c, next: next}
+}
+
+type cacheTransport struct {
+	cache *contentCache
+	next  http.RoundTripper
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == LocalRegistry || (req.Method != http.MethodGet && req.Method != http.MethodHead) || req.Header.Get("Range") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	m := cacheableRequest.FindStringSubmatch(req.URL.Path)
+	if m == nil {
+		return t.next.RoundTrip(req)
+	}
+	manifest := m[1] == "manifests"
+	digest := m[2]
+
+	if resp := t.cache.response(req, digest, manifest); resp != nil {
+		if req.Method == http.MethodGet {
+			t.cache.hits.Add(1)
+			t.cache.hitBytes.Add(resp.ContentLength)
+			if trace.IsEnabled() {
+				trace.Logf(req.Context(), "ec:oci-cache", "hit sha256:%s size=%d", digest, resp.ContentLength)
+			}
+		}
+		return resp, nil
+	}
+
+	if req.Method == http.MethodHead {
+		return t.next.RoundTrip(req)
+	}
+
+	t.cache.misses.Add(1)
+	if trace.IsEnabled() {
+		trace.Logf(req.Context(), "ec:oci-cache", "miss sha256:%s", digest)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Blobs are commonly served via a redirect to a storage service, follow
+	// the redirects here to be able to cache the content
+	if !manifest {
+		if resp, err = t.follow(req, resp); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK || resp.ContentLength > t.cache.maxSize {
+		return resp, nil
+	}
+
+	mediaType := ""
+	if manifest {
+		mediaType = resp.Header.Get("Content-Type")
+	}
+
+	resp.Body = t.cache.writer(digest, mediaType, resp.Body)
+
+	return resp, nil
+}
+
+func (t *cacheTransport) follow(req *http.Request, resp *http.Response) (*http.Response, error) {
+	for i := 0; i < maxRedirects; i++ {
+		switch resp.StatusCode {
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		default:
+			return resp, nil
+		}
+
+		location, err := resp.Location()
+		if err != nil {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		// the credentials for the registry are not passed on, same as the
+		// http.Client does when redirected to a different host
+		redirect, err := http.NewRequestWithContext(req.Context(), http.MethodGet, location.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		redirect.Header.Set("User-Agent", req.Header.Get("User-Agent"))
+
+		if resp, err = t.next.RoundTrip(redirect); err != nil {
+			return nil, err
+		}
+		req = redirect
+	}
+
+	return resp, nil
+}
+
+type cacheEntry struct {
+	size int64
+	used time.Time
+}
+
+type contentCache struct {
+	dir     string
+	maxSize int64
+
+	mu      sync.Mutex
+	loaded  bool
+	entries map[string]*cacheEntry
+	size    int64
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	hitBytes  atomic.Int64
+	evictions atomic.Int64
+}
+
+func (c *contentCache) blobPath(digest string) string {
+	return filepath.Join(c.dir, "blobs", "sha256", digest)
+}
+
+func (c *contentCache) mediaTypePath(digest string) string {
+	return filepath.Join(c.dir, "manifests", "sha256", digest)
+}
+
+// load builds the index of the cached content, must be called with the lock
+// held.
+func (c *contentCache) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	c.entries = map[string]*cacheEntry{}
+
+	dir := filepath.Join(c.dir, "blobs", "sha256")
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Debugf("unable to read the OCI cache directory %s: %v", dir, err)
+		}
+		return
+	}
+
+	for _, f := range files {
+		info, err := f.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		if strings.HasSuffix(f.Name(), ".tmp") {
+			// leftovers from interrupted downloads
+			if time.Since(info.ModTime()) > time.Hour {
+				_ = os.Remove(filepath.Join(dir, f.Name()))
+			}
+			continue
+		}
+
+		c.entries[f.Name()] = &cacheEntry{size: info.Size(), used: info.ModTime()}
+		c.size += info.Size()
+	}
+}
+
+// response returns the response with the cached content, or nil if the
+// content is not cached.
+func (c *contentCache) response(req *http.Request, digest string, manifest bool) *http.Response {
+	c.mu.Lock()
+	c.load()
+	e, ok := c.entries[digest]
+	if ok {
+		e.used = time.Now()
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	header := http.Header{}
+	header.Set("Docker-Content-Digest", "sha256:"+digest)
+	header.Set("Content-Length", strconv.FormatInt(e.size, 10))
+
+	if manifest {
+		mediaType, err := os.ReadFile(c.mediaTypePath(digest))
+		if err != nil {
+			// fetched as a blob before, but the media type is needed
+			return nil
+		}
+		header.Set("Content-Type", string(mediaType))
+	}
+
+	var body io.ReadCloser = http.NoBody
+	if req.Method == http.MethodGet {
+		f, err := os.Open(c.blobPath(digest))
+		if err != nil {
+			// evicted by another process
+			c.remove(digest)
+			return nil
+		}
+		body = f
+	}
+
+	// the modification time is used to find the least recently used entries
+	// when the cache is loaded again
+	now := time.Now()
+	_ = os.Chtimes(c.blobPath(digest), now, now)
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          body,
+		ContentLength: e.size,
+		Request:       req,
+	}
+}
+
+func (c *contentCache) remove(digest string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[digest]; ok {
+		c.size -= e.size
+		delete(c.entries, digest)
+	}
+}
+
+// writer returns a reader passing through the content from body and adding
+// it to the cache once completely read and verified.
+func (c *contentCache) writer(digest, mediaType string, body io.ReadCloser) io.ReadCloser {
+	dir := filepath.Dir(c.blobPath(digest))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Debugf("unable to create the OCI cache directory %s: %v", dir, err)
+		return body
+	}
+
+	f, err := os.CreateTemp(dir, digest+".*.tmp")
+	if err != nil {
+		log.Debugf("unable to create the OCI cache file: %v", err)
+		return body
+	}
+
+	return &cacheWriter{
+		cache:     c,
+		digest:    digest,
+		mediaType: mediaType,
+		body:      body,
+		file:      f,
+		hash:      sha256.New(),
+	}
+}
+
+type cacheWriter struct {
+	cache     *contentCache
+	digest    string
+	mediaType string
+	body      io.ReadCloser
+	file      *os.File
+	hash      hash.Hash
+	size      int64
+}
+
+func (w *cacheWriter) Read(p []byte) (int, error) {
+	n, err := w.body.Read(p)
+	if n > 0 && w.file != nil {
+		w.hash.Write(p[:n])
+		if _, werr := w.file.Write(p[:n]); werr != nil {
+			log.Debugf("unable to write the OCI cache file: %v", werr)
+			w.abandon()
+		}
+		w.size += int64(n)
+	}
+
+	if err == io.EOF && w.file != nil {
+		w.commit()
+	}
+
+	return n, err
+}
+
+func (w *cacheWriter) Close() error {
+	if w.file != nil {
+		// not read completely
+		w.abandon()
+	}
+
+	return w.body.Close()
+}
+
+func (w *cacheWriter) abandon() {
+	name := w.file.Name()
+	w.file.Close()
+	_ = os.Remove(name)
+	w.file = nil
+}
+
+func (w *cacheWriter) commit() {
+	if hex.EncodeToString(w.hash.Sum(nil)) != w.digest {
+		log.Debugf("content for sha256:%s does not match the digest, not caching", w.digest)
+		w.abandon()
+		return
+	}
+
+	name := w.file.Name()
+	if err := w.file.Close(); err != nil {
+		w.abandon()
+		return
+	}
+	w.file = nil
+
+	if w.mediaType != "" {
+		p := w.cache.mediaTypePath(w.digest)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err == nil {
+			_ = os.WriteFile(p, []byte(w.mediaType), 0o644)
+		}
+	}
+
+	if err := os.Rename(name, w.cache.blobPath(w.digest)); err != nil {
+		log.Debugf("unable to store the OCI cache file: %v", err)
+		_ = os.Remove(name)
+		return
+	}
+
+	w.cache.add(w.digest, w.size)
+}
+
+func (c *contentCache) add(digest string, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.load()
+	if e, ok := c.entries[digest]; ok {
+		c.size -= e.size
+	}
+	c.entries[digest] = &cacheEntry{size: size, used: time.Now()}
+	c.size += size
+
+	c.evict()
+}
+
+// evict removes the least recently used entries until the cache fits within
+// the maximum size, must be called with the lock held.
+func (c *contentCache) evict() {
+	if c.size <= c.maxSize {
+		return
+	}
+
+	digests := make([]string, 0, len(c.entries))
+	for d := range c.entries {
+		digests = append(digests, d)
+	}
+	sort.Slice(digests, func(i, j int) bool {
+		return c.entries[digests[i]].used.Before(c.entries[digests[j]].used)
+	})
+
+	for _, d := range digests {
+		if c.size <= c.maxSize {
+			break
+		}
+
+		if err := os.Remove(c.blobPath(d)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Debugf("unable to evict sha256:%s from the OCI cache: %v", d, err)
+			continue
+		}
+		_ = os.Remove(c.mediaTypePath(d))
+
+		c.size -= c.entries[d].size
+		delete(c.entries, d)
+		c.evictions.Add(1)
+	}
+}
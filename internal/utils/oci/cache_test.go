@@ -0,0 +1,318 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package oci
+
+import (
+	"context"
+	"io"
+	golog "log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRegistry records the paths of the requests made to the registry
+type countingRegistry struct {
+	mu       sync.Mutex
+	requests []string
+	handler  http.Handler
+}
+
+func (r *countingRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	r.requests = append(r.requests, req.Method+" "+req.URL.Path)
+	r.mu.Unlock()
+
+	r.handler.ServeHTTP(w, req)
+}
+
+func (r *countingRegistry) reset() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	requests := r.requests
+	r.requests = nil
+
+	return requests
+}
+
+func contentRequests(requests []string) []string {
+	content := []string{}
+	for _, r := range requests {
+		if cacheableRequest.MatchString(strings.TrimPrefix(strings.TrimPrefix(r, "GET "), "HEAD ")) {
+			content = append(content, r)
+		}
+	}
+
+	return content
+}
+
+func setupCache(t *testing.T, maxSize int64) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	SetCacheConfig(CacheConfig{Dir: dir, MaxSize: maxSize})
+	t.Cleanup(func() {
+		SetCacheConfig(CacheConfig{})
+	})
+
+	return dir
+}
+
+func pushRandomImage(t *testing.T, host, repository string) (name.Digest, v1.Image) {
+	t.Helper()
+
+	img, err := random.Image(1024, 2)
+	require.NoError(t, err)
+
+	ref, err := name.ParseReference(host + "/" + repository + ":latest")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+
+	digest, err := img.Digest()
+	require.NoError(t, err)
+
+	return ref.Context().Digest(digest.String()), img
+}
+
+func readImage(t *testing.T, ref name.Reference) {
+	t.Helper()
+
+	img, err := NewClient(context.Background()).Image(ref)
+	require.NoError(t, err)
+
+	_, err = img.RawConfigFile()
+	require.NoError(t, err)
+
+	layers, err := img.Layers()
+	require.NoError(t, err)
+	for _, layer := range layers {
+		rc, err := layer.Compressed()
+		require.NoError(t, err)
+		_, err = io.Copy(io.Discard, rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+	}
+}
+
+func imageSize(t *testing.T, img v1.Image) int64 {
+	t.Helper()
+
+	size, err := img.Size()
+	require.NoError(t, err)
+
+	config, err := img.RawConfigFile()
+	require.NoError(t, err)
+	size += int64(len(config))
+
+	layers, err := img.Layers()
+	require.NoError(t, err)
+	for _, layer := range layers {
+		s, err := layer.Size()
+		require.NoError(t, err)
+		size += s
+	}
+
+	return size
+}
+
+func TestCacheDisabled(t *testing.T) {
+	SetCacheConfig(CacheConfig{})
+
+	next := &localTransport{}
+	assert.Same(t, next, newCacheTransport(next))
+
+	_, ok := GetCacheStats()
+	assert.False(t, ok)
+	assert.Equal(t, CacheConfig{}, GetCacheConfig())
+}
+
+func TestCacheConfig(t *testing.T) {
+	dir := setupCache(t, 0)
+
+	assert.Equal(t, CacheConfig{Dir: dir, MaxSize: DefaultCacheMaxSize}, GetCacheConfig())
+}
+
+func TestCacheServesContent(t *testing.T) {
+	dir := setupCache(t, DefaultCacheMaxSize)
+
+	reg := &countingRegistry{handler: registry.New(registry.Logger(golog.New(io.Discard, "", 0)))}
+	server := httptest.NewServer(reg)
+	t.Cleanup(server.Close)
+
+	ref, img := pushRandomImage(t, strings.TrimPrefix(server.URL, "http://"), "repository/image")
+	reg.reset()
+
+	readImage(t, ref)
+	// manifest, config and two layers
+	assert.Len(t, contentRequests(reg.reset()), 4)
+
+	readImage(t, ref)
+	assert.Empty(t, contentRequests(reg.reset()))
+
+	// same content from a different repository
+	other, err := name.ParseReference(strings.Replace(ref.String(), "repository/image", "repository/other", 1))
+	require.NoError(t, err)
+	readImage(t, other)
+	assert.Empty(t, contentRequests(reg.reset()))
+
+	stats, ok := GetCacheStats()
+	require.True(t, ok)
+	assert.Equal(t, int64(4), stats.Misses)
+	assert.Equal(t, int64(8), stats.Hits)
+	assert.Positive(t, stats.HitBytes)
+	assert.Zero(t, stats.Evictions)
+
+	// the media type is kept for the manifests
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	mediaType, err := os.ReadFile(filepath.Join(dir, "manifests", "sha256", digest.Hex))
+	require.NoError(t, err)
+	expected, err := img.MediaType()
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(mediaType))
+
+	desc, err := NewClient(context.Background()).Head(ref)
+	require.NoError(t, err)
+	assert.Equal(t, digest, desc.Digest)
+	assert.Empty(t, contentRequests(reg.reset()))
+
+	// the cache is persistent, a new configuration loads the stored content
+	SetCacheConfig(CacheConfig{Dir: dir})
+	readImage(t, ref)
+	assert.Empty(t, contentRequests(reg.reset()))
+}
+
+func TestCacheFollowsRedirects(t *testing.T) {
+	dir := setupCache(t, DefaultCacheMaxSize)
+
+	storage := &countingRegistry{handler: registry.New(registry.Logger(golog.New(io.Discard, "", 0)))}
+	storageServer := httptest.NewServer(storage)
+	t.Cleanup(storageServer.Close)
+
+	ref, img := pushRandomImage(t, strings.TrimPrefix(storageServer.URL, "http://"), "repository/image")
+
+	// redirect the blob requests to the storage
+	reg := &countingRegistry{handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/blobs/") && r.Method == http.MethodGet {
+			http.Redirect(w, r, storageServer.URL+r.URL.Path, http.StatusTemporaryRedirect)
+			return
+		}
+		storage.handler.ServeHTTP(w, r)
+	})}
+	server := httptest.NewServer(reg)
+	t.Cleanup(server.Close)
+
+	redirected, err := name.ParseReference(strings.Replace(ref.String(), strings.TrimPrefix(storageServer.URL, "http://"), strings.TrimPrefix(server.URL, "http://"), 1))
+	require.NoError(t, err)
+	storage.reset()
+
+	readImage(t, redirected)
+	// config and two layers via the redirect
+	assert.Len(t, contentRequests(storage.reset()), 3)
+	assert.Len(t, contentRequests(reg.reset()), 4)
+
+	readImage(t, redirected)
+	assert.Empty(t, contentRequests(reg.reset()))
+	assert.Empty(t, contentRequests(storage.reset()))
+
+	layers, err := img.Layers()
+	require.NoError(t, err)
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		require.NoError(t, err)
+		assert.FileExists(t, filepath.Join(dir, "blobs", "sha256", digest.Hex))
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	reg := &countingRegistry{handler: registry.New(registry.Logger(golog.New(io.Discard, "", 0)))}
+	server := httptest.NewServer(reg)
+	t.Cleanup(server.Close)
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	first, firstImg := pushRandomImage(t, host, "repository/first")
+	second, secondImg := pushRandomImage(t, host, "repository/second")
+	reg.reset()
+
+	// room for a single image only
+	maxSize := max(imageSize(t, firstImg), imageSize(t, secondImg))
+	dir := setupCache(t, maxSize)
+
+	readImage(t, first)
+	readImage(t, second)
+
+	stats, ok := GetCacheStats()
+	require.True(t, ok)
+	assert.Positive(t, stats.Evictions)
+
+	var size int64
+	files, err := os.ReadDir(filepath.Join(dir, "blobs", "sha256"))
+	require.NoError(t, err)
+	for _, f := range files {
+		info, err := f.Info()
+		require.NoError(t, err)
+		size += info.Size()
+	}
+	assert.LessOrEqual(t, size, maxSize)
+
+	// the most recently used image is still cached, the first one is not
+	reg.reset()
+	readImage(t, second)
+	assert.Empty(t, contentRequests(reg.reset()))
+
+	readImage(t, first)
+	assert.NotEmpty(t, contentRequests(reg.reset()))
+}
+
+func TestCacheRejectsMismatchedContent(t *testing.T) {
+	dir := setupCache(t, DefaultCacheMaxSize)
+
+	digest := strings.Repeat("a", 64)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("not the expected content"))
+	}))
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/v2/repository/image/blobs/sha256:"+digest, nil)
+	require.NoError(t, err)
+
+	resp, err := newCacheTransport(http.DefaultTransport).RoundTrip(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, "not the expected content", string(body))
+
+	files, err := os.ReadDir(filepath.Join(dir, "blobs", "sha256"))
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
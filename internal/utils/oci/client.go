@@ -19,7 +19,6 @@ package oci
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"runtime/trace"
 
 	"github.com/google/go-containerregistry/pkg/authn"
@@ -57,12 +56,14 @@ func CreateRemoteOptions(ctx context.Context) []remote.Option {
 		)
 	}
 
-	// Create a transport that handles transient errors with exponential backoff
-	var transport http.RoundTripper
+	// Create a transport that handles transient errors with exponential backoff,
+	// serves images from local sources and content from the persistent cache,
+	// and applies the registries configuration
+	transport := newCacheTransport(NewRegistriesTransport(&localTransport{next: remote.DefaultTransport}))
 	if log.IsLevelEnabled(log.TraceLevel) {
-		transport = echttp.NewTracingRoundTripper(echttp.NewRetryTransport(remote.DefaultTransport))
+		transport = echttp.NewTracingRoundTripper(echttp.NewRetryTransport(transport))
 	} else {
-		transport = echttp.NewRetryTransport(remote.DefaultTransport)
+		transport = echttp.NewRetryTransport(transport)
 	}
 
 	return []remote.Option{
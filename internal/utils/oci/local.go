@@ -0,0 +1,554 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	golog "log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	log "github.com/sirupsen/logrus"
+)
+
+// Images can be provided from local sources, an OCI image layout directory or
+// a docker archive, as created by `docker save`, using references with these
+// prefixes:
+//
+//	oci-layout://<path>[@<digest>]
+//	docker-archive:<path>
+//
+// The contents of local sources are served by an in-process registry under
+// the LocalRegistry host. ResolveLocalSource returns the reference to the
+// image within that registry, which is used from that point on. This way the
+// signature and attestation verification and all of the rego builtins work
+// with local sources unchanged, via the remote options from
+// CreateRemoteOptions. The blobs are read from the local sources on demand,
+// and OriginalReference maps the references back for the output.
+const (
+	OCILayoutPrefix     = "oci-layout://"
+	DockerArchivePrefix = "docker-archive:"
+
+	// LocalRegistry is the host of the in-process registry serving the images
+	// from local sources.
+	LocalRegistry = "ec.local"
+)
+
+// Annotations cosign uses to mark the kind of the manifests in the OCI image
+// layouts it creates via `cosign save`.
+const (
+	cosignKindAnnotation       = "kind"
+	cosignImageAnnotation      = "dev.cosignproject.cosign/image"
+	cosignImageIndexAnnotation = "dev.cosignproject.cosign/imageIndex"
+	cosignSigsAnnotation       = "dev.cosignproject.cosign/sigs"
+	cosignAttsAnnotation       = "dev.cosignproject.cosign/atts"
+)
+
+var local = newLocalSources()
+
+// IsLocalSource returns true if the reference points to an image in a local
+// source.
+func IsLocalSource(ref string) bool {
+	return strings.HasPrefix(ref, OCILayoutPrefix) || strings.HasPrefix(ref, DockerArchivePrefix)
+}
+
+// ResolveLocalSource loads the image from the local source and returns its
+// digest reference within the LocalRegistry. Each local source is loaded once,
+// subsequent calls return the same reference.
+func ResolveLocalSource(ref string) (string, error) {
+	return local.resolve(ref)
+}
+
+// OriginalReference returns the reference to the local source, as provided to
+// ResolveLocalSource, for a reference to an image within the LocalRegistry.
+// Other images of an OCI image layout are referenced by their digest within
+// the layout. All other references are returned unchanged.
+func OriginalReference(ref string) string {
+	return local.original(ref)
+}
+
+type localSources struct {
+	mu       sync.Mutex
+	blobs    *localBlobs
+	handler  http.Handler
+	resolved map[string]string
+	// origins of the repositories within the LocalRegistry
+	origins map[string]origin
+}
+
+// origin is the local source served as a repository within the LocalRegistry
+type origin struct {
+	// ref is the reference provided to ResolveLocalSource
+	ref string
+	// path to the OCI image layout, empty for docker archives
+	layout string
+	digest string
+}
+
+func newLocalSources() *localSources {
+	blobs := &localBlobs{fallback: registry.NewInMemoryBlobHandler()}
+	return &localSources{
+		blobs: blobs,
+		handler: registry.New(
+			registry.WithBlobHandler(blobs),
+			registry.WithReferrersSupport(true),
+			registry.Logger(golog.New(io.Discard, "", 0)),
+		),
+		resolved: map[string]string{},
+		origins:  map[string]origin{},
+	}
+}
+
+func (l *localSources) resolve(ref string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if resolved, ok := l.resolved[ref]; ok {
+		return resolved, nil
+	}
+
+	var resolved name.Digest
+	var layout string
+	var err error
+	switch {
+	case strings.HasPrefix(ref, OCILayoutPrefix):
+		resolved, layout, err = l.loadOCILayout(strings.TrimPrefix(ref, OCILayoutPrefix))
+	case strings.HasPrefix(ref, DockerArchivePrefix):
+		resolved, err = l.loadDockerArchive(strings.TrimPrefix(ref, DockerArchivePrefix))
+	default:
+		err = fmt.Errorf("%q is not a reference to a local source", ref)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	log.Debugf("Serving %s as %s", ref, resolved)
+	l.resolved[ref] = resolved.String()
+	if _, ok := l.origins[resolved.Context().Name()]; !ok {
+		l.origins[resolved.Context().Name()] = origin{ref: ref, layout: layout, digest: resolved.DigestStr()}
+	}
+	return resolved.String(), nil
+}
+
+func (l *localSources) original(ref string) string {
+	r, err := name.ParseReference(ref)
+	if err != nil || r.Context().RegistryStr() != LocalRegistry {
+		return ref
+	}
+
+	l.mu.Lock()
+	o, ok := l.origins[r.Context().Name()]
+	l.mu.Unlock()
+	if !ok {
+		return ref
+	}
+
+	switch d, isDigest := r.(name.Digest); {
+	case !isDigest || d.DigestStr() == o.digest:
+		return o.ref
+	case o.layout != "":
+		return fmt.Sprintf("%s%s@%s", OCILayoutPrefix, o.layout, d.DigestStr())
+	}
+
+	return ref
+}
+
+// repository returns a stable repository within the LocalRegistry for the
+// local source at the given path.
+func (l *localSources) repository(kind, path string) (name.Repository, error) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	sum := sha256.Sum256([]byte(path))
+	return name.NewRepository(fmt.Sprintf("%s/%s/%x", LocalRegistry, kind, sum[:6]))
+}
+
+func (l *localSources) loadOCILayout(spec string) (name.Digest, string, error) {
+	path, digest := spec, ""
+	if i := strings.LastIndex(spec, "@"); i != -1 {
+		path, digest = spec[:i], spec[i+1:]
+	}
+
+	ref, err := l.loadOCILayoutImage(path, digest)
+
+	return ref, path, err
+}
+
+func (l *localSources) loadOCILayoutImage(path, digest string) (name.Digest, error) {
+	p, err := layout.FromPath(path)
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("reading OCI image layout %s: %w", path, err)
+	}
+
+	index, err := p.ImageIndex()
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("reading OCI image layout %s: %w", path, err)
+	}
+
+	indexManifest, err := index.IndexManifest()
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("reading OCI image layout %s: %w", path, err)
+	}
+
+	repo, err := l.repository("oci-layout", path)
+	if err != nil {
+		return name.Digest{}, err
+	}
+
+	// blobs are served directly from the layout
+	l.blobs.addDir(filepath.Join(path, "blobs"))
+
+	// the image signed by cosign in a layout created by `cosign save`, and the
+	// images that are candidates when no digest is given
+	var signed *v1.Hash
+	var candidates []v1.Hash
+	for _, d := range indexManifest.Manifests {
+		ref := repo.Digest(d.Digest.String())
+		// referrers are never candidates
+		var subject *v1.Descriptor
+		switch {
+		case d.MediaType.IsIndex():
+			ii, err := index.ImageIndex(d.Digest)
+			if err != nil {
+				return name.Digest{}, err
+			}
+			m, err := ii.IndexManifest()
+			if err != nil {
+				return name.Digest{}, err
+			}
+			subject = m.Subject
+			if err := remote.WriteIndex(ref, ii, l.options()...); err != nil {
+				return name.Digest{}, err
+			}
+		case d.MediaType.IsImage():
+			img, err := index.Image(d.Digest)
+			if err != nil {
+				return name.Digest{}, err
+			}
+			m, err := img.Manifest()
+			if err != nil {
+				return name.Digest{}, err
+			}
+			subject = m.Subject
+			if err := remote.Write(ref, img, l.options()...); err != nil {
+				return name.Digest{}, err
+			}
+		default:
+			log.Debugf("Ignoring %s with unsupported media type %s in OCI image layout %s", d.Digest, d.MediaType, path)
+			continue
+		}
+
+		if tag := d.Annotations[imagespec.AnnotationRefName]; tag != "" {
+			if err := l.tag(repo, tag, d.Digest); err != nil {
+				return name.Digest{}, err
+			}
+		}
+
+		switch d.Annotations[cosignKindAnnotation] {
+		case cosignImageAnnotation, cosignImageIndexAnnotation:
+			signed = &d.Digest
+			candidates = append(candidates, d.Digest)
+		case cosignSigsAnnotation, cosignAttsAnnotation:
+		default:
+			if subject == nil {
+				candidates = append(candidates, d.Digest)
+			}
+		}
+	}
+
+	// tag the signatures and attestations following the cosign tag convention
+	if signed != nil {
+		for _, d := range indexManifest.Manifests {
+			suffix := ""
+			switch d.Annotations[cosignKindAnnotation] {
+			case cosignSigsAnnotation:
+				suffix = "sig"
+			case cosignAttsAnnotation:
+				suffix = "att"
+			default:
+				continue
+			}
+
+			if err := l.tag(repo, fmt.Sprintf("%s-%s.%s", signed.Algorithm, signed.Hex, suffix), d.Digest); err != nil {
+				return name.Digest{}, err
+			}
+		}
+	}
+
+	if digest == "" {
+		if len(candidates) != 1 {
+			return name.Digest{}, fmt.Errorf("OCI image layout %s contains %d images, specify the image using %s<path>@<digest>", path, len(candidates), OCILayoutPrefix)
+		}
+		digest = candidates[0].String()
+	}
+
+	ref, err := name.NewDigest(fmt.Sprintf("%s@%s", repo, digest))
+	if err != nil {
+		return name.Digest{}, err
+	}
+
+	if _, err := remote.Head(ref, l.options()...); err != nil {
+		return name.Digest{}, fmt.Errorf("image %s not found in OCI image layout %s: %w", digest, path, err)
+	}
+
+	return ref, nil
+}
+
+func (l *localSources) loadDockerArchive(path string) (name.Digest, error) {
+	img, err := tarball.ImageFromPath(path, nil)
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("reading docker archive %s: %w", path, err)
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("reading docker archive %s: %w", path, err)
+	}
+
+	repo, err := l.repository("docker-archive", path)
+	if err != nil {
+		return name.Digest{}, err
+	}
+
+	// layers are served directly from the archive, only the manifest and the
+	// config are written to the registry
+	layers, err := img.Layers()
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("reading docker archive %s: %w", path, err)
+	}
+	for _, layer := range layers {
+		if err := l.blobs.addLayer(layer); err != nil {
+			return name.Digest{}, fmt.Errorf("reading docker archive %s: %w", path, err)
+		}
+	}
+
+	ref := repo.Digest(digest.String())
+	if err := remote.Write(ref, img, l.options()...); err != nil {
+		return name.Digest{}, err
+	}
+
+	return ref, nil
+}
+
+func (l *localSources) tag(repo name.Repository, tag string, digest v1.Hash) error {
+	desc, err := remote.Get(repo.Digest(digest.String()), l.options()...)
+	if err != nil {
+		return err
+	}
+
+	return remote.Tag(repo.Tag(tag), desc, l.options()...)
+}
+
+func (l *localSources) options() []remote.Option {
+	return []remote.Option{remote.WithTransport(&localTransport{})}
+}
+
+// localTransport serves the requests to the LocalRegistry from the in-process
+// registry and passes all other requests to the next transport.
+type localTransport struct {
+	next http.RoundTripper
+}
+
+func (t *localTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != LocalRegistry {
+		if t.next == nil {
+			return nil, fmt.Errorf("no transport for %s", req.URL.Host)
+		}
+		return t.next.RoundTrip(req)
+	}
+
+	// server requests always have a body, the registry handler relies on it
+	if req.Body == nil {
+		req = req.Clone(req.Context())
+		req.Body = http.NoBody
+	}
+
+	// the response body is streamed from the handler as it writes it
+	pr, pw := io.Pipe()
+	w := &pipeResponseWriter{header: http.Header{}, body: pw, ready: make(chan struct{})}
+	go func() {
+		local.handler.ServeHTTP(w, req)
+		w.WriteHeader(http.StatusOK)
+		pw.Close()
+	}()
+	<-w.ready
+
+	contentLength := int64(-1)
+	if l, err := strconv.ParseInt(w.sent.Get("Content-Length"), 10, 64); err == nil {
+		contentLength = l
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", w.status, http.StatusText(w.status)),
+		StatusCode:    w.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        w.sent,
+		Body:          pr,
+		ContentLength: contentLength,
+		Request:       req,
+	}, nil
+}
+
+// pipeResponseWriter writes the response body to a pipe, the response is
+// ready once the status has been written.
+type pipeResponseWriter struct {
+	header http.Header
+	body   *io.PipeWriter
+	once   sync.Once
+	ready  chan struct{}
+	// status and headers as written
+	status int
+	sent   http.Header
+}
+
+func (w *pipeResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *pipeResponseWriter) WriteHeader(status int) {
+	w.once.Do(func() {
+		w.status = status
+		w.sent = w.header.Clone()
+		close(w.ready)
+	})
+}
+
+func (w *pipeResponseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
+
+// localBlobs serves the blobs from the blobs directories of the loaded OCI
+// image layouts and the layers of the loaded docker archives, falling back to
+// an in-memory store for all other blobs.
+type localBlobs struct {
+	mu       sync.RWMutex
+	dirs     []string
+	layers   map[v1.Hash]v1.Layer
+	fallback registry.BlobHandler
+}
+
+func (b *localBlobs) addDir(dir string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dirs = append(b.dirs, dir)
+}
+
+func (b *localBlobs) addLayer(layer v1.Layer) error {
+	h, err := layer.Digest()
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.layers == nil {
+		b.layers = map[v1.Hash]v1.Layer{}
+	}
+	b.layers[h] = layer
+
+	return nil
+}
+
+func (b *localBlobs) layer(h v1.Hash) (v1.Layer, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	layer, ok := b.layers[h]
+	return layer, ok
+}
+
+func (b *localBlobs) path(h v1.Hash) (string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, dir := range b.dirs {
+		p := filepath.Join(dir, h.Algorithm, h.Hex)
+		if _, err := os.Stat(p); err == nil {
+			return p, true
+		}
+	}
+
+	return "", false
+}
+
+func (b *localBlobs) Get(ctx context.Context, repo string, h v1.Hash) (io.ReadCloser, error) {
+	if p, ok := b.path(h); ok {
+		return os.Open(p)
+	}
+
+	if layer, ok := b.layer(h); ok {
+		return layer.Compressed()
+	}
+
+	return b.fallback.Get(ctx, repo, h)
+}
+
+func (b *localBlobs) Stat(ctx context.Context, repo string, h v1.Hash) (int64, error) {
+	if p, ok := b.path(h); ok {
+		info, err := os.Stat(p)
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	}
+
+	if layer, ok := b.layer(h); ok {
+		return layer.Size()
+	}
+
+	if s, ok := b.fallback.(registry.BlobStatHandler); ok {
+		return s.Stat(ctx, repo, h)
+	}
+
+	rc, err := b.fallback.Get(ctx, repo, h)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	return io.Copy(io.Discard, rc)
+}
+
+func (b *localBlobs) Put(ctx context.Context, repo string, h v1.Hash, rc io.ReadCloser) error {
+	if p, ok := b.fallback.(registry.BlobPutHandler); ok {
+		return p.Put(ctx, repo, h, rc)
+	}
+
+	return errors.New("blob uploads are not supported")
+}
+
+var _ registry.BlobStatHandler = (*localBlobs)(nil)
+var _ registry.BlobPutHandler = (*localBlobs)(nil)
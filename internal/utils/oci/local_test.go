@@ -0,0 +1,230 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	ociremote "github.com/sigstore/cosign/v3/pkg/oci/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomImage(t *testing.T) v1.Image {
+	t.Helper()
+	img, err := random.Image(256, 2)
+	require.NoError(t, err)
+	return img
+}
+
+func digestOf(t *testing.T, img v1.Image) v1.Hash {
+	t.Helper()
+	d, err := img.Digest()
+	require.NoError(t, err)
+	return d
+}
+
+func TestIsLocalSource(t *testing.T) {
+	assert.True(t, IsLocalSource("oci-layout:///tmp/layout@sha256:abc"))
+	assert.True(t, IsLocalSource("docker-archive:image.tar"))
+	assert.False(t, IsLocalSource("registry.io/repository/image:tag"))
+	assert.False(t, IsLocalSource("oci-layout:image"))
+}
+
+func TestResolveLocalSourceOCILayout(t *testing.T) {
+	dir := t.TempDir()
+	p, err := layout.Write(dir, empty.Index)
+	require.NoError(t, err)
+
+	img := randomImage(t)
+	sig := randomImage(t)
+	att := randomImage(t)
+
+	imgDescriptor, err := partial.Descriptor(img)
+	require.NoError(t, err)
+	referrer, ok := mutate.Subject(randomImage(t), *imgDescriptor).(v1.Image)
+	require.True(t, ok)
+
+	require.NoError(t, p.AppendImage(img, layout.WithAnnotations(map[string]string{
+		cosignKindAnnotation:        cosignImageAnnotation,
+		imagespec.AnnotationRefName: "v1",
+	})))
+	require.NoError(t, p.AppendImage(sig, layout.WithAnnotations(map[string]string{cosignKindAnnotation: cosignSigsAnnotation})))
+	require.NoError(t, p.AppendImage(att, layout.WithAnnotations(map[string]string{cosignKindAnnotation: cosignAttsAnnotation})))
+	require.NoError(t, p.AppendImage(referrer))
+
+	imgDigest := digestOf(t, img)
+
+	resolved, err := ResolveLocalSource(fmt.Sprintf("oci-layout://%s@%s", dir, imgDigest))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(resolved, LocalRegistry+"/oci-layout/"))
+	assert.True(t, strings.HasSuffix(resolved, "@"+imgDigest.String()))
+
+	// without digest the only image is used
+	withoutDigest, err := ResolveLocalSource("oci-layout://" + dir)
+	require.NoError(t, err)
+	assert.Equal(t, resolved, withoutDigest)
+
+	ref, err := name.NewDigest(resolved)
+	require.NoError(t, err)
+
+	// reported by the reference to the layout
+	assert.Equal(t, fmt.Sprintf("oci-layout://%s@%s", dir, imgDigest), OriginalReference(resolved))
+	assert.Equal(t, fmt.Sprintf("oci-layout://%s@%s", dir, digestOf(t, sig)), OriginalReference(ref.Context().Digest(digestOf(t, sig).String()).String()))
+	assert.Equal(t, "registry.io/repository/image:tag", OriginalReference("registry.io/repository/image:tag"))
+	assert.Equal(t, LocalRegistry+"/unknown/image:tag", OriginalReference(LocalRegistry+"/unknown/image:tag"))
+
+	// accessed as any other image via the default client
+	client := NewClient(context.Background())
+
+	fetched, err := client.Image(ref)
+	require.NoError(t, err)
+	assert.Equal(t, imgDigest, digestOf(t, fetched))
+
+	layers, err := fetched.Layers()
+	require.NoError(t, err)
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		require.NoError(t, err)
+
+		fetchedLayer, err := client.Layer(ref.Context().Digest(digest.String()))
+		require.NoError(t, err)
+		_, err = fetchedLayer.Compressed()
+		require.NoError(t, err)
+	}
+
+	// tags from the layout and following the cosign convention
+	for tag, expected := range map[string]v1.Hash{
+		"v1": imgDigest,
+		fmt.Sprintf("sha256-%s.sig", imgDigest.Hex): digestOf(t, sig),
+		fmt.Sprintf("sha256-%s.att", imgDigest.Hex): digestOf(t, att),
+	} {
+		digest, err := client.ResolveDigest(ref.Context().Tag(tag))
+		require.NoError(t, err)
+		assert.Equal(t, expected.String(), digest, tag)
+	}
+
+	// referrers from the layout
+	referrers, err := ociremote.Referrers(ref, "", ociremote.WithRemoteOptions(CreateRemoteOptions(context.Background())...))
+	require.NoError(t, err)
+	require.Len(t, referrers.Manifests, 1)
+	assert.Equal(t, digestOf(t, referrer), referrers.Manifests[0].Digest)
+}
+
+func TestResolveLocalSourceDockerArchive(t *testing.T) {
+	img := randomImage(t)
+	archive := filepath.Join(t.TempDir(), "image.tar")
+	require.NoError(t, tarball.WriteToFile(archive, name.MustParseReference("registry.io/repository/image:tag"), img))
+
+	resolved, err := ResolveLocalSource("docker-archive:" + archive)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(resolved, LocalRegistry+"/docker-archive/"))
+	assert.Equal(t, "docker-archive:"+archive, OriginalReference(resolved))
+
+	ref, err := name.NewDigest(resolved)
+	require.NoError(t, err)
+
+	fetched, err := NewClient(context.Background()).Image(ref)
+	require.NoError(t, err)
+
+	expected, err := img.ConfigName()
+	require.NoError(t, err)
+	actual, err := fetched.ConfigName()
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	// the layers are read from the archive, not stored in memory
+	layers, err := fetched.Layers()
+	require.NoError(t, err)
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		require.NoError(t, err)
+
+		_, err = local.blobs.fallback.(registry.BlobStatHandler).Stat(context.Background(), "", digest)
+		assert.Error(t, err)
+
+		rc, err := layer.Compressed()
+		require.NoError(t, err)
+		h, _, err := v1.SHA256(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		assert.Equal(t, digest, h)
+	}
+}
+
+func TestResolveLocalSourceErrors(t *testing.T) {
+	multiple := t.TempDir()
+	p, err := layout.Write(multiple, empty.Index)
+	require.NoError(t, err)
+	require.NoError(t, p.AppendImage(randomImage(t)))
+	require.NoError(t, p.AppendImage(randomImage(t)))
+
+	cases := []struct {
+		name string
+		ref  string
+		err  string
+	}{
+		{
+			name: "missing layout",
+			ref:  "oci-layout://" + filepath.Join(t.TempDir(), "missing"),
+			err:  "reading OCI image layout",
+		},
+		{
+			name: "multiple images without digest",
+			ref:  "oci-layout://" + multiple,
+			err:  "contains 2 images, specify the image using oci-layout://<path>@<digest>",
+		},
+		{
+			name: "unknown digest",
+			ref:  fmt.Sprintf("oci-layout://%s@sha256:%s", multiple, strings.Repeat("0", 64)),
+			err:  "not found in OCI image layout",
+		},
+		{
+			name: "missing archive",
+			ref:  "docker-archive:" + filepath.Join(t.TempDir(), "missing.tar"),
+			err:  "reading docker archive",
+		},
+		{
+			name: "not a local source",
+			ref:  "registry.io/repository/image:tag",
+			err:  "is not a reference to a local source",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ResolveLocalSource(c.ref)
+			assert.ErrorContains(t, err, c.err)
+		})
+	}
+}
@@ -0,0 +1,531 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/BurntSushi/toml"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	log "github.com/sirupsen/logrus"
+)
+
+// The registries configuration rewrites the repository prefixes to different
+// locations and adds mirrors to try before the location, in the style of
+// containers-registries.conf(5), for example:
+//
+//	[[registry]]
+//	prefix = "quay.io/konflux-ci"
+//	location = "registry.internal/konflux-ci"
+//
+//	[[registry.mirror]]
+//	location = "mirror.internal/quay/konflux-ci"
+//	insecure = true
+//
+// The most specific prefix matching a repository applies. The mirrors are
+// tried in order for pulls, falling back to the location. Pushes always go to
+// the location.
+//
+// This is implemented as a transport, part of the remote options from
+// CreateRemoteOptions and of the transports of the policy and bundle
+// downloads. The transport authenticates the requests to the registries
+// named in the configuration itself, as the credentials needed depend on the
+// registry the request is sent to, and sends them via the next transport.
+
+// RegistriesConfig holds the registries configuration.
+type RegistriesConfig struct {
+	Registries []RegistryConfig `toml:"registry"`
+}
+
+// RegistryConfig holds the configuration of the repositories with the given
+// prefix.
+type RegistryConfig struct {
+	// Prefix the configuration applies to, a registry host, optionally
+	// followed by the repository path
+	Prefix string `toml:"prefix"`
+	// Location replaces the prefix, defaults to the prefix
+	Location string `toml:"location"`
+	// Insecure allows unencrypted HTTP connections to the location
+	Insecure bool `toml:"insecure"`
+	// Blocked denies any access to the repositories with the prefix
+	Blocked bool `toml:"blocked"`
+	// MirrorByDigestOnly limits the use of mirrors to pulls by digest
+	MirrorByDigestOnly bool `toml:"mirror-by-digest-only"`
+	// Mirrors replace the prefix, tried in order, before the location
+	Mirrors []MirrorConfig `toml:"mirror"`
+}
+
+// MirrorConfig holds the configuration of a mirror.
+type MirrorConfig struct {
+	Location string `toml:"location"`
+	Insecure bool   `toml:"insecure"`
+	// PullFromMirror is one of "all", "digest-only" or "tag-only"
+	PullFromMirror string `toml:"pull-from-mirror"`
+}
+
+const (
+	pullFromMirrorAll        = "all"
+	pullFromMirrorDigestOnly = "digest-only"
+	pullFromMirrorTagOnly    = "tag-only"
+)
+
+var registries atomic.Pointer[registriesRules]
+
+// LoadRegistriesConfig loads the registries configuration from the TOML file
+// at the given path, an empty path removes the configuration.
+func LoadRegistriesConfig(path string) error {
+	if path == "" {
+		registries.Store(nil)
+		return nil
+	}
+
+	var config RegistriesConfig
+	meta, err := toml.DecodeFile(path, &config)
+	if err != nil {
+		return fmt.Errorf("reading registries configuration %s: %w", path, err)
+	}
+
+	for _, k := range meta.Undecoded() {
+		log.Debugf("Ignoring unsupported registries configuration key %q in %s", k.String(), path)
+	}
+
+	return SetRegistriesConfig(config)
+}
+
+// SetRegistriesConfig validates and applies the registries configuration.
+func SetRegistriesConfig(config RegistriesConfig) error {
+	if len(config.Registries) == 0 {
+		registries.Store(nil)
+		return nil
+	}
+
+	rules, err := newRegistriesRules(config)
+	if err != nil {
+		return err
+	}
+
+	registries.Store(rules)
+
+	return nil
+}
+
+// NewRegistriesTransport returns the transport applying the registries
+// configuration to the requests, or the next transport if there is no
+// registries configuration.
+func NewRegistriesTransport(next http.RoundTripper) http.RoundTripper {
+	rules := registries.Load()
+	if rules == nil {
+		return next
+	}
+
+	return &registriesTransport{rules: rules, next: next}
+}
+
+// RegistryEndpoints returns the references to try, in order, for pulling the
+// given reference according to the registries configuration. Meant for
+// clients that cannot use the transport from NewRegistriesTransport.
+func RegistryEndpoints(ref string) ([]string, error) {
+	rules := registries.Load()
+	if rules == nil {
+		return []string{ref}, nil
+	}
+
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	repository := r.Context().Name()
+	_, byDigest := r.(name.Digest)
+
+	rule := rules.match(repository)
+	if rule == nil {
+		return []string{ref}, nil
+	}
+	if rule.blocked {
+		return nil, rule.blockedError()
+	}
+
+	separator := ":"
+	if byDigest {
+		separator = "@"
+	}
+
+	refs := []string{}
+	for _, e := range rule.endpoints(true, byDigest) {
+		refs = append(refs, e.rewrite(rule.prefix, repository)+separator+r.Identifier())
+	}
+
+	return refs, nil
+}
+
+type endpoint struct {
+	location string
+	insecure bool
+}
+
+// registry returns the registry at the given host of the endpoint, using
+// plain HTTP if the endpoint is insecure or the registry is local
+func (e endpoint) registry(host string) name.Registry {
+	var opts []name.Option
+	if e.insecure {
+		opts = append(opts, name.Insecure)
+	}
+
+	// the hosts are validated when the configuration is loaded
+	reg, _ := name.NewRegistry(host, opts...)
+	return reg
+}
+
+// rewrite replaces the prefix of the repository with the location
+func (e endpoint) rewrite(prefix, repository string) string {
+	return e.location + strings.TrimPrefix(repository, prefix)
+}
+
+type registryRule struct {
+	prefix   string
+	blocked  bool
+	location endpoint
+	mirrors  []endpoint
+	// pull policies of mirrors, one of the pullFromMirror constants
+	policies []string
+}
+
+func (r *registryRule) blockedError() error {
+	return fmt.Errorf("access to %s is blocked by the registries configuration", r.prefix)
+}
+
+// endpoints returns the endpoints to use, mirrors are used only for pulls
+func (r *registryRule) endpoints(pull, byDigest bool) []endpoint {
+	if !pull {
+		return []endpoint{r.location}
+	}
+
+	endpoints := make([]endpoint, 0, len(r.mirrors)+1)
+	for i, m := range r.mirrors {
+		switch r.policies[i] {
+		case pullFromMirrorDigestOnly:
+			if !byDigest {
+				continue
+			}
+		case pullFromMirrorTagOnly:
+			if byDigest {
+				continue
+			}
+		}
+		endpoints = append(endpoints, m)
+	}
+
+	return append(endpoints, r.location)
+}
+
+type registriesRules struct {
+	rules []*registryRule
+	// hosts holds all registry hosts named in the configuration
+	hosts map[string]bool
+}
+
+func newRegistriesRules(config RegistriesConfig) (*registriesRules, error) {
+	rules := &registriesRules{hosts: map[string]bool{}}
+
+	seen := map[string]bool{}
+	for _, c := range config.Registries {
+		prefix := strings.TrimSuffix(c.Prefix, "/")
+		if prefix == "" {
+			prefix = strings.TrimSuffix(c.Location, "/")
+		}
+		if prefix == "" {
+			return nil, errors.New("registries configuration: registry entry without prefix or location")
+		}
+		if strings.Contains(prefix, "*") {
+			return nil, fmt.Errorf("registries configuration: wildcard prefix %q is not supported", prefix)
+		}
+		if seen[prefix] {
+			return nil, fmt.Errorf("registries configuration: duplicate prefix %q", prefix)
+		}
+		seen[prefix] = true
+
+		location := strings.TrimSuffix(c.Location, "/")
+		if location == "" {
+			location = prefix
+		}
+
+		rule := &registryRule{
+			prefix:   prefix,
+			blocked:  c.Blocked,
+			location: endpoint{location: location, insecure: c.Insecure},
+		}
+
+		for _, m := range c.Mirrors {
+			if m.Location == "" {
+				return nil, fmt.Errorf("registries configuration: mirror of %q without location", prefix)
+			}
+
+			policy := m.PullFromMirror
+			switch policy {
+			case "":
+				policy = pullFromMirrorAll
+				if c.MirrorByDigestOnly {
+					policy = pullFromMirrorDigestOnly
+				}
+			case pullFromMirrorAll, pullFromMirrorDigestOnly, pullFromMirrorTagOnly:
+			default:
+				return nil, fmt.Errorf("registries configuration: unsupported pull-from-mirror value %q for mirror %q", policy, m.Location)
+			}
+
+			rule.mirrors = append(rule.mirrors, endpoint{location: strings.TrimSuffix(m.Location, "/"), insecure: m.Insecure})
+			rule.policies = append(rule.policies, policy)
+		}
+
+		for _, l := range append([]endpoint{{location: prefix}, rule.location}, rule.mirrors...) {
+			if _, err := name.NewRegistry(registryHost(l.location)); err != nil {
+				return nil, fmt.Errorf("registries configuration: invalid location %q: %w", l.location, err)
+			}
+			rules.hosts[registryHost(l.location)] = true
+		}
+
+		rules.rules = append(rules.rules, rule)
+	}
+
+	return rules, nil
+}
+
+func registryHost(location string) string {
+	host, _, _ := strings.Cut(location, "/")
+	return host
+}
+
+// match returns the rule with the longest prefix matching the repository, or
+// nil if no rule matches.
+func (r *registriesRules) match(repository string) *registryRule {
+	var matched *registryRule
+	for _, rule := range r.rules {
+		if repository != rule.prefix && !strings.HasPrefix(repository, rule.prefix+"/") {
+			continue
+		}
+		if matched == nil || len(rule.prefix) > len(matched.prefix) {
+			matched = rule
+		}
+	}
+
+	return matched
+}
+
+// forHost returns the rule with the shortest prefix on the registry host, or
+// nil if no rule has a prefix on the host.
+func (r *registriesRules) forHost(host string) *registryRule {
+	var matched *registryRule
+	for _, rule := range r.rules {
+		if registryHost(rule.prefix) != host {
+			continue
+		}
+		if matched == nil || len(rule.prefix) < len(matched.prefix) {
+			matched = rule
+		}
+	}
+
+	return matched
+}
+
+// registryRequest matches the requests for repository content
+var registryRequest = regexp.MustCompile(`^/v2/(.+)/(manifests|blobs|tags|referrers)/(.*)$`)
+
+type registriesTransport struct {
+	rules *registriesRules
+	next  http.RoundTripper
+
+	// authenticating transports by endpoint and scope
+	transports sync.Map
+}
+
+// transport returns the transport authenticating to the repository, or to the
+// registry if the repository is only a host, on top of the next transport
+func (t *registriesTransport) transport(req *http.Request, e endpoint, repository string, pull bool) (http.RoundTripper, error) {
+	scope := transport.PullScope
+	if !pull {
+		scope = transport.PushScope
+	}
+
+	key := fmt.Sprintf("%s|%t|%s", repository, e.insecure, scope)
+	if rt, ok := t.transports.Load(key); ok {
+		return rt.(http.RoundTripper), nil
+	}
+
+	host, path, _ := strings.Cut(repository, "/")
+	reg := e.registry(host)
+
+	var scopes []string
+	if path != "" {
+		scopes = []string{reg.Repo(path).Scope(scope)}
+	}
+
+	auth, err := authn.DefaultKeychain.Resolve(reg)
+	if err != nil {
+		return nil, err
+	}
+
+	rt, err := transport.NewWithContext(req.Context(), reg, auth, t.next, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, _ := t.transports.LoadOrStore(key, rt)
+
+	return stored.(http.RoundTripper), nil
+}
+
+func (t *registriesTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.rules.hosts[req.URL.Host] {
+		return t.next.RoundTrip(req)
+	}
+
+	// The authentication is handled here, the clients are answered the ping
+	// of the first endpoint that accepts the credentials from the keychain
+	if req.URL.Path == "/v2/" || req.URL.Path == "/v2" {
+		return t.ping(req)
+	}
+
+	m := registryRequest.FindStringSubmatch(req.URL.Path)
+	if m == nil {
+		return t.next.RoundTrip(req)
+	}
+	repository := req.URL.Host + "/" + m[1]
+	kind, rest := m[2], m[3]
+
+	pull := req.Method == http.MethodGet || req.Method == http.MethodHead
+	byDigest := kind != "manifests" || strings.HasPrefix(rest, "sha256:")
+
+	endpoints := []endpoint{{location: req.URL.Host, insecure: req.URL.Scheme == "http"}}
+	prefix := req.URL.Host
+	if rule := t.rules.match(repository); rule != nil {
+		if rule.blocked {
+			return nil, rule.blockedError()
+		}
+		endpoints = rule.endpoints(pull, byDigest)
+		prefix = rule.prefix
+	}
+
+	return tryEndpoints(endpoints, func(e endpoint) (*http.Response, error) {
+		return t.send(req, e, e.rewrite(prefix, repository), kind, rest, pull)
+	})
+}
+
+// ping sends the ping to the endpoints of the registry, for the clients to
+// see if they are reachable
+func (t *registriesTransport) ping(req *http.Request) (*http.Response, error) {
+	endpoints := []endpoint{{location: req.URL.Host, insecure: req.URL.Scheme == "http"}}
+	if rule := t.rules.forHost(req.URL.Host); rule != nil {
+		switch {
+		case !rule.blocked:
+			endpoints = append(append([]endpoint{}, rule.mirrors...), rule.location)
+		case rule.prefix == req.URL.Host:
+			return nil, rule.blockedError()
+		}
+	}
+
+	return tryEndpoints(endpoints, func(e endpoint) (*http.Response, error) {
+		host := registryHost(e.location)
+
+		rt, err := t.transport(req, e, host, true)
+		if err != nil {
+			return nil, err
+		}
+
+		r := req.Clone(req.Context())
+		r.Header.Del("Authorization")
+		r.Host = ""
+		r.URL.Host = host
+		r.URL.Scheme = e.registry(host).Scheme()
+
+		return rt.RoundTrip(r)
+	})
+}
+
+// tryEndpoints sends the request to the endpoints in order, until one does not
+// fail, returning the response of the last endpoint otherwise
+func tryEndpoints(endpoints []endpoint, send func(endpoint) (*http.Response, error)) (*http.Response, error) {
+	var resp *http.Response
+	var err error
+	for i, e := range endpoints {
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		resp, err = send(e)
+		if i == len(endpoints)-1 {
+			break
+		}
+
+		if err != nil {
+			log.Debugf("Request to %s failed, trying the next endpoint: %v", e.location, err)
+			continue
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden, resp.StatusCode >= 500:
+			log.Debugf("Request to %s failed with status %d, trying the next endpoint", e.location, resp.StatusCode)
+			continue
+		}
+
+		break
+	}
+
+	return resp, err
+}
+
+func (t *registriesTransport) send(req *http.Request, e endpoint, repository, kind, rest string, pull bool) (*http.Response, error) {
+	host, path, _ := strings.Cut(repository, "/")
+
+	rt, err := t.transport(req, e, repository, pull)
+	if err != nil {
+		return nil, err
+	}
+
+	r := req.Clone(req.Context())
+	r.Header.Del("Authorization")
+	r.Host = ""
+	r.URL.Host = host
+	r.URL.Scheme = e.registry(host).Scheme()
+	r.URL.Path = "/v2/" + path + "/" + kind + "/" + rest
+	r.URL.RawPath = ""
+
+	if r.URL.String() != req.URL.String() {
+		log.Tracef("Registries configuration rewrote %s to %s", req.URL, r.URL)
+	}
+
+	resp, err := rt.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// the clients resolve relative redirects against the original request
+	if l := resp.Header.Get("Location"); l != "" {
+		if loc, err := r.URL.Parse(l); err == nil {
+			resp.Header.Set("Location", loc.String())
+		}
+	}
+
+	return resp, nil
+}
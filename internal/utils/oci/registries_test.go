@@ -0,0 +1,327 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package oci
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	golog "log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRegistriesConfig(t *testing.T, config string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "registries.conf")
+	require.NoError(t, os.WriteFile(path, []byte(config), 0o600))
+
+	require.NoError(t, LoadRegistriesConfig(path))
+	t.Cleanup(func() {
+		require.NoError(t, LoadRegistriesConfig(""))
+	})
+}
+
+func newTestRegistry(t *testing.T) (*countingRegistry, string) {
+	t.Helper()
+
+	reg := &countingRegistry{handler: registry.New(registry.Logger(golog.New(io.Discard, "", 0)))}
+	server := httptest.NewServer(reg)
+	t.Cleanup(server.Close)
+
+	return reg, strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestLoadRegistriesConfigErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name:   "malformed",
+			config: "[[registry]",
+			err:    "reading registries configuration",
+		},
+		{
+			name:   "no prefix or location",
+			config: "[[registry]]\ninsecure = true",
+			err:    "registry entry without prefix or location",
+		},
+		{
+			name:   "wildcard",
+			config: "[[registry]]\nprefix = \"*.example.io\"",
+			err:    `wildcard prefix "*.example.io" is not supported`,
+		},
+		{
+			name:   "duplicate",
+			config: "[[registry]]\nprefix = \"example.io\"\n[[registry]]\nprefix = \"example.io/\"",
+			err:    `duplicate prefix "example.io"`,
+		},
+		{
+			name:   "mirror without location",
+			config: "[[registry]]\nprefix = \"example.io\"\n[[registry.mirror]]\ninsecure = true",
+			err:    `mirror of "example.io" without location`,
+		},
+		{
+			name:   "unsupported pull-from-mirror",
+			config: "[[registry]]\nprefix = \"example.io\"\n[[registry.mirror]]\nlocation = \"mirror.io\"\npull-from-mirror = \"sometimes\"",
+			err:    `unsupported pull-from-mirror value "sometimes"`,
+		},
+		{
+			name:   "invalid location",
+			config: "[[registry]]\nprefix = \"example.io\"\nlocation = \"in valid/repo\"",
+			err:    `invalid location "in valid/repo"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "registries.conf")
+			require.NoError(t, os.WriteFile(path, []byte(c.config), 0o600))
+
+			assert.ErrorContains(t, LoadRegistriesConfig(path), c.err)
+		})
+	}
+
+	assert.ErrorContains(t, LoadRegistriesConfig(filepath.Join(t.TempDir(), "missing.conf")), "reading registries configuration")
+}
+
+func TestRegistryEndpoints(t *testing.T) {
+	writeRegistriesConfig(t, `
+unqualified-search-registries = ["docker.io"]
+
+[[registry]]
+prefix = "quay.io/org"
+location = "registry.internal/quay/org"
+
+[[registry.mirror]]
+location = "mirror.internal/org"
+pull-from-mirror = "digest-only"
+
+[[registry.mirror]]
+location = "tags.internal/org"
+pull-from-mirror = "tag-only"
+
+[[registry]]
+prefix = "quay.io/org/special"
+mirror-by-digest-only = true
+
+[[registry.mirror]]
+location = "special.internal"
+
+[[registry]]
+prefix = "blocked.io"
+blocked = true
+`)
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	cases := []struct {
+		ref      string
+		expected []string
+		err      string
+	}{
+		{
+			ref:      "registry.io/repository/image:tag",
+			expected: []string{"registry.io/repository/image:tag"},
+		},
+		{
+			ref:      "quay.io/org/image:tag",
+			expected: []string{"tags.internal/org/image:tag", "registry.internal/quay/org/image:tag"},
+		},
+		{
+			ref:      "quay.io/org/image@" + digest,
+			expected: []string{"mirror.internal/org/image@" + digest, "registry.internal/quay/org/image@" + digest},
+		},
+		{
+			// not matching at the path boundary
+			ref:      "quay.io/organization/image:tag",
+			expected: []string{"quay.io/organization/image:tag"},
+		},
+		{
+			// the most specific prefix applies
+			ref:      "quay.io/org/special/image:tag",
+			expected: []string{"quay.io/org/special/image:tag"},
+		},
+		{
+			ref:      "quay.io/org/special/image@" + digest,
+			expected: []string{"special.internal/image@" + digest, "quay.io/org/special/image@" + digest},
+		},
+		{
+			ref: "blocked.io/image:tag",
+			err: "access to blocked.io is blocked by the registries configuration",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.ref, func(t *testing.T) {
+			refs, err := RegistryEndpoints(c.ref)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, refs)
+		})
+	}
+}
+
+func TestRegistriesTransportMirrors(t *testing.T) {
+	// the first mirror does not have the image
+	emptyMirror, emptyHost := newTestRegistry(t)
+	mirror, mirrorHost := newTestRegistry(t)
+
+	ref, _ := pushRandomImage(t, mirrorHost, "mirror/repository/image")
+	mirror.reset()
+
+	writeRegistriesConfig(t, fmt.Sprintf(`
+[[registry]]
+prefix = "registry.invalid/repository"
+
+[[registry.mirror]]
+location = "%s/empty"
+
+[[registry.mirror]]
+location = "%s/mirror/repository"
+`, emptyHost, mirrorHost))
+
+	original, err := name.NewDigest("registry.invalid/repository/image@" + ref.DigestStr())
+	require.NoError(t, err)
+
+	readImage(t, original)
+
+	assert.Contains(t, emptyMirror.reset(), "GET /v2/empty/image/manifests/"+ref.DigestStr())
+	assert.Contains(t, mirror.reset(), "GET /v2/mirror/repository/image/manifests/"+ref.DigestStr())
+
+	// the location is not reachable, and no mirror has the tag
+	_, err = NewClient(context.Background()).Image(original.Context().Tag("missing"))
+	assert.Error(t, err)
+}
+
+func TestRegistriesTransportRewrite(t *testing.T) {
+	reg, host := newTestRegistry(t)
+
+	ref, _ := pushRandomImage(t, host, "rewritten/image")
+	reg.reset()
+
+	writeRegistriesConfig(t, fmt.Sprintf(`
+[[registry]]
+prefix = "registry.invalid"
+location = "%s/rewritten"
+
+[[registry]]
+prefix = "blocked.invalid"
+blocked = true
+`, host))
+
+	client := NewClient(context.Background())
+
+	// tags are resolved via the location
+	digest, err := client.ResolveDigest(name.MustParseReference("registry.invalid/image:latest"))
+	require.NoError(t, err)
+	assert.Equal(t, ref.DigestStr(), digest)
+	assert.Contains(t, reg.reset(), "GET /v2/rewritten/image/manifests/latest")
+
+	_, err = client.Head(name.MustParseReference("blocked.invalid/image:latest"))
+	assert.ErrorContains(t, err, "access to blocked.invalid is blocked by the registries configuration")
+}
+
+// recordingTransport records the URLs of the requests sent through it
+type recordingTransport struct {
+	mu       sync.Mutex
+	requests []string
+	next     http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.requests = append(t.requests, req.Method+" "+req.URL.String())
+	t.mu.Unlock()
+
+	return t.next.RoundTrip(req)
+}
+
+func TestRegistriesTransportMirrorAuth(t *testing.T) {
+	reg, host := newTestRegistry(t)
+
+	ref, _ := pushRandomImage(t, host, "mirror/image")
+	reg.reset()
+
+	// the mirror requires the credentials from the keychain, the client
+	// reading the image is anonymous
+	handler := reg.handler
+	reg.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="mirror"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+
+	dockerConfig := t.TempDir()
+	auth := base64.StdEncoding.EncodeToString([]byte("user:secret"))
+	require.NoError(t, os.WriteFile(filepath.Join(dockerConfig, "config.json"), []byte(fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, host, auth)), 0o600))
+	t.Setenv("DOCKER_CONFIG", dockerConfig)
+
+	writeRegistriesConfig(t, fmt.Sprintf(`
+[[registry]]
+prefix = "registry.invalid"
+
+[[registry.mirror]]
+location = "%s/mirror"
+`, host))
+
+	original, err := name.NewDigest("registry.invalid/image@" + ref.DigestStr())
+	require.NoError(t, err)
+
+	next := &recordingTransport{next: http.DefaultTransport}
+	img, err := remote.Image(original, remote.WithTransport(NewRegistriesTransport(next)))
+	require.NoError(t, err)
+
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	assert.Equal(t, ref.DigestStr(), digest.String())
+
+	_, err = img.RawConfigFile()
+	require.NoError(t, err)
+
+	requests := reg.reset()
+	assert.Contains(t, requests, "GET /v2/")
+	assert.Contains(t, requests, "GET /v2/mirror/image/manifests/"+ref.DigestStr())
+	// the requests to the mirror go through the next transport
+	for _, r := range requests {
+		method, path, _ := strings.Cut(r, " ")
+		assert.Contains(t, next.requests, method+" http://"+host+path)
+	}
+}
@@ -38,7 +38,8 @@ import (
 
 // Predicate type URL
 const (
-	PredicateType = "https://conforma.dev/verification_summary/v1"
+	PredicateType     = "https://conforma.dev/verification_summary/v1"
+	SLSAPredicateType = "https://slsa.dev/verification_summary/v1"
 )
 
 // LoadPrivateKey is aliased to allow easy testing.
@@ -46,7 +47,7 @@ var LoadPrivateKey = cosign.LoadPrivateKey
 
 type Attestor struct {
 	PredicatePath string  // path to the raw VSA (predicate) JSON
-	PredicateType string  // e.g. "https://enterprisecontract.dev/attestations/vsa/v1" // TODO: make this configurable
+	PredicateType string  // PredicateType or SLSAPredicateType
 	Digest        string  // sha256:abcd…  (as returned by `skopeo inspect --format {{.Digest}}`)
 	Repo          string  // "quay.io/acme/widget" (hostname/namespace/repo)
 	Signer        *Signer // Signer is the signer used to sign the VSA
@@ -89,6 +89,45 @@ func (f *FileVSARetriever) RetrieveVSA(ctx context.Context, identifier string) (
 	return envelope, nil
 }
 
+// ListVSAs retrieves the DSSE envelopes of all JSON files in the base path,
+// such as those saved by the local storage backend. Files that do not hold a
+// DSSE envelope are skipped. The identifier is not used to select the files,
+// the envelopes are expected to be matched against it by the caller.
+func (f *FileVSARetriever) ListVSAs(ctx context.Context, identifier string) ([]*ssldsse.Envelope, error) {
+	basePath := f.basePath
+	if basePath == "" {
+		basePath = "."
+	}
+
+	files, err := afero.ReadDir(f.fs, basePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read VSA directory %s: %w", basePath, err)
+	}
+
+	var envelopes []*ssldsse.Envelope
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+
+		filePath := filepath.Join(basePath, file.Name())
+		data, err := afero.ReadFile(f.fs, filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read VSA file: %w", err)
+		}
+
+		envelope, err := f.parseDSSEEnvelope(data)
+		if err != nil {
+			log.Debugf("Skipping %s: %v", filePath, err)
+			continue
+		}
+		envelopes = append(envelopes, envelope)
+	}
+
+	log.Debugf("Retrieved %d DSSE envelopes from %s", len(envelopes), basePath)
+	return envelopes, nil
+}
+
 // resolveFilePath determines the full file path from the identifier
 func (f *FileVSARetriever) resolveFilePath(identifier string) string {
 	// If it's an absolute path, use it directly
@@ -0,0 +1,251 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vsa
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	log "github.com/sirupsen/logrus"
+)
+
+// Signature validity of the listed VSAs
+const (
+	SignatureValid      = "valid"
+	SignatureInvalid    = "invalid"
+	SignatureUnverified = "unverified"
+)
+
+// VSARecord summarizes a VSA recorded for an image
+type VSARecord struct {
+	Digest       string    `json:"digest"`
+	Timestamp    time.Time `json:"timestamp"`
+	Status       string    `json:"status"`
+	Verifier     string    `json:"verifier"`
+	PolicyDigest string    `json:"policyDigest"`
+	Signature    string    `json:"signature"`
+	Source       string    `json:"source"`
+	Revoked      bool      `json:"revoked"`
+}
+
+// ListOptions selects the VSAs to list
+type ListOptions struct {
+	// Since excludes the VSAs older than the given time, if set
+	Since time.Time
+	// Until excludes the VSAs newer than the given time, if set
+	Until time.Time
+	// Status excludes the VSAs with a different status, if set
+	Status string
+	// PublicKey is used to verify the signatures of the VSAs, if set
+	PublicKey string
+}
+
+// vsaSource is a VSA lister along with the backend it was configured from
+type vsaSource struct {
+	name   string
+	lister VSALister
+}
+
+// CreateListerFromFlag creates the VSA lister for the backend in the given
+// flag, in the same format as the --vsa-upload flag. The local and file
+// backends list the VSAs saved in the directory.
+func CreateListerFromFlag(flag string) (VSALister, error) {
+	// file is a retrieval only backend, not accepted by ParseStorageFlag
+	backend, basePath, _ := strings.Cut(flag, "@")
+	switch strings.ToLower(backend) {
+	case "local":
+		if basePath == "" {
+			basePath = "./vsa-upload"
+		}
+		return NewFileVSARetrieverWithOSFs(basePath), nil
+	case "file":
+		if basePath == "" {
+			basePath = "."
+		}
+		return NewFileVSARetrieverWithOSFs(basePath), nil
+	}
+
+	config, err := ParseStorageFlag(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	retriever := CreateRetrieverFromUploadFlags([]string{flag})
+	if retriever == nil {
+		return nil, fmt.Errorf("no VSA retriever available for %s", flag)
+	}
+
+	lister, ok := retriever.(VSALister)
+	if !ok {
+		return nil, fmt.Errorf("listing VSAs is not supported by the %s backend", config.Backend)
+	}
+
+	return lister, nil
+}
+
+// ListVSARecords lists the VSAs recorded for the image digest in the
+// identifier by each of the backends in the flags, newest first
+func ListVSARecords(ctx context.Context, flags []string, identifier string, opts ListOptions) ([]VSARecord, error) {
+	sources := make([]vsaSource, 0, len(flags))
+	for _, flag := range flags {
+		lister, err := CreateListerFromFlag(flag)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, vsaSource{name: flag, lister: lister})
+	}
+
+	return listVSARecords(ctx, sources, identifier, opts)
+}
+
+func listVSARecords(ctx context.Context, sources []vsaSource, identifier string, opts ListOptions) ([]VSARecord, error) {
+	digest, err := extractImageDigest(identifier)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, hash, _ := strings.Cut(digest, ":")
+
+	// revocations may be recorded by any of the sources
+	listed := make([][]*ssldsse.Envelope, 0, len(sources))
+	var all []*ssldsse.Envelope
+	for _, s := range sources {
+		envelopes, err := s.lister.ListVSAs(ctx, identifier)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list VSAs from %s: %w", s.name, err)
+		}
+		listed = append(listed, envelopes)
+		all = append(all, envelopes...)
+	}
+
+	records := []VSARecord{}
+	for i, s := range sources {
+		for _, envelope := range listed[i] {
+			if !envelopeHasSubject(envelope, algorithm, hash) || isRevocation(envelope) {
+				continue
+			}
+
+			predicate, err := ParseVSAContent(envelope)
+			if err != nil {
+				log.Debugf("Skipping envelope from %s that is not a VSA: %v", s.name, err)
+				continue
+			}
+
+			record, err := newVSARecord(ctx, envelope, predicate, opts.PublicKey)
+			if err != nil {
+				log.Warnf("Skipping VSA from %s: %v", s.name, err)
+				continue
+			}
+			record.Source = s.name
+
+			revocation, err := findRevocation(ctx, all, envelope, record.Timestamp, opts.PublicKey)
+			if err != nil {
+				return nil, err
+			}
+			record.Revoked = revocation != nil
+
+			if opts.matches(record) {
+				records = append(records, record)
+			}
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.After(records[j].Timestamp)
+	})
+
+	return records, nil
+}
+
+func newVSARecord(ctx context.Context, envelope *ssldsse.Envelope, predicate *Predicate, publicKey string) (VSARecord, error) {
+	timestamp, err := time.Parse(time.RFC3339, predicate.Timestamp)
+	if err != nil {
+		return VSARecord{}, fmt.Errorf("failed to parse VSA timestamp: %w", err)
+	}
+
+	policy, err := json.Marshal(predicate.Policy)
+	if err != nil {
+		return VSARecord{}, err
+	}
+	policyDigest := sha256.Sum256(policy)
+
+	digest, err := PayloadDigest(envelope)
+	if err != nil {
+		return VSARecord{}, err
+	}
+
+	signature := SignatureUnverified
+	if publicKey != "" {
+		signature = SignatureValid
+		if err := verifyVSASignatureFromEnvelope(ctx, envelope, publicKey); err != nil {
+			log.Debugf("VSA signature verification failed: %v", err)
+			signature = SignatureInvalid
+		}
+	}
+
+	return VSARecord{
+		Digest:       digest,
+		Timestamp:    timestamp,
+		Status:       predicate.Status,
+		Verifier:     predicate.Verifier,
+		PolicyDigest: "sha256:" + hex.EncodeToString(policyDigest[:]),
+		Signature:    signature,
+	}, nil
+}
+
+func (o ListOptions) matches(r VSARecord) bool {
+	if !o.Since.IsZero() && r.Timestamp.Before(o.Since) {
+		return false
+	}
+
+	if !o.Until.IsZero() && r.Timestamp.After(o.Until) {
+		return false
+	}
+
+	return o.Status == "" || strings.EqualFold(o.Status, r.Status)
+}
+
+// envelopeHasSubject returns true if the in-toto statement in the envelope
+// has a subject with the given digest
+func envelopeHasSubject(envelope *ssldsse.Envelope, algorithm, hash string) bool {
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return false
+	}
+
+	var statement struct {
+		Subject []Subject `json:"subject"`
+	}
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return false
+	}
+
+	for _, s := range statement.Subject {
+		if strings.EqualFold(s.Digest[algorithm], hash) {
+			return true
+		}
+	}
+
+	return false
+}
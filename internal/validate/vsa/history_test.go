@@ -0,0 +1,245 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vsa
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ecapi "github.com/conforma/crds/api/v1alpha1"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/dsse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLister struct {
+	envelopes []*ssldsse.Envelope
+	err       error
+}
+
+func (f fakeLister) ListVSAs(_ context.Context, _ string) ([]*ssldsse.Envelope, error) {
+	return f.envelopes, f.err
+}
+
+// signedVSAEnvelope returns the VSA envelope for the image digest signed with
+// the given key
+func signedVSAEnvelope(t *testing.T, key *ecdsa.PrivateKey, digest string, predicate Predicate) *ssldsse.Envelope {
+	t.Helper()
+
+	statement, err := json.Marshal(map[string]any{
+		"_type":         "https://in-toto.io/Statement/v0.1",
+		"predicateType": PredicateType,
+		"subject": []Subject{
+			{Name: "registry.io/org/image", Digest: map[string]string{"sha256": strings.TrimPrefix(digest, "sha256:")}},
+		},
+		"predicate": predicate,
+	})
+	require.NoError(t, err)
+
+	sv, err := signature.LoadECDSASignerVerifier(key, crypto.SHA256)
+	require.NoError(t, err)
+
+	signed, err := dsse.WrapSigner(sv, "application/vnd.in-toto+json").SignMessage(bytes.NewReader(statement))
+	require.NoError(t, err)
+
+	var envelope ssldsse.Envelope
+	require.NoError(t, json.Unmarshal(signed, &envelope))
+
+	return &envelope
+}
+
+func writePublicKey(t *testing.T, key *ecdsa.PrivateKey) string {
+	t.Helper()
+
+	pem, err := cryptoutils.MarshalPublicKeyToPEM(key.Public())
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "cosign.pub")
+	require.NoError(t, os.WriteFile(path, pem, 0600))
+
+	return path
+}
+
+func TestListVSARecords(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	vsa := func(k *ecdsa.PrivateKey, d string, age time.Duration, status string) *ssldsse.Envelope {
+		return signedVSAEnvelope(t, k, d, Predicate{
+			Policy:    ecapi.EnterpriseContractPolicySpec{PublicKey: "cosign.pub"},
+			Timestamp: now.Add(-age).Format(time.RFC3339),
+			Status:    status,
+			Verifier:  "ec-cli",
+		})
+	}
+
+	notVSA := &ssldsse.Envelope{PayloadType: "application/vnd.in-toto+json", Payload: "bm90IGpzb24="}
+
+	sources := []vsaSource{
+		{
+			name: "rekor@https://rekor.example.com",
+			lister: fakeLister{envelopes: []*ssldsse.Envelope{
+				vsa(key, digest, 48*time.Hour, "passed"),
+				vsa(otherKey, digest, time.Hour, "failed"),
+				// other image
+				vsa(key, "sha256:"+strings.Repeat("b", 64), 0, "passed"),
+				notVSA,
+			}},
+		},
+		{
+			name: "local@/tmp/vsa",
+			lister: fakeLister{envelopes: []*ssldsse.Envelope{
+				vsa(key, digest, 2*time.Hour, "passed"),
+				// supersedes the VSAs older than a day, recorded in Rekor
+				signedRevocation(t, key, digest, &Revocation{Timestamp: now.Add(-24 * time.Hour).Format(time.RFC3339)}),
+			}},
+		},
+	}
+
+	publicKey := writePublicKey(t, key)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		opts     ListOptions
+		expected []VSARecord
+	}{
+		{
+			name: "all",
+			expected: []VSARecord{
+				{Timestamp: now.Add(-time.Hour), Status: "failed", Signature: SignatureUnverified, Source: "rekor@https://rekor.example.com"},
+				{Timestamp: now.Add(-2 * time.Hour), Status: "passed", Signature: SignatureUnverified, Source: "local@/tmp/vsa"},
+				// the revocation is ignored without a public key to verify it
+				{Timestamp: now.Add(-48 * time.Hour), Status: "passed", Signature: SignatureUnverified, Source: "rekor@https://rekor.example.com"},
+			},
+		},
+		{
+			name: "signatures verified",
+			opts: ListOptions{PublicKey: publicKey},
+			expected: []VSARecord{
+				{Timestamp: now.Add(-time.Hour), Status: "failed", Signature: SignatureInvalid, Source: "rekor@https://rekor.example.com"},
+				{Timestamp: now.Add(-2 * time.Hour), Status: "passed", Signature: SignatureValid, Source: "local@/tmp/vsa"},
+				{Timestamp: now.Add(-48 * time.Hour), Status: "passed", Signature: SignatureValid, Source: "rekor@https://rekor.example.com", Revoked: true},
+			},
+		},
+		{
+			name: "time window and status",
+			opts: ListOptions{Since: now.Add(-24 * time.Hour), Until: now, Status: "PASSED"},
+			expected: []VSARecord{
+				{Timestamp: now.Add(-2 * time.Hour), Status: "passed", Signature: SignatureUnverified, Source: "local@/tmp/vsa"},
+			},
+		},
+		{
+			name:     "nothing matches",
+			opts:     ListOptions{Until: now.Add(-72 * time.Hour)},
+			expected: []VSARecord{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := listVSARecords(ctx, sources, "registry.io/org/image@"+digest, tt.opts)
+			require.NoError(t, err)
+
+			require.Len(t, records, len(tt.expected))
+			for i := range records {
+				assert.True(t, strings.HasPrefix(records[i].Digest, "sha256:"))
+				assert.True(t, strings.HasPrefix(records[i].PolicyDigest, "sha256:"))
+				assert.Equal(t, "ec-cli", records[i].Verifier)
+				records[i].Digest, records[i].PolicyDigest, records[i].Verifier = "", "", ""
+			}
+			assert.Equal(t, tt.expected, records)
+		})
+	}
+
+	_, err = listVSARecords(ctx, sources, "registry.io/org/image:latest", ListOptions{})
+	assert.ErrorContains(t, err, "does not contain a valid image digest")
+
+	_, err = listVSARecords(ctx, []vsaSource{{name: "rekor", lister: fakeLister{err: errors.New("unavailable")}}}, digest, ListOptions{})
+	assert.EqualError(t, err, "failed to list VSAs from rekor: unavailable")
+}
+
+func TestCreateListerFromFlag(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		flag     string
+		expected VSALister
+		err      string
+	}{
+		{flag: "local@" + dir, expected: &FileVSARetriever{}},
+		{flag: "file@" + dir, expected: &FileVSARetriever{}},
+		{flag: "rekor@https://rekor.example.com", expected: &RekorVSARetriever{}},
+		{flag: "oci@registry.io/org/vsas", expected: &OCIVSARetriever{}},
+		{flag: "s3@https://s3.example.com/bucket", expected: &HTTPVSARetriever{}},
+		{flag: "oci@registry.io/Org", err: "no VSA retriever available for oci@registry.io/Org"},
+		{flag: "unknown@here", err: "unsupported backend 'unknown'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			lister, err := CreateListerFromFlag(tt.flag)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.IsType(t, tt.expected, lister)
+		})
+	}
+}
+
+func TestFileVSARetrieverListVSAs(t *testing.T) {
+	dir := t.TempDir()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	envelope := signedVSAEnvelope(t, key, "sha256:"+strings.Repeat("a", 64), Predicate{Timestamp: time.Now().Format(time.RFC3339)})
+	data, err := json.Marshal(envelope)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vsa-1.json"), data, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.json"), []byte(`{"notes": true}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vsa-2.txt"), data, 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub.json"), 0700))
+
+	envelopes, err := NewFileVSARetrieverWithOSFs(dir).ListVSAs(context.Background(), "sha256:"+strings.Repeat("a", 64))
+	require.NoError(t, err)
+	assert.Equal(t, []*ssldsse.Envelope{envelope}, envelopes)
+
+	_, err = NewFileVSARetrieverWithOSFs(filepath.Join(dir, "missing")).ListVSAs(context.Background(), "")
+	assert.ErrorContains(t, err, "failed to read VSA directory")
+}
@@ -0,0 +1,124 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vsa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	log "github.com/sirupsen/logrus"
+)
+
+// HTTPVSARetriever implements VSARetriever using the VSAs stored in a generic
+// HTTP store or an S3 compatible object store by the HTTPBackend
+type HTTPVSARetriever struct {
+	store *objectStore
+}
+
+// NewHTTPVSARetriever creates a new VSA retriever for the generic HTTP store
+// with the given configuration
+func NewHTTPVSARetriever(config *StorageConfig) (*HTTPVSARetriever, error) {
+	store, err := newObjectStore("http", config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPVSARetriever{store: store}, nil
+}
+
+// NewS3VSARetriever creates a new VSA retriever for the S3 compatible object
+// store with the given configuration
+func NewS3VSARetriever(config *StorageConfig) (*HTTPVSARetriever, error) {
+	store, err := newObjectStore("s3", config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPVSARetriever{store: store}, nil
+}
+
+// RetrieveVSA retrieves the latest VSA stored for the image digest, the
+// identifier is an image digest or an image reference pinned to the digest
+func (r *HTTPVSARetriever) RetrieveVSA(ctx context.Context, identifier string) (*ssldsse.Envelope, error) {
+	if identifier == "" {
+		return nil, errors.New("identifier cannot be empty")
+	}
+
+	digest, err := extractImageDigest(identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	objectURL, err := r.store.objectURL(digest, latestVSAObject)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := r.store.get(ctx, objectURL)
+	if err != nil {
+		if errors.Is(err, errObjectNotFound) {
+			return nil, fmt.Errorf("no VSA found in the %s store for image digest: %s", r.store.displayKind(), digest)
+		}
+		return nil, err
+	}
+
+	var envelope ssldsse.Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse VSA envelope from %s: %w", objectURL, err)
+	}
+
+	log.Debugf("Retrieved VSA from %s", objectURL)
+	return &envelope, nil
+}
+
+// ListVSAs retrieves all VSAs stored for the image digest, only supported by
+// S3 compatible object stores
+func (r *HTTPVSARetriever) ListVSAs(ctx context.Context, identifier string) ([]*ssldsse.Envelope, error) {
+	if identifier == "" {
+		return nil, errors.New("identifier cannot be empty")
+	}
+
+	digest, err := extractImageDigest(identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := r.store.list(ctx, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	envelopes := make([]*ssldsse.Envelope, 0, len(objects))
+	for _, objectURL := range objects {
+		data, err := r.store.get(ctx, objectURL)
+		if err != nil {
+			return nil, err
+		}
+
+		var envelope ssldsse.Envelope
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			log.Debugf("Skipping malformed VSA envelope %s: %v", objectURL, err)
+			continue
+		}
+		envelopes = append(envelopes, &envelope)
+	}
+
+	return envelopes, nil
+}
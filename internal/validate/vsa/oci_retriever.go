@@ -0,0 +1,270 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vsa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/conforma/cli/internal/utils/oci"
+)
+
+// OCIVSARetriever implements VSARetriever using the VSAs attached to the
+// images in an OCI registry, either as referrers or using the cosign
+// attestation tag
+type OCIVSARetriever struct {
+	// repository the VSAs are stored in, if empty the repository of the image
+	// is used
+	repository string
+}
+
+// NewOCIVSARetriever creates a new OCI registry based VSA retriever
+func NewOCIVSARetriever(repository string) (*OCIVSARetriever, error) {
+	if repository != "" {
+		if _, err := name.NewRepository(repository); err != nil {
+			return nil, fmt.Errorf("invalid OCI repository %q: %w", repository, err)
+		}
+	}
+
+	return &OCIVSARetriever{repository: repository}, nil
+}
+
+// RetrieveVSA retrieves the newest VSA attached to the image with the given
+// identifier. The identifier is an image reference, or an image digest when
+// the retriever has a repository configured. The VSA is chosen by the time in
+// its predicate before its signature is verified, use RetrieveVSACandidates to
+// find the newest VSA with a valid signature.
+func (r *OCIVSARetriever) RetrieveVSA(ctx context.Context, identifier string) (*ssldsse.Envelope, error) {
+	candidates, err := r.RetrieveVSACandidates(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return candidates[0], nil
+}
+
+// RetrieveVSACandidates retrieves the VSAs attached to the image with the
+// given identifier, newest first. Returns an error if there are none.
+func (r *OCIVSARetriever) RetrieveVSACandidates(ctx context.Context, identifier string) ([]*ssldsse.Envelope, error) {
+	ref, err := r.reference(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	envelopes, err := r.envelopes(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(envelopes) == 0 {
+		return nil, fmt.Errorf("no VSA found in the registry for image digest: %s", ref.DigestStr())
+	}
+
+	sort.SliceStable(envelopes, func(i, j int) bool {
+		return envelopeTimestamp(envelopes[i]).After(envelopeTimestamp(envelopes[j]))
+	})
+
+	log.Debugf("Retrieved %d VSAs from the registry for %s", len(envelopes), ref)
+	return envelopes, nil
+}
+
+// ListVSAs retrieves all VSAs attached to the image with the given identifier
+func (r *OCIVSARetriever) ListVSAs(ctx context.Context, identifier string) ([]*ssldsse.Envelope, error) {
+	ref, err := r.reference(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.envelopes(ctx, ref)
+}
+
+// reference returns the reference of the image, pinned to the digest, in the
+// repository holding the VSAs
+func (r *OCIVSARetriever) reference(ctx context.Context, identifier string) (name.Digest, error) {
+	if identifier == "" {
+		return name.Digest{}, errors.New("identifier cannot be empty")
+	}
+
+	if isValidImageDigest(identifier) {
+		if r.repository == "" {
+			return name.Digest{}, fmt.Errorf("a repository is required to retrieve VSAs by image digest: %s", identifier)
+		}
+
+		repo, err := name.NewRepository(r.repository)
+		if err != nil {
+			return name.Digest{}, err
+		}
+
+		return repo.Digest(identifier), nil
+	}
+
+	ref, err := name.ParseReference(identifier)
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("identifier '%s' is not an image reference: %w", identifier, err)
+	}
+
+	digest := ref.Identifier()
+	if _, ok := ref.(name.Digest); !ok {
+		if digest, err = oci.NewClient(ctx).ResolveDigest(ref); err != nil {
+			return name.Digest{}, fmt.Errorf("failed to resolve the digest of %s: %w", identifier, err)
+		}
+	}
+
+	repo := ref.Context()
+	if r.repository != "" {
+		if repo, err = name.NewRepository(r.repository); err != nil {
+			return name.Digest{}, err
+		}
+	}
+
+	return repo.Digest(digest), nil
+}
+
+// envelopes returns the VSA envelopes attached to the image, as referrers and
+// as cosign attestations
+func (r *OCIVSARetriever) envelopes(ctx context.Context, ref name.Digest) ([]*ssldsse.Envelope, error) {
+	opts := oci.CreateRemoteOptions(ctx)
+
+	var envelopes []*ssldsse.Envelope
+
+	referrers, err := remote.Referrers(ref, append(opts, remote.WithFilter("artifactType", VSAArtifactType))...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the referrers of %s: %w", ref, err)
+	}
+	index, err := referrers.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, desc := range index.Manifests {
+		if desc.ArtifactType != VSAArtifactType {
+			continue
+		}
+
+		img, err := remote.Image(ref.Context().Digest(desc.Digest.String()), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the VSA referrer %s: %w", desc.Digest, err)
+		}
+
+		found, err := vsaEnvelopes(img)
+		if err != nil {
+			return nil, err
+		}
+		envelopes = append(envelopes, found...)
+	}
+
+	img, err := remote.Image(attestationTag(ref), opts...)
+	if err != nil {
+		var terr *transport.Error
+		if !errors.As(err, &terr) || terr.StatusCode != http.StatusNotFound {
+			return nil, fmt.Errorf("failed to fetch the attestations of %s: %w", ref, err)
+		}
+	} else {
+		found, err := vsaEnvelopes(img)
+		if err != nil {
+			return nil, err
+		}
+		envelopes = append(envelopes, found...)
+	}
+
+	return envelopes, nil
+}
+
+// vsaEnvelopes returns the DSSE envelopes holding VSAs from the layers of the
+// image
+func vsaEnvelopes(img v1.Image) ([]*ssldsse.Envelope, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	var envelopes []*ssldsse.Envelope
+	for _, desc := range manifest.Layers {
+		predicateType := desc.Annotations[predicateTypeAnnotation]
+		if desc.MediaType != DSSEMediaType || (predicateType != PredicateType && predicateType != SLSAPredicateType) {
+			continue
+		}
+
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		rc, err := layer.Compressed()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		var envelope ssldsse.Envelope
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			log.Debugf("Skipping malformed VSA envelope %s: %v", desc.Digest, err)
+			continue
+		}
+		envelopes = append(envelopes, &envelope)
+	}
+
+	return envelopes, nil
+}
+
+// envelopeTimestamp returns the timestamp of the VSA in the envelope, or the
+// zero time if it cannot be determined
+func envelopeTimestamp(envelope *ssldsse.Envelope) time.Time {
+	s, err := parseStatement(envelope)
+	if err != nil {
+		return time.Time{}
+	}
+
+	// The time is in the timestamp of the Conforma predicate or in the
+	// timeVerified of the SLSA predicate
+	var predicate struct {
+		Timestamp    string `json:"timestamp"`
+		TimeVerified string `json:"timeVerified"`
+	}
+	if err := json.Unmarshal(s.Predicate, &predicate); err != nil {
+		return time.Time{}
+	}
+
+	timestamp := predicate.Timestamp
+	if s.PredicateType == SLSAPredicateType {
+		timestamp = predicate.TimeVerified
+	}
+
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
@@ -36,6 +36,24 @@ func GenerateAndWritePredicate(ctx context.Context, generator *Generator, writer
 	return writtenPath, nil
 }
 
+// GenerateAndWriteSLSAPredicate generates a Predicate and writes it in the SLSA
+// verification summary format to a file, returning the written path.
+func GenerateAndWriteSLSAPredicate(ctx context.Context, generator *Generator, writer *Writer) (string, error) {
+	pred, err := generator.GeneratePredicate(ctx)
+	if err != nil {
+		return "", err
+	}
+	slsa, err := NewSLSAPredicate(pred)
+	if err != nil {
+		return "", err
+	}
+	writtenPath, err := writer.WriteSLSAPredicate(slsa, pred.Summary.Component.Name)
+	if err != nil {
+		return "", err
+	}
+	return writtenPath, nil
+}
+
 // GenerateAndWriteSnapshotPredicate generates a snapshot Predicate and writes it to a file, returning the written path.
 func GenerateAndWriteSnapshotPredicate(ctx context.Context, generator *applicationsnapshot.SnapshotPredicateGenerator, writer *applicationsnapshot.SnapshotPredicateWriter) (string, error) {
 	pred, err := generator.GeneratePredicate(ctx)
@@ -166,6 +166,12 @@ func isValidImageDigest(digest string) bool {
 
 // extractImageDigest extracts and validates an image digest from various identifier formats
 func (r *RekorVSARetriever) extractImageDigest(identifier string) (string, error) {
+	return extractImageDigest(identifier)
+}
+
+// extractImageDigest extracts the image digest from an identifier, which is
+// either the digest itself or an image reference pinned to the digest
+func extractImageDigest(identifier string) (string, error) {
 	// If identifier is already a digest, validate and return it
 	if isValidImageDigest(identifier) {
 		return identifier, nil
@@ -264,7 +270,7 @@ func (r *RekorVSARetriever) classifyEntryKind(entry models.LogEntryAnon) string
 	// Fallback (only if Body missing/unreadable): look at Attestation for VSA predicate (intoto hint)
 	if entry.Attestation != nil && entry.Attestation.Data != nil {
 		if attBytes, err := base64.StdEncoding.DecodeString(string(entry.Attestation.Data)); err == nil {
-			if strings.Contains(string(attBytes), "https://conforma.dev/verification_summary/v1") {
+			if strings.Contains(string(attBytes), PredicateType) || strings.Contains(string(attBytes), SLSAPredicateType) {
 				return "intoto"
 			}
 		}
@@ -306,13 +312,17 @@ func (r *RekorVSARetriever) RetrieveVSA(ctx context.Context, identifier string)
 		return nil, fmt.Errorf("no entries found in Rekor for image digest: %s", imageDigest)
 	}
 
-	// Find all in-toto 0.0.2 entries
+	// Find all in-toto 0.0.2 entries, other than VSA revocation records
 	var intotoV002Entries []models.LogEntryAnon
 	for _, entry := range entries {
 		entryKind := r.classifyEntryKind(entry)
-		if entryKind == "intoto-v002" {
-			intotoV002Entries = append(intotoV002Entries, entry)
+		if entryKind != "intoto-v002" {
+			continue
+		}
+		if envelope, err := r.buildDSSEEnvelopeFromIntotoV002(entry); err == nil && isRevocation(envelope) {
+			continue
 		}
+		intotoV002Entries = append(intotoV002Entries, entry)
 	}
 
 	if len(intotoV002Entries) == 0 {
@@ -335,6 +345,47 @@ func (r *RekorVSARetriever) RetrieveVSA(ctx context.Context, identifier string)
 	return envelope, nil
 }
 
+// ListVSAs retrieves all DSSE envelopes recorded in Rekor for the image digest
+// in the identifier, in no particular order
+func (r *RekorVSARetriever) ListVSAs(ctx context.Context, identifier string) ([]*ssldsse.Envelope, error) {
+	if identifier == "" {
+		return nil, fmt.Errorf("identifier cannot be empty")
+	}
+
+	imageDigest, err := r.extractImageDigest(identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract image digest from identifier: %w", err)
+	}
+
+	if r.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.options.Timeout)
+		defer cancel()
+	}
+
+	entries, err := r.searchForImageDigest(ctx, imageDigest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search Rekor for image digest: %w", err)
+	}
+
+	var envelopes []*ssldsse.Envelope
+	for _, entry := range entries {
+		if r.classifyEntryKind(entry) != "intoto-v002" {
+			continue
+		}
+
+		envelope, err := r.buildDSSEEnvelopeFromIntotoV002(entry)
+		if err != nil {
+			log.Debugf("Skipping Rekor entry that is not a DSSE envelope: %v", err)
+			continue
+		}
+		envelopes = append(envelopes, envelope)
+	}
+
+	log.Debugf("Retrieved %d DSSE envelopes from Rekor for image digest: %s", len(envelopes), imageDigest)
+	return envelopes, nil
+}
+
 // buildDSSEEnvelopeFromIntotoV002 builds an ssldsse.Envelope directly from an in-toto 0.0.2 entry
 // This eliminates the need for intermediate JSON marshaling/unmarshaling
 func (r *RekorVSARetriever) buildDSSEEnvelopeFromIntotoV002(entry models.LogEntryAnon) (*ssldsse.Envelope, error) {
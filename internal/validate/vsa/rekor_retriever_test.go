@@ -33,6 +33,7 @@ import (
 	"github.com/sigstore/rekor/pkg/generated/client/tlog"
 	"github.com/sigstore/rekor/pkg/generated/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // MockRekorAPI provides a mock implementation for testing the actual rekorClient with mocked client.Rekor dependency
@@ -306,6 +307,56 @@ func TestRekorVSARetriever_RetrieveVSA(t *testing.T) {
 	assert.Equal(t, "test-key-id", envelope.Signatures[0].KeyID)
 }
 
+func TestRekorVSARetriever_Revocations(t *testing.T) {
+	imageDigest := "sha256:abc123def456"
+	vsaStatement := `{"_type":"https://in-toto.io/Statement/v0.1","subject":[{"name":"test-image","digest":{"sha256":"abc123def456"}}],"predicateType":"https://conforma.dev/verification_summary/v1","predicate":{"test":"data"}}`
+	revocationStatement := `{"_type":"https://in-toto.io/Statement/v0.1","subject":[{"name":"test-image","digest":{"sha256":"abc123def456"}}],"predicateType":"https://conforma.dev/vsa_revocation/v1","predicate":{"timestamp":"2026-01-02T03:04:05Z"}}`
+
+	doubleEncodedSig := base64.StdEncoding.EncodeToString([]byte(base64.StdEncoding.EncodeToString([]byte("test"))))
+	intotoV002Body := base64.StdEncoding.EncodeToString([]byte(`{
+		"spec": {
+			"content": {
+				"envelope": {
+					"payloadType": "application/vnd.in-toto+json",
+					"signatures": [{"sig": "` + doubleEncodedSig + `", "keyid": "test-key-id"}]
+				}
+			}
+		}
+	}`))
+
+	mockClient := &MockRekorClient{
+		entries: []models.LogEntryAnon{
+			{
+				LogIndex:       int64Ptr(123),
+				IntegratedTime: int64Ptr(1000),
+				Body:           intotoV002Body,
+				Attestation:    &models.LogEntryAnonAttestation{Data: strfmt.Base64([]byte(vsaStatement))},
+			},
+			{
+				// the revocation is the latest entry
+				LogIndex:       int64Ptr(124),
+				IntegratedTime: int64Ptr(2000),
+				Body:           intotoV002Body,
+				Attestation:    &models.LogEntryAnonAttestation{Data: strfmt.Base64([]byte(revocationStatement))},
+			},
+		},
+	}
+
+	retriever := NewRekorVSARetrieverWithClient(mockClient, DefaultRetrievalOptions())
+
+	envelope, err := retriever.RetrieveVSA(context.Background(), imageDigest)
+	require.NoError(t, err)
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	require.NoError(t, err)
+	assert.Equal(t, vsaStatement, string(payload))
+
+	envelopes, err := retriever.ListVSAs(context.Background(), imageDigest)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 2)
+	assert.False(t, isRevocation(envelopes[0]))
+	assert.True(t, isRevocation(envelopes[1]))
+}
+
 func TestRekorVSARetriever_RetrieveVSA_EmptyDigest(t *testing.T) {
 	mockClient := &MockRekorClient{entries: []models.LogEntryAnon{}}
 	retriever := NewRekorVSARetrieverWithClient(mockClient, DefaultRetrievalOptions())
@@ -31,6 +31,25 @@ type VSARetriever interface {
 	RetrieveVSA(ctx context.Context, identifier string) (*ssldsse.Envelope, error)
 }
 
+// VSALister is implemented by the retrievers able to retrieve all VSAs
+// recorded for an image, not only the latest one
+type VSALister interface {
+	// ListVSAs retrieves the DSSE envelopes recorded for the image digest in the
+	// identifier. Envelopes for other subjects may be included, callers are
+	// expected to match the subject of the envelopes.
+	ListVSAs(ctx context.Context, identifier string) ([]*ssldsse.Envelope, error)
+}
+
+// VSACandidateRetriever is implemented by the retrievers that find several
+// candidate VSAs for an image that are not verified in any way, e.g. attached
+// to the image by anyone able to push to the registry. The newest candidate
+// with a valid signature is used when the signature is verified.
+type VSACandidateRetriever interface {
+	// RetrieveVSACandidates retrieves the VSAs recorded for the image in the
+	// identifier, newest first
+	RetrieveVSACandidates(ctx context.Context, identifier string) ([]*ssldsse.Envelope, error)
+}
+
 // RetrievalOptions configures VSA retrieval behavior
 type RetrievalOptions struct {
 	URL     string
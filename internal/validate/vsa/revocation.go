@@ -0,0 +1,273 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vsa
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	sigopts "github.com/sigstore/sigstore/pkg/signature/options"
+	log "github.com/sirupsen/logrus"
+)
+
+// RevocationPredicateType is the predicate type of the VSA revocation records
+const RevocationPredicateType = "https://conforma.dev/vsa_revocation/v1"
+
+// Revocation is the predicate of a VSA revocation record. The record has the
+// same subject as the VSAs it revokes, and is signed with the same key.
+type Revocation struct {
+	// VSA is the digest of the payload of the revoked VSA, as reported by
+	// "ec vsa list". If empty, all VSAs of the subject created up to the time
+	// of the revocation are revoked, i.e. superseded.
+	VSA string `json:"vsa,omitempty"`
+	// Timestamp is the time of the revocation
+	Timestamp string `json:"timestamp"`
+	// Reason for the revocation
+	Reason string `json:"reason,omitempty"`
+}
+
+// NewRevocation creates the revocation of the VSA with the given payload
+// digest, or of all VSAs up to now if the digest is empty
+func NewRevocation(vsaDigest, reason string) *Revocation {
+	return &Revocation{
+		VSA:       vsaDigest,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Reason:    reason,
+	}
+}
+
+// Sign returns the DSSE envelope of the in-toto statement of the revocation for
+// the image with the given repository and digest
+func (r *Revocation) Sign(ctx context.Context, repo, digest string, signer *Signer) ([]byte, error) {
+	if !isValidImageDigest(digest) || !strings.HasPrefix(digest, "sha256:") {
+		return nil, fmt.Errorf("invalid image digest: %s", digest)
+	}
+
+	if r.VSA != "" && !isValidImageDigest(r.VSA) {
+		return nil, fmt.Errorf("invalid VSA digest: %s", r.VSA)
+	}
+
+	stmt := map[string]any{
+		"_type":         "https://in-toto.io/Statement/v0.1",
+		"predicateType": RevocationPredicateType,
+		"subject": []Subject{
+			{Name: repo, Digest: map[string]string{"sha256": strings.TrimPrefix(digest, "sha256:")}},
+		},
+		"predicate": r,
+	}
+
+	payload, err := json.Marshal(stmt)
+	if err != nil {
+		return nil, fmt.Errorf("marshal statement: %w", err)
+	}
+
+	env, err := signer.WrapSigner.SignMessage(bytes.NewReader(payload), sigopts.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign VSA revocation: %w", err)
+	}
+
+	return env, nil
+}
+
+// UploadRevocation uploads the revocation record envelope to the configured
+// storage backends, only the local and rekor backends can store revocations.
+func UploadRevocation(ctx context.Context, envelopeContent []byte, storageConfigs []string, signer *Signer) error {
+	if len(storageConfigs) == 0 {
+		return errors.New("no storage backends configured")
+	}
+
+	for _, storageFlag := range storageConfigs {
+		config, err := ParseStorageFlag(storageFlag)
+		if err != nil {
+			return fmt.Errorf("invalid storage config '%s': %w", storageFlag, err)
+		}
+
+		if b := strings.ToLower(config.Backend); b != "local" && b != "rekor" {
+			return fmt.Errorf("the %s backend cannot store VSA revocations, supported backends: local, rekor", config.Backend)
+		}
+
+		backend, err := CreateStorageBackend(config)
+		if err != nil {
+			return fmt.Errorf("failed to create %s backend: %w", config.Backend, err)
+		}
+
+		if uploader, ok := backend.(SignerAwareUploader); ok {
+			_, err = uploader.UploadWithSigner(ctx, envelopeContent, signer)
+		} else {
+			err = backend.Upload(ctx, envelopeContent)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to upload the VSA revocation to %s: %w", backend.Name(), err)
+		}
+
+		log.WithFields(log.Fields{
+			"backend": backend.Name(),
+		}).Info("[VSA] Successfully uploaded VSA revocation")
+	}
+
+	return nil
+}
+
+// PayloadDigest returns the digest of the payload of the envelope, identifying
+// the VSA in the revocation records
+func PayloadDigest(envelope *ssldsse.Envelope) (string, error) {
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode DSSE payload: %w", err)
+	}
+
+	sum := sha256.Sum256(payload)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// statement is the in-toto statement in a DSSE envelope
+type statement struct {
+	PredicateType string          `json:"predicateType"`
+	Subject       []Subject       `json:"subject"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+func parseStatement(envelope *ssldsse.Envelope) (*statement, error) {
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode DSSE payload: %w", err)
+	}
+
+	var s statement
+	if err := json.Unmarshal(payload, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse in-toto statement: %w", err)
+	}
+
+	return &s, nil
+}
+
+// isRevocation returns true if the envelope holds a VSA revocation record
+func isRevocation(envelope *ssldsse.Envelope) bool {
+	s, err := parseStatement(envelope)
+	return err == nil && s.PredicateType == RevocationPredicateType
+}
+
+// revokes returns true if the revocation applies to the VSA with the given
+// payload digest and time
+func (r *Revocation) revokes(vsaDigest string, vsaTime time.Time) (bool, error) {
+	if r.VSA != "" {
+		return strings.EqualFold(r.VSA, vsaDigest), nil
+	}
+
+	revokedAt, err := time.Parse(time.RFC3339, r.Timestamp)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse revocation timestamp: %w", err)
+	}
+
+	return !vsaTime.After(revokedAt), nil
+}
+
+// FindRevocation returns the revocation of the VSA in the envelope, created at
+// the given time, among the records listed for the identifier. Returns nil if
+// the VSA has not been revoked. Only the revocations signed with the public key
+// are honoured, without a public key revocations are ignored, as anyone can
+// record one for the image.
+func FindRevocation(ctx context.Context, lister VSALister, identifier string, vsa *ssldsse.Envelope, vsaTime time.Time, publicKeyPath string) (*Revocation, error) {
+	envelopes, err := lister.ListVSAs(ctx, identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VSA revocations: %w", err)
+	}
+
+	return findRevocation(ctx, envelopes, vsa, vsaTime, publicKeyPath)
+}
+
+func findRevocation(ctx context.Context, envelopes []*ssldsse.Envelope, vsa *ssldsse.Envelope, vsaTime time.Time, publicKeyPath string) (*Revocation, error) {
+	vsaDigest, err := PayloadDigest(vsa)
+	if err != nil {
+		return nil, err
+	}
+
+	vsaStatement, err := parseStatement(vsa)
+	if err != nil {
+		return nil, err
+	}
+
+	ignored := 0
+	for _, envelope := range envelopes {
+		s, err := parseStatement(envelope)
+		if err != nil || s.PredicateType != RevocationPredicateType || !sharesSubject(vsaStatement.Subject, s.Subject) {
+			continue
+		}
+
+		var revocation Revocation
+		if err := json.Unmarshal(s.Predicate, &revocation); err != nil {
+			log.Debugf("Skipping malformed VSA revocation: %v", err)
+			continue
+		}
+
+		revoked, err := revocation.revokes(vsaDigest, vsaTime)
+		if err != nil {
+			log.Debugf("Skipping VSA revocation: %v", err)
+			continue
+		}
+		if !revoked {
+			continue
+		}
+
+		if publicKeyPath == "" {
+			ignored++
+			continue
+		}
+
+		if err := verifyVSASignatureFromEnvelope(ctx, envelope, publicKeyPath); err != nil {
+			log.Debugf("Ignoring VSA revocation with an invalid signature: %v", err)
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"vsa":       vsaDigest,
+			"timestamp": revocation.Timestamp,
+			"reason":    revocation.Reason,
+		}).Debug("VSA has been revoked")
+
+		return &revocation, nil
+	}
+
+	if ignored > 0 {
+		log.Warnf("Ignoring %d revocation(s) of VSA %s, a public key is required to verify them", ignored, vsaDigest)
+	}
+
+	return nil, nil
+}
+
+// sharesSubject returns true if any of the subjects have the same sha256
+// digest
+func sharesSubject(a, b []Subject) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x.Digest["sha256"] != "" && strings.EqualFold(x.Digest["sha256"], y.Digest["sha256"]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
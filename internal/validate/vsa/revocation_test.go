@@ -0,0 +1,255 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vsa
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ecapi "github.com/conforma/crds/api/v1alpha1"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	cosigntypes "github.com/sigstore/cosign/v3/pkg/types"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/dsse"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeListingRetriever retrieves the first VSA of the envelopes, and lists all
+// of them
+type fakeListingRetriever struct {
+	envelopes []*ssldsse.Envelope
+}
+
+func (f fakeListingRetriever) RetrieveVSA(_ context.Context, _ string) (*ssldsse.Envelope, error) {
+	return f.envelopes[0], nil
+}
+
+func (f fakeListingRetriever) ListVSAs(_ context.Context, _ string) ([]*ssldsse.Envelope, error) {
+	return f.envelopes, nil
+}
+
+func ecdsaSigner(t *testing.T, key *ecdsa.PrivateKey) *Signer {
+	t.Helper()
+
+	sv, err := signature.LoadECDSASignerVerifier(key, crypto.SHA256)
+	require.NoError(t, err)
+
+	return &Signer{
+		FS:             afero.NewMemMapFs(),
+		WrapSigner:     dsse.WrapSigner(sv, cosigntypes.IntotoPayloadType),
+		SignerVerifier: sv,
+	}
+}
+
+func signedRevocation(t *testing.T, key *ecdsa.PrivateKey, digest string, r *Revocation) *ssldsse.Envelope {
+	t.Helper()
+
+	signed, err := r.Sign(context.Background(), "registry.io/org/image", digest, ecdsaSigner(t, key))
+	require.NoError(t, err)
+
+	var envelope ssldsse.Envelope
+	require.NoError(t, json.Unmarshal(signed, &envelope))
+
+	return &envelope
+}
+
+func TestRevocationSign(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	envelope := signedRevocation(t, key, digest, &Revocation{VSA: "sha256:" + strings.Repeat("c", 64), Timestamp: "2026-01-02T03:04:05Z", Reason: "withdrawn"})
+
+	s, err := parseStatement(envelope)
+	require.NoError(t, err)
+	assert.Equal(t, RevocationPredicateType, s.PredicateType)
+	assert.Equal(t, []Subject{{Name: "registry.io/org/image", Digest: map[string]string{"sha256": strings.Repeat("a", 64)}}}, s.Subject)
+	assert.JSONEq(t, `{"vsa": "sha256:`+strings.Repeat("c", 64)+`", "timestamp": "2026-01-02T03:04:05Z", "reason": "withdrawn"}`, string(s.Predicate))
+	assert.True(t, isRevocation(envelope))
+
+	_, err = NewRevocation("", "").Sign(context.Background(), "registry.io/org/image", "latest", ecdsaSigner(t, key))
+	assert.EqualError(t, err, "invalid image digest: latest")
+
+	_, err = NewRevocation("not-a-digest", "").Sign(context.Background(), "registry.io/org/image", digest, ecdsaSigner(t, key))
+	assert.EqualError(t, err, "invalid VSA digest: not-a-digest")
+}
+
+func TestFindRevocation(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	vsaTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	vsa := signedVSAEnvelope(t, key, digest, Predicate{Timestamp: vsaTime.Format(time.RFC3339), Status: "passed"})
+	vsaDigest, err := PayloadDigest(vsa)
+	require.NoError(t, err)
+
+	publicKey := writePublicKey(t, key)
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		revocation *ssldsse.Envelope
+		publicKey  string
+		revoked    bool
+	}{
+		{
+			name:       "revoked by digest",
+			revocation: signedRevocation(t, key, digest, &Revocation{VSA: vsaDigest, Timestamp: vsaTime.Add(-time.Hour).Format(time.RFC3339)}),
+			publicKey:  publicKey,
+			revoked:    true,
+		},
+		{
+			name:       "other VSA revoked",
+			revocation: signedRevocation(t, key, digest, &Revocation{VSA: "sha256:" + strings.Repeat("c", 64), Timestamp: vsaTime.Add(time.Hour).Format(time.RFC3339)}),
+		},
+		{
+			name:       "superseded",
+			revocation: signedRevocation(t, key, digest, &Revocation{Timestamp: vsaTime.Add(time.Hour).Format(time.RFC3339)}),
+			publicKey:  publicKey,
+			revoked:    true,
+		},
+		{
+			name:       "newer than the revocation",
+			revocation: signedRevocation(t, key, digest, &Revocation{Timestamp: vsaTime.Add(-time.Hour).Format(time.RFC3339)}),
+		},
+		{
+			name:       "other image",
+			revocation: signedRevocation(t, key, "sha256:"+strings.Repeat("b", 64), &Revocation{Timestamp: vsaTime.Add(time.Hour).Format(time.RFC3339)}),
+		},
+		{
+			name:       "signed with another key",
+			revocation: signedRevocation(t, otherKey, digest, &Revocation{VSA: vsaDigest}),
+			publicKey:  publicKey,
+		},
+		{
+			name:       "without public key",
+			revocation: signedRevocation(t, key, digest, &Revocation{VSA: vsaDigest}),
+		},
+		{
+			name:       "signed with another key without public key",
+			revocation: signedRevocation(t, otherKey, digest, &Revocation{VSA: vsaDigest}),
+		},
+		{
+			name:       "malformed timestamp",
+			revocation: signedRevocation(t, key, digest, &Revocation{Timestamp: "yesterday"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lister := fakeLister{envelopes: []*ssldsse.Envelope{vsa, tt.revocation}}
+
+			revocation, err := FindRevocation(ctx, lister, digest, vsa, vsaTime, tt.publicKey)
+			require.NoError(t, err)
+			assert.Equal(t, tt.revoked, revocation != nil)
+		})
+	}
+}
+
+func TestCheckExistingVSARevoked(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	vsa := signedVSAEnvelope(t, key, digest, Predicate{
+		Policy:    ecapi.EnterpriseContractPolicySpec{Sources: []ecapi.Source{{Policy: []string{"oci::registry.io/policy"}}}},
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Status:    "passed",
+	})
+	revocation := signedRevocation(t, key, digest, NewRevocation("", "key compromised"))
+	publicKey := writePublicKey(t, key)
+	ctx := context.Background()
+
+	retriever := fakeListingRetriever{envelopes: []*ssldsse.Envelope{vsa, revocation}}
+
+	// without a public key the revocation cannot be verified and is ignored
+	valid, err := NewVSAChecker(retriever).IsValidVSA(ctx, "registry.io/org/image@"+digest, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	lookup, err := NewVSAChecker(retriever).CheckExistingVSAWithVerification(ctx, "registry.io/org/image@"+digest, time.Hour, true, publicKey)
+	require.NoError(t, err)
+	assert.True(t, lookup.Revoked)
+	assert.False(t, lookup.Found)
+
+	// a revocation signed with another key is not honoured
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	forged := fakeListingRetriever{envelopes: []*ssldsse.Envelope{vsa, signedRevocation(t, otherKey, digest, NewRevocation("", "forged"))}}
+	lookup, err = NewVSAChecker(forged).CheckExistingVSAWithVerification(ctx, "registry.io/org/image@"+digest, time.Hour, true, publicKey)
+	require.NoError(t, err)
+	assert.False(t, lookup.Revoked)
+	assert.True(t, lookup.Found)
+
+	result, err := ValidateVSAAndComparePolicy(ctx, "registry.io/org/image@"+digest, &VSAValidationConfig{
+		Retriever:     retriever,
+		PublicKeyPath: publicKey,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &ValidationResult{
+		Passed:            false,
+		Message:           "VSA has been revoked: key compromised",
+		SignatureVerified: true,
+		ReasonCode:        "revoked",
+	}, result)
+
+	// without the revocation the VSA is valid
+	retriever = fakeListingRetriever{envelopes: []*ssldsse.Envelope{vsa}}
+	lookup, err = NewVSAChecker(retriever).CheckExistingVSAWithVerification(ctx, "registry.io/org/image@"+digest, time.Hour, true, publicKey)
+	require.NoError(t, err)
+	assert.True(t, lookup.Found)
+}
+
+func TestUploadRevocation(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signer := ecdsaSigner(t, key)
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	envelope, err := NewRevocation("", "withdrawn").Sign(context.Background(), "registry.io/org/image", digest, signer)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, UploadRevocation(context.Background(), envelope, []string{"local@" + dir}, signer))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	assert.Equal(t, envelope, data)
+
+	err = UploadRevocation(context.Background(), envelope, []string{"oci@registry.io/org/vsas"}, signer)
+	assert.EqualError(t, err, "the oci backend cannot store VSA revocations, supported backends: local, rekor")
+
+	err = UploadRevocation(context.Background(), envelope, nil, signer)
+	assert.EqualError(t, err, "no storage backends configured")
+}
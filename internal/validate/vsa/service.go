@@ -40,6 +40,7 @@ type Service struct {
 	policySource string
 	policy       PublicKeyProvider
 	outputDir    string
+	format       string
 }
 
 // NewServiceWithFS creates a new VSA service with the given signer and filesystem
@@ -50,9 +51,17 @@ func NewServiceWithFS(signer *Signer, fs afero.Fs, policySource string, policy P
 		policySource: policySource,
 		policy:       policy,
 		outputDir:    outputDir,
+		format:       FormatConforma,
 	}
 }
 
+// WithFormat sets the format of the component VSA predicates, one of
+// SupportedFormats
+func (s *Service) WithFormat(format string) *Service {
+	s.format = format
+	return s
+}
+
 // ProcessComponentVSA processes VSA generation, writing, and attestation for a single component
 func (s *Service) ProcessComponentVSA(ctx context.Context, report applicationsnapshot.Report, comp applicationsnapshot.Component, gitURL, digest string) (string, error) {
 	generator := NewGenerator(report, comp, s.policySource, s.policy)
@@ -62,8 +71,15 @@ func (s *Service) ProcessComponentVSA(ctx context.Context, report applicationsna
 		FilePerm:      0o600,
 	}
 
-	// Generate and write Predicate (new format)
-	writtenPath, err := GenerateAndWritePredicate(ctx, generator, writer)
+	// Generate and write Predicate in the requested format
+	predicateType := PredicateType
+	generate := GenerateAndWritePredicate
+	if s.format == FormatSLSA {
+		predicateType = SLSAPredicateType
+		generate = GenerateAndWriteSLSAPredicate
+	}
+
+	writtenPath, err := generate(ctx, generator, writer)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate and write component Predicate: %w", err)
 	}
@@ -78,6 +94,7 @@ func (s *Service) ProcessComponentVSA(ctx context.Context, report applicationsna
 	if err != nil {
 		return "", fmt.Errorf("failed to create component attestor: %w", err)
 	}
+	attestor.PredicateType = predicateType
 
 	envelopePath, err := AttestVSA(ctx, attestor)
 	if err != nil {
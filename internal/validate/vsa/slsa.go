@@ -0,0 +1,166 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vsa
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/conforma/cli/internal/version"
+	"github.com/conforma/cli/pkg/schema"
+)
+
+// Formats of the VSA predicate
+const (
+	// FormatConforma is the Conforma specific predicate, see Predicate
+	FormatConforma = "conforma"
+	// FormatSLSA is the SLSA verification summary predicate, see SLSAPredicate
+	FormatSLSA = "slsa"
+)
+
+// SupportedFormats lists the supported formats of the VSA predicate
+var SupportedFormats = []string{FormatConforma, FormatSLSA}
+
+const (
+	// SLSAVerifierID identifies Conforma as the verifier in SLSA VSAs
+	SLSAVerifierID = "https://conforma.dev"
+	// SLSAVersion is the version of the SLSA specification of the SLSA VSAs
+	SLSAVersion = "1.0"
+
+	slsaPassed = "PASSED"
+	slsaFailed = "FAILED"
+)
+
+// SLSAPredicate is the predicate of the SLSA verification summary attestation,
+// see https://slsa.dev/spec/v1.0/verification_summary
+type SLSAPredicate struct {
+	Verifier           SLSAVerifier             `json:"verifier"`
+	TimeVerified       string                   `json:"timeVerified,omitempty"`
+	ResourceURI        string                   `json:"resourceUri"`
+	Policy             SLSAResourceDescriptor   `json:"policy"`
+	InputAttestations  []SLSAResourceDescriptor `json:"inputAttestations,omitempty"`
+	VerificationResult string                   `json:"verificationResult"`
+	VerifiedLevels     []string                 `json:"verifiedLevels"`
+	DependencyLevels   map[string]int           `json:"dependencyLevels,omitempty"`
+	SLSAVersion        string                   `json:"slsaVersion,omitempty"`
+}
+
+// SLSAVerifier identifies the entity that performed the verification
+type SLSAVerifier struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+// SLSAResourceDescriptor describes a resource, e.g. the policy, by its location
+// and digest
+type SLSAResourceDescriptor struct {
+	URI       string            `json:"uri,omitempty"`
+	Digest    map[string]string `json:"digest,omitempty"`
+	MediaType string            `json:"mediaType,omitempty"`
+	Content   []byte            `json:"content,omitempty"`
+}
+
+// NewSLSAPredicate converts the Predicate to the SLSA verification summary
+// format. The resolved policy is kept as the content of the policy descriptor
+// so that the policy of the VSA can be compared when it is reused. Conforma
+// policies do not map to SLSA levels, hence no levels are claimed.
+func NewSLSAPredicate(predicate *Predicate) (*SLSAPredicate, error) {
+	if len(predicate.ImageRefs) == 0 {
+		return nil, errors.New("the VSA predicate has no image references")
+	}
+
+	policy, err := json.Marshal(predicate.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal VSA policy: %w", err)
+	}
+	digest := sha256.Sum256(policy)
+
+	result := slsaFailed
+	if predicate.Status == "passed" {
+		result = slsaPassed
+	}
+
+	return &SLSAPredicate{
+		Verifier: SLSAVerifier{
+			ID:      SLSAVerifierID,
+			Version: map[string]string{"conforma": version.Version},
+		},
+		TimeVerified: predicate.Timestamp,
+		ResourceURI:  predicate.ImageRefs[0],
+		Policy: SLSAResourceDescriptor{
+			URI:       predicate.PolicySource,
+			Digest:    map[string]string{"sha256": hex.EncodeToString(digest[:])},
+			MediaType: "application/json",
+			Content:   policy,
+		},
+		VerificationResult: result,
+		VerifiedLevels:     []string{},
+		SLSAVersion:        SLSAVersion,
+	}, nil
+}
+
+// toPredicate maps the SLSA verification summary onto the Predicate. The policy
+// is available only if the policy descriptor holds its content, as it does in
+// the VSAs produced by Conforma.
+func (s *SLSAPredicate) toPredicate() (*Predicate, error) {
+	predicate := Predicate{
+		PolicySource: s.Policy.URI,
+		ImageRefs:    []string{s.ResourceURI},
+		Timestamp:    s.TimeVerified,
+		Status:       strings.ToLower(s.VerificationResult),
+		Verifier:     s.Verifier.ID,
+	}
+
+	if len(s.Policy.Content) > 0 {
+		if err := json.Unmarshal(s.Policy.Content, &predicate.Policy); err != nil {
+			return nil, fmt.Errorf("failed to parse the policy of the SLSA VSA predicate: %w", err)
+		}
+	}
+
+	return &predicate, nil
+}
+
+// isSLSAPredicate returns true if the predicate, in absence of the predicate
+// type, has the shape of the SLSA verification summary
+func isSLSAPredicate(predicateBytes []byte) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(predicateBytes, &fields); err != nil {
+		return false
+	}
+
+	_, ok := fields["verificationResult"]
+	return ok
+}
+
+// parseSLSAPredicate validates the SLSA verification summary predicate against
+// its JSON schema and maps it onto the Predicate
+func parseSLSAPredicate(predicateBytes []byte) (*Predicate, error) {
+	if err := validateSchema(schema.SLSA_VSA_v1, predicateBytes); err != nil {
+		return nil, err
+	}
+
+	var slsa SLSAPredicate
+	if err := json.Unmarshal(predicateBytes, &slsa); err != nil {
+		return nil, fmt.Errorf("failed to parse SLSA VSA predicate from DSSE payload: %w", err)
+	}
+
+	return slsa.toPredicate()
+}
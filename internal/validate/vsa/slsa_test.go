@@ -0,0 +1,202 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vsa
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	ecapi "github.com/conforma/crds/api/v1alpha1"
+	app "github.com/konflux-ci/application-api/api/v1alpha1"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conforma/cli/internal/applicationsnapshot"
+	"github.com/conforma/cli/internal/version"
+)
+
+var testSLSAPolicy = ecapi.EnterpriseContractPolicySpec{
+	Sources: []ecapi.Source{{Policy: []string{"oci::quay.io/policy"}}},
+}
+
+func TestNewSLSAPredicate(t *testing.T) {
+	predicate := &Predicate{
+		Policy:       testSLSAPolicy,
+		PolicySource: "policy.yaml",
+		ImageRefs:    []string{"registry.io/repository/image@sha256:abc", "registry.io/repository/image@sha256:def"},
+		Timestamp:    "2024-03-21T12:00:00Z",
+		Status:       "passed",
+		Verifier:     "conforma",
+	}
+
+	slsa, err := NewSLSAPredicate(predicate)
+	require.NoError(t, err)
+
+	policy := `{"sources":[{"policy":["oci::quay.io/policy"]}]}`
+	digest := sha256.Sum256([]byte(policy))
+	data, err := json.Marshal(slsa)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"verifier": {"id": "https://conforma.dev", "version": {"conforma": "`+version.Version+`"}},
+		"timeVerified": "2024-03-21T12:00:00Z",
+		"resourceUri": "registry.io/repository/image@sha256:abc",
+		"policy": {
+			"uri": "policy.yaml",
+			"digest": {"sha256": "`+hex.EncodeToString(digest[:])+`"},
+			"mediaType": "application/json",
+			"content": "`+base64.StdEncoding.EncodeToString([]byte(policy))+`"
+		},
+		"verificationResult": "PASSED",
+		"verifiedLevels": [],
+		"slsaVersion": "1.0"
+	}`, string(data))
+
+	predicate.Status = "failed"
+	slsa, err = NewSLSAPredicate(predicate)
+	require.NoError(t, err)
+	assert.Equal(t, "FAILED", slsa.VerificationResult)
+
+	_, err = NewSLSAPredicate(&Predicate{Status: "passed"})
+	assert.EqualError(t, err, "the VSA predicate has no image references")
+}
+
+func TestParseVSAContentSLSA(t *testing.T) {
+	envelope := func(payload string) *ssldsse.Envelope {
+		return &ssldsse.Envelope{
+			PayloadType: "application/vnd.in-toto+json",
+			Payload:     base64.StdEncoding.EncodeToString([]byte(payload)),
+		}
+	}
+
+	slsa, err := NewSLSAPredicate(&Predicate{
+		Policy:       testSLSAPolicy,
+		PolicySource: "policy.yaml",
+		ImageRefs:    []string{"registry.io/repository/image@sha256:abc"},
+		Timestamp:    "2024-03-21T12:00:00Z",
+		Status:       "passed",
+	})
+	require.NoError(t, err)
+	conforma, err := json.Marshal(slsa)
+	require.NoError(t, err)
+
+	external := `{
+		"verifier": {"id": "https://example.com/verifier"},
+		"timeVerified": "2024-03-21T12:00:00Z",
+		"resourceUri": "registry.io/repository/image@sha256:abc",
+		"policy": {"uri": "https://example.com/policy", "digest": {"sha256": "abc"}},
+		"verificationResult": "FAILED",
+		"verifiedLevels": ["SLSA_BUILD_LEVEL_3"]
+	}`
+
+	cases := []struct {
+		name     string
+		payload  string
+		expected *Predicate
+		err      string
+	}{
+		{
+			name:    "in-toto statement",
+			payload: `{"_type": "https://in-toto.io/Statement/v0.1", "predicateType": "https://slsa.dev/verification_summary/v1", "predicate": ` + string(conforma) + `}`,
+			expected: &Predicate{
+				Policy:       testSLSAPolicy,
+				PolicySource: "policy.yaml",
+				ImageRefs:    []string{"registry.io/repository/image@sha256:abc"},
+				Timestamp:    "2024-03-21T12:00:00Z",
+				Status:       "passed",
+				Verifier:     "https://conforma.dev",
+			},
+		},
+		{
+			name:    "raw predicate without policy content",
+			payload: external,
+			expected: &Predicate{
+				PolicySource: "https://example.com/policy",
+				ImageRefs:    []string{"registry.io/repository/image@sha256:abc"},
+				Timestamp:    "2024-03-21T12:00:00Z",
+				Status:       "failed",
+				Verifier:     "https://example.com/verifier",
+			},
+		},
+		{
+			name:    "not conforming to schema",
+			payload: `{"predicateType": "https://slsa.dev/verification_summary/v1", "predicate": {"verificationResult": "passed", "verifier": {"id": "x"}, "resourceUri": "x", "policy": {"uri": "x"}, "verifiedLevels": []}}`,
+			err:     `VSA predicate does not conform to schema: /verificationResult: value must be one of "PASSED", "FAILED"`,
+		},
+		{
+			name:    "invalid policy content",
+			payload: `{"predicateType": "https://slsa.dev/verification_summary/v1", "predicate": {"verificationResult": "PASSED", "verifier": {"id": "x"}, "resourceUri": "x", "policy": {"content": "` + base64.StdEncoding.EncodeToString([]byte("[]")) + `"}, "verifiedLevels": []}}`,
+			err:     "failed to parse the policy of the SLSA VSA predicate: json: cannot unmarshal array into Go value of type v1alpha1.EnterpriseContractPolicySpec",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			predicate, err := ParseVSAContent(envelope(c.payload))
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, predicate)
+		})
+	}
+}
+
+func TestServiceSLSAFormat(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	fs := afero.NewMemMapFs()
+	signer := ecdsaSigner(t, key)
+	signer.FS = fs
+
+	report := applicationsnapshot.Report{Policy: testSLSAPolicy}
+	comp := applicationsnapshot.Component{
+		SnapshotComponent: app.SnapshotComponent{
+			Name:           "component",
+			ContainerImage: "registry.io/repository/image@sha256:abc",
+		},
+		Success: true,
+	}
+
+	service := NewServiceWithFS(signer, fs, "policy.yaml", nil, "vsa-").WithFormat(FormatSLSA)
+	envelopePath, err := service.ProcessComponentVSA(context.Background(), report, comp, "", "sha256:abc")
+	require.NoError(t, err)
+
+	content, err := afero.ReadFile(fs, envelopePath)
+	require.NoError(t, err)
+	assert.Equal(t, SLSAPredicateType, envelopePredicateType(content))
+
+	var envelope ssldsse.Envelope
+	require.NoError(t, json.Unmarshal(content, &envelope))
+
+	predicate, err := ParseVSAContent(&envelope)
+	require.NoError(t, err)
+	assert.Equal(t, "passed", predicate.Status)
+	assert.Equal(t, "policy.yaml", predicate.PolicySource)
+	assert.Equal(t, testSLSAPolicy, predicate.Policy)
+	assert.Equal(t, []string{"registry.io/repository/image@sha256:abc"}, predicate.ImageRefs)
+}
@@ -41,7 +41,7 @@ type SignerAwareUploader interface {
 
 // StorageConfig represents parsed storage configuration
 type StorageConfig struct {
-	Backend    string            // rekor, local (maybe others in future)
+	Backend    string            // rekor, local, oci, http, s3
 	BaseURL    string            // Primary URL
 	Parameters map[string]string // Additional parameters
 }
@@ -50,6 +50,9 @@ type StorageConfig struct {
 // Supported formats:
 //   - rekor@https://rekor.sigstore.dev
 //   - local@/path/to/directory
+//   - oci@quay.io/org/repository
+//   - http@https://vsa.example.com/store?prefix=release-policy
+//   - s3@https://s3.amazonaws.com/bucket?prefix=release-policy&region=us-east-1
 //   - rekor?server=custom.rekor.com&timeout=30s
 func ParseStorageFlag(storageFlag string) (*StorageConfig, error) {
 	if storageFlag == "" {
@@ -83,7 +86,7 @@ func ParseStorageFlag(storageFlag string) (*StorageConfig, error) {
 	}
 
 	// Validate that backend is supported
-	supportedBackends := []string{"rekor", "local"}
+	supportedBackends := []string{"rekor", "local", "oci", "http", "s3"}
 	isSupported := false
 	for _, supported := range supportedBackends {
 		if strings.ToLower(config.Backend) == supported {
@@ -135,8 +138,14 @@ func CreateStorageBackend(config *StorageConfig) (StorageBackend, error) {
 		return NewRekorBackend(config)
 	case "local":
 		return NewLocalBackend(config)
+	case "oci":
+		return NewOCIBackend(config)
+	case "http":
+		return NewHTTPBackend(config)
+	case "s3":
+		return NewS3Backend(config)
 	default:
-		return nil, fmt.Errorf("unsupported storage backend: %s. Supported backends: rekor, local", config.Backend)
+		return nil, fmt.Errorf("unsupported storage backend: %s. Supported backends: rekor, local, oci, http, s3", config.Backend)
 	}
 }
 
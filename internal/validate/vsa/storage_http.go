@@ -0,0 +1,368 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vsa
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// latestVSAObject is the name of the object holding the most recently
+	// uploaded VSA of an image
+	latestVSAObject = "latest.json"
+
+	// httpStoreTokenEnv is the environment variable holding the bearer token
+	// used to authenticate with the HTTP store
+	httpStoreTokenEnv = "EC_VSA_STORE_TOKEN"
+)
+
+// errObjectNotFound is returned when the object is not present in the store
+var errObjectNotFound = errors.New("object not found")
+
+// objectStore is a minimal client of an object store that supports PUT and
+// GET of objects by URL, such as a generic HTTP store or the S3 API. VSAs are
+// stored keyed by the image digest, under an optional prefix allowing the
+// VSAs of different policies to be kept apart:
+//
+//	<url>/<prefix>/<algorithm>/<hex>/<timestamp>-<hash>.json
+//	<url>/<prefix>/<algorithm>/<hex>/latest.json
+type objectStore struct {
+	kind    string
+	baseURL string
+	prefix  string
+	region  string
+	client  *http.Client
+}
+
+// newObjectStore creates the object store client from the storage
+// configuration, kind is either "http" or "s3". For S3 the URL is of the
+// endpoint including the bucket, path-style addressing is used so any S3
+// compatible store can be used.
+func newObjectStore(kind string, config *StorageConfig) (*objectStore, error) {
+	store := &objectStore{
+		kind:    kind,
+		baseURL: config.BaseURL,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+
+	if kind == "s3" {
+		store.region = "us-east-1" // Default
+		if region := os.Getenv("AWS_REGION"); region != "" {
+			store.region = region
+		}
+	}
+
+	for key, value := range config.Parameters {
+		switch key {
+		case "url", "server":
+			store.baseURL = value
+		case "prefix", "policy":
+			store.prefix = strings.Trim(value, "/")
+		case "timeout":
+			timeout, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout format '%s': %w", value, err)
+			}
+			store.client.Timeout = timeout
+		case "region":
+			if kind == "s3" {
+				store.region = value
+				continue
+			}
+			fallthrough
+		default:
+			log.Warnf("[VSA] %s backend: ignoring unknown parameter '%s'", store.displayKind(), key)
+		}
+	}
+
+	if store.baseURL == "" {
+		return nil, fmt.Errorf("%s backend requires a URL", store.displayKind())
+	}
+
+	u, err := url.Parse(store.baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid %s store URL %q, expected an http or https URL", store.displayKind(), store.baseURL)
+	}
+	if kind == "s3" && strings.Trim(u.Path, "/") == "" {
+		return nil, fmt.Errorf("S3 store URL %q must include the bucket", store.baseURL)
+	}
+	store.baseURL = strings.TrimSuffix(store.baseURL, "/")
+
+	return store, nil
+}
+
+func (s *objectStore) displayKind() string {
+	if s.kind == "s3" {
+		return "S3"
+	}
+
+	return "HTTP"
+}
+
+// objectURL returns the URL of the object with the given name stored for the
+// image digest
+func (s *objectStore) objectURL(digest, object string) (string, error) {
+	algorithm, hash, ok := strings.Cut(digest, ":")
+	if !ok || !isValidImageDigest(digest) {
+		return "", fmt.Errorf("invalid image digest: %s", digest)
+	}
+
+	return s.baseURL + "/" + path.Join(s.prefix, algorithm, hash, object), nil
+}
+
+func (s *objectStore) put(ctx context.Context, objectURL string, content []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.do(ctx, req, content)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to store %s: %s", objectURL, responseError(resp))
+	}
+
+	return nil
+}
+
+func (s *objectStore) get(ctx context.Context, objectURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, objectURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, errObjectNotFound
+	default:
+		return nil, fmt.Errorf("failed to fetch %s: %s", objectURL, responseError(resp))
+	}
+}
+
+// list returns the URLs of the objects stored for the image digest, using the
+// S3 ListObjectsV2 API. Generic HTTP stores provide no means of listing.
+func (s *objectStore) list(ctx context.Context, digest string) ([]string, error) {
+	if s.kind != "s3" {
+		return nil, errors.New("listing VSAs is not supported by the HTTP store")
+	}
+
+	latestURL, err := s.objectURL(digest, latestVSAObject)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(latestURL)
+	if err != nil {
+		return nil, err
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	keyPrefix := strings.TrimSuffix(key, latestVSAObject)
+
+	var objects []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {keyPrefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		listURL := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/" + bucket, RawQuery: query.Encode()}
+
+		data, err := s.get(ctx, listURL.String())
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err := xml.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse the S3 object listing: %w", err)
+		}
+
+		for _, c := range result.Contents {
+			// latest.json is a copy of one of the VSAs
+			if path.Base(c.Key) == latestVSAObject || path.Ext(c.Key) != ".json" {
+				continue
+			}
+			objectURL := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/" + path.Join(bucket, c.Key)}
+			objects = append(objects, objectURL.String())
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// do sends the request, authenticated with the bearer token for HTTP stores
+// and signed using AWS Signature Version 4 for S3 stores. Without credentials
+// the request is sent anonymously.
+func (s *objectStore) do(ctx context.Context, req *http.Request, content []byte) (*http.Response, error) {
+	switch s.kind {
+	case "s3":
+		if creds, ok := s3Credentials(); ok {
+			payloadHash := sha256.Sum256(content)
+			hash := hex.EncodeToString(payloadHash[:])
+			req.Header.Set("X-Amz-Content-Sha256", hash)
+			if err := v4.NewSigner().SignHTTP(ctx, creds, req, hash, "s3", s.region, time.Now()); err != nil {
+				return nil, fmt.Errorf("failed to sign the S3 request: %w", err)
+			}
+		}
+	default:
+		if token := os.Getenv(httpStoreTokenEnv); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s store request failed: %w", s.displayKind(), err)
+	}
+
+	return resp, nil
+}
+
+// s3Credentials returns the AWS credentials from the standard environment
+// variables
+func s3Credentials() (aws.Credentials, bool) {
+	creds := aws.Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	return creds, creds.AccessKeyID != "" && creds.SecretAccessKey != ""
+}
+
+func responseError(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		return fmt.Sprintf("%s: %s", resp.Status, msg)
+	}
+
+	return resp.Status
+}
+
+// HTTPBackend implements VSA storage in a generic HTTP store accepting PUT
+// requests, or in an S3 compatible object store
+type HTTPBackend struct {
+	store *objectStore
+}
+
+// NewHTTPBackend creates a new generic HTTP store backend
+func NewHTTPBackend(config *StorageConfig) (StorageBackend, error) {
+	store, err := newObjectStore("http", config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPBackend{store: store}, nil
+}
+
+// NewS3Backend creates a new S3 compatible object store backend
+func NewS3Backend(config *StorageConfig) (StorageBackend, error) {
+	store, err := newObjectStore("s3", config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPBackend{store: store}, nil
+}
+
+// Name returns the backend name
+func (h *HTTPBackend) Name() string {
+	if h.store.prefix == "" {
+		return fmt.Sprintf("%s (%s)", h.store.displayKind(), h.store.baseURL)
+	}
+
+	return fmt.Sprintf("%s (%s, prefix %s)", h.store.displayKind(), h.store.baseURL, h.store.prefix)
+}
+
+// Upload stores the VSA envelope for each of the image digests in its subject,
+// and updates the latest VSA of the images
+func (h *HTTPBackend) Upload(ctx context.Context, envelopeContent []byte) error {
+	subjects, err := envelopeSubjects(envelopeContent)
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().UTC().Format("2006-01-02T15-04-05.000000000")
+	contentHash := sha256.Sum256(envelopeContent)
+	object := fmt.Sprintf("%s-%x.json", timestamp, contentHash[:8])
+
+	for _, s := range subjects {
+		hash, ok := s.Digest["sha256"]
+		if !ok {
+			return fmt.Errorf("subject %q has no sha256 digest", s.Name)
+		}
+		digest := "sha256:" + hash
+
+		for _, name := range []string{object, latestVSAObject} {
+			objectURL, err := h.store.objectURL(digest, name)
+			if err != nil {
+				return err
+			}
+
+			if err := h.store.put(ctx, objectURL, envelopeContent); err != nil {
+				return err
+			}
+		}
+
+		log.WithFields(log.Fields{
+			"backend": h.Name(),
+			"digest":  digest,
+			"object":  object,
+		}).Info("[VSA] Successfully stored VSA in the object store")
+	}
+
+	return nil
+}
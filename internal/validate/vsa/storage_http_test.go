@@ -0,0 +1,326 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vsa
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeObjectStore is an in-memory stand-in for an HTTP or S3 compatible
+// object store, it records the requests and the authorization headers
+type fakeObjectStore struct {
+	mu            sync.Mutex
+	objects       map[string][]byte
+	authorization []string
+}
+
+func newFakeObjectStore(t *testing.T) (*fakeObjectStore, string) {
+	t.Helper()
+
+	store := &fakeObjectStore{objects: map[string][]byte{}}
+	server := httptest.NewServer(store)
+	t.Cleanup(server.Close)
+
+	return store, server.URL
+}
+
+func (f *fakeObjectStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.authorization = append(f.authorization, r.Header.Get("Authorization"))
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = data
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet:
+		if r.URL.Query().Get("list-type") == "2" {
+			f.listObjects(w, r)
+			return
+		}
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// listObjects responds with the objects of the bucket in the request path, as
+// the S3 ListObjectsV2 API does, one object per page
+func (f *fakeObjectStore) listObjects(w http.ResponseWriter, r *http.Request) {
+	bucket := r.URL.Path + "/"
+	prefix := bucket + r.URL.Query().Get("prefix")
+
+	keys := []string{}
+	for k := range f.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, strings.TrimPrefix(k, bucket))
+		}
+	}
+	sort.Strings(keys)
+
+	start := 0
+	if token := r.URL.Query().Get("continuation-token"); token != "" {
+		start = sort.SearchStrings(keys, token)
+	}
+
+	result := struct {
+		XMLName  xml.Name `xml:"ListBucketResult"`
+		Contents []struct {
+			Key string `xml:"Key"`
+		} `xml:"Contents"`
+		IsTruncated           bool   `xml:"IsTruncated"`
+		NextContinuationToken string `xml:"NextContinuationToken,omitempty"`
+	}{}
+	if start < len(keys) {
+		result.Contents = append(result.Contents, struct {
+			Key string `xml:"Key"`
+		}{Key: keys[start]})
+	}
+	if start+1 < len(keys) {
+		result.IsTruncated = true
+		result.NextContinuationToken = keys[start+1]
+	}
+
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+func (f *fakeObjectStore) keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.objects))
+	for k := range f.objects {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+func TestNewObjectStore(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     string
+		config   *StorageConfig
+		expected string
+		err      string
+	}{
+		{
+			name:     "http",
+			kind:     "http",
+			config:   &StorageConfig{BaseURL: "https://vsa.example.com/store/"},
+			expected: "HTTP (https://vsa.example.com/store)",
+		},
+		{
+			name:     "http with prefix",
+			kind:     "http",
+			config:   &StorageConfig{BaseURL: "https://vsa.example.com", Parameters: map[string]string{"prefix": "/release/"}},
+			expected: "HTTP (https://vsa.example.com, prefix release)",
+		},
+		{
+			name:     "s3 with url parameter",
+			kind:     "s3",
+			config:   &StorageConfig{Parameters: map[string]string{"url": "https://s3.example.com/bucket", "region": "eu-west-1"}},
+			expected: "S3 (https://s3.example.com/bucket)",
+		},
+		{
+			name:   "missing url",
+			kind:   "http",
+			config: &StorageConfig{},
+			err:    "HTTP backend requires a URL",
+		},
+		{
+			name:   "not an http url",
+			kind:   "http",
+			config: &StorageConfig{BaseURL: "/tmp/vsas"},
+			err:    `invalid HTTP store URL "/tmp/vsas"`,
+		},
+		{
+			name:   "s3 without bucket",
+			kind:   "s3",
+			config: &StorageConfig{BaseURL: "https://s3.example.com"},
+			err:    `S3 store URL "https://s3.example.com" must include the bucket`,
+		},
+		{
+			name:   "invalid timeout",
+			kind:   "http",
+			config: &StorageConfig{BaseURL: "https://vsa.example.com", Parameters: map[string]string{"timeout": "soon"}},
+			err:    "invalid timeout format 'soon'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := newObjectStore(tt.kind, tt.config)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, (&HTTPBackend{store: store}).Name())
+		})
+	}
+}
+
+func TestHTTPBackendRoundTrip(t *testing.T) {
+	hash := strings.Repeat("a", 64)
+	ref, err := name.NewDigest("registry.io/org/image@sha256:" + hash)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		backend string
+		env     map[string]string
+		auth    string
+	}{
+		{
+			name:    "http",
+			backend: "http",
+			env:     map[string]string{httpStoreTokenEnv: "t0k3n"},
+			auth:    "Bearer t0k3n",
+		},
+		{
+			name:    "s3",
+			backend: "s3",
+			env: map[string]string{
+				"AWS_ACCESS_KEY_ID":     "AKIDEXAMPLE",
+				"AWS_SECRET_ACCESS_KEY": "secret",
+			},
+			auth: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/",
+		},
+		{
+			name:    "s3 anonymous",
+			backend: "s3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			store, url := newFakeObjectStore(t)
+			flag := tt.backend + "@" + url + "/bucket?prefix=release"
+
+			config, err := ParseStorageFlag(flag)
+			require.NoError(t, err)
+			backend, err := CreateStorageBackend(config)
+			require.NoError(t, err)
+
+			older := testVSAEnvelope(t, ref, time.Now().Add(-time.Hour))
+			newer := testVSAEnvelope(t, ref, time.Now())
+			require.NoError(t, backend.Upload(context.Background(), older))
+			require.NoError(t, backend.Upload(context.Background(), newer))
+
+			keys := store.keys()
+			assert.Len(t, keys, 3)
+			for _, k := range keys {
+				assert.True(t, strings.HasPrefix(k, "/bucket/release/sha256/"+hash+"/"), k)
+			}
+			assert.Contains(t, keys, "/bucket/release/sha256/"+hash+"/latest.json")
+
+			for _, a := range store.authorization {
+				if tt.auth == "" {
+					assert.Empty(t, a)
+				} else {
+					assert.True(t, strings.HasPrefix(a, tt.auth), a)
+				}
+			}
+
+			retriever := CreateRetrieverFromUploadFlags([]string{flag})
+			require.IsType(t, &HTTPVSARetriever{}, retriever)
+
+			envelope, err := retriever.RetrieveVSA(context.Background(), ref.String())
+			require.NoError(t, err)
+
+			var expected ssldsse.Envelope
+			require.NoError(t, json.Unmarshal(newer, &expected))
+			assert.Equal(t, &expected, envelope)
+
+			// the VSAs of other policies are kept apart
+			other, err := NewHTTPVSARetriever(&StorageConfig{BaseURL: url + "/bucket", Parameters: map[string]string{"prefix": "other"}})
+			require.NoError(t, err)
+			_, err = other.RetrieveVSA(context.Background(), ref.DigestStr())
+			assert.ErrorContains(t, err, "no VSA found in the HTTP store for image digest: "+ref.DigestStr())
+
+			envelopes, err := retriever.(VSALister).ListVSAs(context.Background(), ref.String())
+			if tt.backend != "s3" {
+				assert.EqualError(t, err, "listing VSAs is not supported by the HTTP store")
+				return
+			}
+			require.NoError(t, err)
+
+			var expectedOlder ssldsse.Envelope
+			require.NoError(t, json.Unmarshal(older, &expectedOlder))
+			assert.ElementsMatch(t, []*ssldsse.Envelope{&expectedOlder, &expected}, envelopes)
+		})
+	}
+}
+
+func TestHTTPBackendErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+	}))
+	t.Cleanup(server.Close)
+
+	ref, err := name.NewDigest("registry.io/org/image@sha256:" + strings.Repeat("b", 64))
+	require.NoError(t, err)
+
+	backend, err := NewHTTPBackend(&StorageConfig{BaseURL: server.URL})
+	require.NoError(t, err)
+
+	err = backend.Upload(context.Background(), testVSAEnvelope(t, ref, time.Now()))
+	assert.ErrorContains(t, err, "403 Forbidden: AccessDenied")
+
+	err = backend.Upload(context.Background(), []byte("not an envelope"))
+	assert.ErrorContains(t, err, "failed to parse DSSE envelope")
+
+	retriever, err := NewHTTPVSARetriever(&StorageConfig{BaseURL: server.URL})
+	require.NoError(t, err)
+
+	_, err = retriever.RetrieveVSA(context.Background(), ref.DigestStr())
+	assert.ErrorContains(t, err, "403 Forbidden: AccessDenied")
+
+	_, err = retriever.RetrieveVSA(context.Background(), "registry.io/org/image:latest")
+	assert.ErrorContains(t, err, "does not contain a valid image digest")
+}
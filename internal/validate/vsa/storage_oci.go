@@ -0,0 +1,278 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vsa
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/conforma/cli/internal/utils/oci"
+)
+
+const (
+	// VSAArtifactType is the artifact type of the VSA referrers, set as the
+	// media type of the config so registries report it as the artifactType
+	VSAArtifactType = "application/vnd.conforma.vsa.v1+json"
+	// DSSEMediaType is the media type of the layer holding the DSSE envelope,
+	// same as used by cosign for attestations
+	DSSEMediaType = "application/vnd.dsse.envelope.v1+json"
+
+	// predicateTypeAnnotation is the annotation cosign uses on the layers of
+	// the attestation images to denote the predicate type
+	predicateTypeAnnotation = "predicateType"
+	createdAnnotation       = "org.opencontainers.image.created"
+)
+
+// OCIBackend implements VSA storage in an OCI registry, the VSA is attached to
+// the image it describes as a referrer. If the registry does not accept the
+// referrer, the VSA is appended to the attestations stored using the cosign
+// sha256-<digest>.att tag convention.
+type OCIBackend struct {
+	// repository to store the VSAs in, if empty the repository of the image
+	// the VSA describes is used
+	repository string
+}
+
+// NewOCIBackend creates a new OCI registry storage backend
+func NewOCIBackend(config *StorageConfig) (StorageBackend, error) {
+	repository := config.BaseURL
+
+	for key, value := range config.Parameters {
+		switch key {
+		case "repository", "repo":
+			repository = value
+		default:
+			log.Warnf("[VSA] OCI backend: ignoring unknown parameter '%s'", key)
+		}
+	}
+
+	if repository != "" {
+		if _, err := name.NewRepository(repository); err != nil {
+			return nil, fmt.Errorf("invalid OCI repository %q: %w", repository, err)
+		}
+	}
+
+	return &OCIBackend{repository: repository}, nil
+}
+
+// Name returns the backend name
+func (o *OCIBackend) Name() string {
+	if o.repository == "" {
+		return "OCI (image repository)"
+	}
+
+	return fmt.Sprintf("OCI (%s)", o.repository)
+}
+
+// Upload attaches the VSA envelope to each of the images in its subject
+func (o *OCIBackend) Upload(ctx context.Context, envelopeContent []byte) error {
+	subjects, err := envelopeSubjects(envelopeContent)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range subjects {
+		original, err := subjectDigest(s)
+		if err != nil {
+			return err
+		}
+
+		target := original
+		if o.repository != "" {
+			repo, err := name.NewRepository(o.repository)
+			if err != nil {
+				return fmt.Errorf("invalid OCI repository %q: %w", o.repository, err)
+			}
+			target = repo.Digest(original.DigestStr())
+		}
+
+		if err := attachVSA(ctx, target, original, envelopeContent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// envelopeSubjects returns the subjects of the in-toto statement in the DSSE
+// envelope
+func envelopeSubjects(envelopeContent []byte) ([]Subject, error) {
+	var envelope ssldsse.Envelope
+	if err := json.Unmarshal(envelopeContent, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse DSSE envelope: %w", err)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode DSSE payload: %w", err)
+	}
+
+	var statement struct {
+		Subject []Subject `json:"subject"`
+	}
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return nil, fmt.Errorf("failed to parse in-toto statement: %w", err)
+	}
+
+	if len(statement.Subject) == 0 {
+		return nil, errors.New("the VSA has no subject")
+	}
+
+	return statement.Subject, nil
+}
+
+// envelopePredicateType returns the predicate type of the in-toto statement in
+// the DSSE envelope, defaulting to the Conforma VSA predicate type
+func envelopePredicateType(envelopeContent []byte) string {
+	var envelope ssldsse.Envelope
+	if err := json.Unmarshal(envelopeContent, &envelope); err != nil {
+		return PredicateType
+	}
+
+	s, err := parseStatement(&envelope)
+	if err != nil || s.PredicateType == "" {
+		return PredicateType
+	}
+
+	return s.PredicateType
+}
+
+// subjectDigest returns the image reference, pinned to the digest, of the
+// in-toto statement subject
+func subjectDigest(s Subject) (name.Digest, error) {
+	digest, ok := s.Digest["sha256"]
+	if !ok {
+		return name.Digest{}, fmt.Errorf("subject %q has no sha256 digest", s.Name)
+	}
+
+	ref, err := name.ParseReference(s.Name)
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("subject %q is not an image reference: %w", s.Name, err)
+	}
+
+	return ref.Context().Digest("sha256:" + digest), nil
+}
+
+// attachVSA pushes the VSA envelope as a referrer of the target image, falling
+// back to the cosign attestation tag if the registry does not accept it. The
+// original reference is used to describe the subject when the target
+// repository does not contain the image.
+func attachVSA(ctx context.Context, target, original name.Digest, envelopeContent []byte) error {
+	opts := oci.CreateRemoteOptions(ctx)
+
+	subject, err := remote.Head(target, opts...)
+	if err != nil && target.Context() != original.Context() {
+		subject, err = remote.Head(original, opts...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to describe the VSA subject %s: %w", original, err)
+	}
+
+	img, err := vsaReferrer(*subject, envelopeContent)
+	if err != nil {
+		return err
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return err
+	}
+
+	err = remote.Write(target.Context().Digest(digest.String()), img, opts...)
+	if err == nil {
+		log.WithFields(log.Fields{
+			"subject":  target.String(),
+			"referrer": digest.String(),
+		}).Info("[VSA] Successfully attached VSA to the image")
+		return nil
+	}
+
+	log.Debugf("[VSA] Unable to attach the VSA as a referrer of %s, falling back to the attestation tag: %v", target, err)
+
+	tag := attestationTag(target)
+	base, err := remote.Image(tag, opts...)
+	if err != nil {
+		var terr *transport.Error
+		if !errors.As(err, &terr) || terr.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("failed to fetch the attestations of %s: %w", target, err)
+		}
+		base = mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+	}
+
+	att, err := mutate.Append(base, mutate.Addendum{
+		Layer:       static.NewLayer(envelopeContent, DSSEMediaType),
+		Annotations: map[string]string{predicateTypeAnnotation: envelopePredicateType(envelopeContent)},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := remote.Write(tag, att, opts...); err != nil {
+		return fmt.Errorf("failed to push the VSA to %s: %w", tag, err)
+	}
+
+	log.WithFields(log.Fields{
+		"subject": target.String(),
+		"tag":     tag.String(),
+	}).Info("[VSA] Successfully attached VSA to the image attestations")
+
+	return nil
+}
+
+// vsaReferrer returns the artifact holding the VSA envelope referring to the
+// given subject
+func vsaReferrer(subject v1.Descriptor, envelopeContent []byte) (v1.Image, error) {
+	img, err := mutate.Append(mutate.MediaType(empty.Image, types.OCIManifestSchema1), mutate.Addendum{
+		Layer:       static.NewLayer(envelopeContent, DSSEMediaType),
+		Annotations: map[string]string{predicateTypeAnnotation: envelopePredicateType(envelopeContent)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	img = mutate.ConfigMediaType(img, VSAArtifactType)
+	img = mutate.Annotations(img, map[string]string{createdAnnotation: time.Now().UTC().Format(time.RFC3339)}).(v1.Image)
+
+	return mutate.Subject(img, v1.Descriptor{
+		MediaType: subject.MediaType,
+		Digest:    subject.Digest,
+		Size:      subject.Size,
+	}).(v1.Image), nil
+}
+
+// attestationTag returns the tag used by cosign to store the attestations of
+// the image with the given digest
+func attestationTag(ref name.Digest) name.Tag {
+	return ref.Context().Tag(strings.Replace(ref.DigestStr(), ":", "-", 1) + ".att")
+}
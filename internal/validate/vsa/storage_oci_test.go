@@ -0,0 +1,264 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vsa
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	golog "log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ecapi "github.com/conforma/crds/api/v1alpha1"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOCITestRegistry starts an in-process registry, the handler can be
+// wrapped to alter its behavior
+func newOCITestRegistry(t *testing.T, referrers bool, wrap func(http.Handler) http.Handler) string {
+	t.Helper()
+
+	var handler http.Handler = registry.New(registry.Logger(golog.New(io.Discard, "", 0)), registry.WithReferrersSupport(referrers))
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func pushTestImage(t *testing.T, repository string) name.Digest {
+	t.Helper()
+
+	img, err := random.Image(512, 1)
+	require.NoError(t, err)
+
+	ref, err := name.ParseReference(repository + ":latest")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+
+	digest, err := img.Digest()
+	require.NoError(t, err)
+
+	return ref.Context().Digest(digest.String())
+}
+
+func testVSAEnvelope(t *testing.T, ref name.Digest, timestamp time.Time) []byte {
+	t.Helper()
+
+	statement, err := json.Marshal(map[string]any{
+		"_type":         "https://in-toto.io/Statement/v0.1",
+		"predicateType": PredicateType,
+		"subject": []Subject{
+			{Name: ref.Context().String(), Digest: map[string]string{"sha256": strings.TrimPrefix(ref.DigestStr(), "sha256:")}},
+		},
+		"predicate": map[string]any{
+			"timestamp": timestamp.UTC().Format(time.RFC3339),
+			"status":    "passed",
+		},
+	})
+	require.NoError(t, err)
+
+	envelope, err := json.Marshal(ssldsse.Envelope{
+		PayloadType: "application/vnd.in-toto+json",
+		Payload:     base64.StdEncoding.EncodeToString(statement),
+		Signatures:  []ssldsse.Signature{{KeyID: "key", Sig: "c2lnbmF0dXJl"}},
+	})
+	require.NoError(t, err)
+
+	return envelope
+}
+
+func TestNewOCIBackend(t *testing.T) {
+	backend, err := NewOCIBackend(&StorageConfig{Backend: "oci"})
+	require.NoError(t, err)
+	assert.Equal(t, "OCI (image repository)", backend.Name())
+
+	backend, err = NewOCIBackend(&StorageConfig{Backend: "oci", Parameters: map[string]string{"repository": "registry.io/vsas"}})
+	require.NoError(t, err)
+	assert.Equal(t, "OCI (registry.io/vsas)", backend.Name())
+
+	_, err = NewOCIBackend(&StorageConfig{Backend: "oci", BaseURL: "registry.io/In Valid"})
+	assert.ErrorContains(t, err, `invalid OCI repository "registry.io/In Valid"`)
+
+	_, err = NewOCIVSARetriever("registry.io/In Valid")
+	assert.ErrorContains(t, err, `invalid OCI repository "registry.io/In Valid"`)
+}
+
+func TestOCIBackendRoundTrip(t *testing.T) {
+	// rejects the manifests with a subject, as some registries do
+	noSubjects := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/manifests/") {
+				body, _ := io.ReadAll(r.Body)
+				if bytes.Contains(body, []byte(`"subject"`)) {
+					http.Error(w, `{"errors":[{"code":"MANIFEST_INVALID"}]}`, http.StatusBadRequest)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	cases := []struct {
+		name      string
+		referrers bool
+		wrap      func(http.Handler) http.Handler
+		separate  bool
+	}{
+		{name: "referrers API", referrers: true},
+		{name: "referrers tag schema", referrers: false},
+		{name: "attestation tag", referrers: false, wrap: noSubjects},
+		{name: "separate repository", referrers: true, separate: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			host := newOCITestRegistry(t, c.referrers, c.wrap)
+			ref := pushTestImage(t, host+"/org/image")
+
+			config := &StorageConfig{Backend: "oci"}
+			if c.separate {
+				config.BaseURL = host + "/org/vsas"
+			}
+			backend, err := NewOCIBackend(config)
+			require.NoError(t, err)
+
+			older := testVSAEnvelope(t, ref, time.Now().Add(-time.Hour))
+			newer := testVSAEnvelope(t, ref, time.Now())
+
+			ctx := context.Background()
+			require.NoError(t, backend.Upload(ctx, newer))
+			require.NoError(t, backend.Upload(ctx, older))
+
+			retriever, err := NewOCIVSARetriever(config.BaseURL)
+			require.NoError(t, err)
+
+			envelope, err := retriever.RetrieveVSA(ctx, ref.String())
+			require.NoError(t, err)
+
+			var expected, expectedOlder ssldsse.Envelope
+			require.NoError(t, json.Unmarshal(newer, &expected))
+			require.NoError(t, json.Unmarshal(older, &expectedOlder))
+			assert.Equal(t, &expected, envelope)
+
+			candidates, err := retriever.RetrieveVSACandidates(ctx, ref.String())
+			require.NoError(t, err)
+			assert.Equal(t, []*ssldsse.Envelope{&expected, &expectedOlder}, candidates)
+
+			if c.separate {
+				// by digest from the configured repository
+				envelope, err = retriever.RetrieveVSA(ctx, ref.DigestStr())
+				require.NoError(t, err)
+				assert.Equal(t, &expected, envelope)
+			}
+		})
+	}
+}
+
+func TestOCIVSARetrieverErrors(t *testing.T) {
+	host := newOCITestRegistry(t, true, nil)
+	ref := pushTestImage(t, host+"/org/image")
+
+	retriever, err := NewOCIVSARetriever("")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, err = retriever.RetrieveVSA(ctx, "")
+	assert.EqualError(t, err, "identifier cannot be empty")
+
+	_, err = retriever.RetrieveVSA(ctx, ref.DigestStr())
+	assert.EqualError(t, err, "a repository is required to retrieve VSAs by image digest: "+ref.DigestStr())
+
+	// tags are resolved to the digest
+	_, err = retriever.RetrieveVSA(ctx, host+"/org/image:latest")
+	assert.EqualError(t, err, "no VSA found in the registry for image digest: "+ref.DigestStr())
+}
+
+// fakeCandidateRetriever retrieves the candidates in the given order
+type fakeCandidateRetriever struct {
+	candidates []*ssldsse.Envelope
+}
+
+func (f fakeCandidateRetriever) RetrieveVSA(_ context.Context, _ string) (*ssldsse.Envelope, error) {
+	return f.candidates[0], nil
+}
+
+func (f fakeCandidateRetriever) RetrieveVSACandidates(_ context.Context, _ string) ([]*ssldsse.Envelope, error) {
+	return f.candidates, nil
+}
+
+func TestCheckExistingVSAVerifiedCandidate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	now := time.Now().UTC()
+	vsa := func(k *ecdsa.PrivateKey, age time.Duration) *ssldsse.Envelope {
+		return signedVSAEnvelope(t, k, digest, Predicate{
+			Policy:    ecapi.EnterpriseContractPolicySpec{PublicKey: "cosign.pub"},
+			Timestamp: now.Add(-age).Format(time.RFC3339),
+			Status:    "passed",
+		})
+	}
+
+	valid := vsa(key, time.Hour)
+	// attached by someone else to hide the valid VSA
+	forged := vsa(otherKey, 0)
+
+	publicKey := writePublicKey(t, key)
+	ctx := context.Background()
+	imageRef := "registry.io/org/image@" + digest
+
+	checker := NewVSAChecker(fakeCandidateRetriever{candidates: []*ssldsse.Envelope{forged, valid}})
+
+	result, err := checker.CheckExistingVSAWithVerification(ctx, imageRef, 24*time.Hour, true, publicKey)
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.True(t, result.SignatureVerified)
+	assert.Equal(t, valid, result.Envelope)
+
+	// without verification the newest VSA is used
+	result, err = checker.CheckExistingVSAWithVerification(ctx, imageRef, 24*time.Hour, false, "")
+	require.NoError(t, err)
+	assert.Equal(t, forged, result.Envelope)
+
+	checker = NewVSAChecker(fakeCandidateRetriever{candidates: []*ssldsse.Envelope{forged}})
+	_, err = checker.CheckExistingVSAWithVerification(ctx, imageRef, 24*time.Hour, true, publicKey)
+	assert.ErrorContains(t, err, "VSA signature verification failed: ")
+}
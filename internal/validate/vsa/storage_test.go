@@ -52,6 +52,29 @@ func TestParseStorageFlag(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name:        "oci repository",
+			storageFlag: "oci@localhost:5000/org/vsas",
+			expected: &StorageConfig{
+				Backend:    "oci",
+				BaseURL:    "localhost:5000/org/vsas",
+				Parameters: map[string]string{},
+			},
+			expectError: false,
+		},
+		{
+			name:        "s3 with prefix",
+			storageFlag: "s3@https://s3.example.com/vsas?prefix=release&region=eu-west-1",
+			expected: &StorageConfig{
+				Backend: "s3",
+				BaseURL: "https://s3.example.com/vsas",
+				Parameters: map[string]string{
+					"prefix": "release",
+					"region": "eu-west-1",
+				},
+			},
+			expectError: false,
+		},
 		{
 			name:        "rekor with custom parameters",
 			storageFlag: "rekor@https://custom.rekor.com?timeout=30s&retries=5",
@@ -130,10 +153,37 @@ func TestCreateStorageBackend(t *testing.T) {
 			expectType:  "*vsa.LocalBackend",
 		},
 		{
-			name: "unsupported backend",
+			name: "oci backend",
+			config: &StorageConfig{
+				Backend: "oci",
+				BaseURL: "registry.io/vsas",
+			},
+			expectError: false,
+			expectType:  "*vsa.OCIBackend",
+		},
+		{
+			name: "http backend",
+			config: &StorageConfig{
+				Backend: "http",
+				BaseURL: "https://vsa.example.com/store",
+			},
+			expectError: false,
+			expectType:  "*vsa.HTTPBackend",
+		},
+		{
+			name: "s3 backend",
 			config: &StorageConfig{
 				Backend: "s3",
-				BaseURL: "s3://bucket/path",
+				BaseURL: "https://s3.example.com/bucket",
+			},
+			expectError: false,
+			expectType:  "*vsa.HTTPBackend",
+		},
+		{
+			name: "unsupported backend",
+			config: &StorageConfig{
+				Backend: "gcs",
+				BaseURL: "gs://bucket/path",
 			},
 			expectError: true,
 			expectType:  "",
@@ -63,6 +63,20 @@ func ValidateVSAAndComparePolicy(ctx context.Context, identifier string, data *V
 		return nil, fmt.Errorf("failed to check existing VSA: %w", err)
 	}
 
+	if result.Revoked {
+		message := "VSA has been revoked"
+		if result.Revocation != nil && result.Revocation.Reason != "" {
+			message = fmt.Sprintf("VSA has been revoked: %s", result.Revocation.Reason)
+		}
+		return &ValidationResult{
+			Passed:            false,
+			Message:           message,
+			SignatureVerified: result.SignatureVerified,
+			PredicateOutcome:  "",
+			ReasonCode:        "revoked",
+		}, nil
+	}
+
 	if !result.Found {
 		return &ValidationResult{
 			Passed:            false,
@@ -21,16 +21,19 @@ import (
 	"crypto"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	ecapi "github.com/conforma/crds/api/v1alpha1"
 	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
@@ -41,6 +44,7 @@ import (
 	"github.com/conforma/cli/internal/applicationsnapshot"
 	"github.com/conforma/cli/internal/utils"
 	validate_utils "github.com/conforma/cli/internal/validate"
+	"github.com/conforma/cli/pkg/schema"
 )
 
 // ComponentSummary represents the summary information for a single component
@@ -87,7 +91,7 @@ type ValidationResult struct {
 	PredicateOutcome  string `json:"predicate_outcome,omitempty"` // Outcome from VSA predicate
 
 	// Structured fields for reliable extraction (prefer over message parsing)
-	ReasonCode string      `json:"reason_code,omitempty"` // Structured reason code: "policy_mismatch", "predicate_failed", "no_vsa", "expired", "retrieval_failed"
+	ReasonCode string      `json:"reason_code,omitempty"` // Structured reason code: "policy_mismatch", "predicate_failed", "no_vsa", "revoked", "expired", "retrieval_failed"
 	PolicyDiff *PolicyDiff `json:"policy_diff,omitempty"` // Policy difference counts (only set when ReasonCode is "policy_mismatch")
 }
 
@@ -342,6 +346,18 @@ func NewWriter() *Writer {
 func (w *Writer) WritePredicate(predicate *Predicate) (string, error) {
 	log.Infof("Writing VSA for images: %v", predicate.ImageRefs)
 
+	return w.write(predicate, predicate.Summary.Component.Name)
+}
+
+// WriteSLSAPredicate writes the SLSA predicate of the named component as a JSON
+// file to a temp directory and returns the path.
+func (w *Writer) WriteSLSAPredicate(predicate *SLSAPredicate, componentName string) (string, error) {
+	log.Infof("Writing SLSA VSA for image: %s", predicate.ResourceURI)
+
+	return w.write(predicate, componentName)
+}
+
+func (w *Writer) write(predicate any, componentName string) (string, error) {
 	// Serialize with indent
 	data, err := json.MarshalIndent(predicate, "", "  ")
 	if err != nil {
@@ -367,9 +383,8 @@ func (w *Writer) WritePredicate(predicate *Predicate) (string, error) {
 	}
 
 	// Write to file with same naming convention as old VSA
-	componentName := "unknown"
-	if predicate.Summary.Component.Name != "" {
-		componentName = predicate.Summary.Component.Name
+	if componentName == "" {
+		componentName = "unknown"
 	}
 	filename := fmt.Sprintf("vsa-%s.json", componentName)
 	filepath := filepath.Join(tempDir, filename)
@@ -390,6 +405,8 @@ type VSALookupResult struct {
 	Timestamp         time.Time
 	Envelope          *ssldsse.Envelope // Store the envelope for signature verification
 	SignatureVerified bool              // Whether signature verification was performed and succeeded
+	Revoked           bool              // Whether the VSA has been revoked, in which case it is not Found
+	Revocation        *Revocation       // The revocation of the VSA, if revoked
 }
 
 // VSAChecker handles checking for existing VSAs using any VSARetriever
@@ -422,6 +439,8 @@ type Subject struct {
 // The function handles different payload formats:
 // 1. In-toto Statement wrapped in DSSE envelope
 // 2. Raw Predicate directly in DSSE payload
+// The predicate can be in either the Conforma or the SLSA verification summary
+// format, the latter is mapped onto the Predicate.
 func ParseVSAContent(envelope *ssldsse.Envelope) (*Predicate, error) {
 	// Decode the base64-encoded payload
 	payloadBytes, err := base64.StdEncoding.DecodeString(envelope.Payload)
@@ -429,23 +448,77 @@ func ParseVSAContent(envelope *ssldsse.Envelope) (*Predicate, error) {
 		return nil, fmt.Errorf("failed to decode DSSE payload: %w", err)
 	}
 
-	var predicate Predicate
-
-	// Try to parse the payload as an in-toto statement first
-	var statement InTotoStatement
+	// Try to parse the payload as an in-toto statement first, otherwise the
+	// payload is directly the predicate
+	predicateBytes := payloadBytes
+	var statement struct {
+		PredicateType string          `json:"predicateType"`
+		Predicate     json.RawMessage `json:"predicate"`
+	}
 	if err := json.Unmarshal(payloadBytes, &statement); err == nil && statement.PredicateType != "" {
-		// It's an in-toto statement, the predicate is already unmarshaled
-		predicate = statement.Predicate
-	} else {
-		// The payload is directly the predicate
-		if err := json.Unmarshal(payloadBytes, &predicate); err != nil {
-			return nil, fmt.Errorf("failed to parse VSA predicate from DSSE payload: %w", err)
+		predicateBytes = statement.Predicate
+		if statement.PredicateType == SLSAPredicateType {
+			return parseSLSAPredicate(predicateBytes)
 		}
+	} else if isSLSAPredicate(predicateBytes) {
+		return parseSLSAPredicate(predicateBytes)
+	}
+
+	if err := validateSchema(schema.VSA_Predicate, predicateBytes); err != nil {
+		return nil, err
+	}
+
+	var predicate Predicate
+	if err := json.Unmarshal(predicateBytes, &predicate); err != nil {
+		return nil, fmt.Errorf("failed to parse VSA predicate from DSSE payload: %w", err)
 	}
 
 	return &predicate, nil
 }
 
+// validateSchema validates the VSA predicate against the given VSA predicate
+// JSON schema, the returned error lists all the fields that do not conform.
+func validateSchema(predicateSchema *jsonschema.Schema, predicateBytes []byte) error {
+	var predicate any
+	if err := json.Unmarshal(predicateBytes, &predicate); err != nil {
+		return fmt.Errorf("failed to parse VSA predicate from DSSE payload: %w", err)
+	}
+
+	err := predicateSchema.Validate(predicate)
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return fmt.Errorf("unable to validate VSA predicate: %w", err)
+	}
+
+	violations := schemaViolations(validationErr)
+	slices.Sort(violations)
+
+	return fmt.Errorf("VSA predicate does not conform to schema: %s", strings.Join(violations, "; "))
+}
+
+// schemaViolations collects the messages of the leaf validation errors, i.e.
+// the ones pointing at the offending field, prefixed with the field location.
+func schemaViolations(err *jsonschema.ValidationError) []string {
+	if len(err.Causes) == 0 {
+		location := err.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		return []string{fmt.Sprintf("%s: %s", location, err.Message)}
+	}
+
+	var violations []string
+	for _, cause := range err.Causes {
+		violations = append(violations, schemaViolations(cause)...)
+	}
+
+	return violations
+}
+
 // CheckExistingVSAWithVerification looks up existing VSAs for an image and performs all checks including optional signature verification
 func (c *VSAChecker) CheckExistingVSAWithVerification(ctx context.Context, imageRef string, expirationThreshold time.Duration, verifySignature bool, publicKeyPath string) (*VSALookupResult, error) {
 	result := &VSALookupResult{
@@ -460,10 +533,26 @@ func (c *VSAChecker) CheckExistingVSAWithVerification(ctx context.Context, image
 		return nil, fmt.Errorf("VSA retriever not available")
 	}
 
-	// 1. SINGLE VSA RETRIEVAL
-	envelope, err := c.retriever.RetrieveVSA(ctx, imageRef)
+	if verifySignature && publicKeyPath == "" {
+		return nil, fmt.Errorf("public key path required for signature verification")
+	}
+
+	// 1. SINGLE VSA RETRIEVAL, out of the candidates the newest one with a valid
+	// signature is used so that newer VSAs with an invalid signature cannot hide
+	// it
+	var envelope *ssldsse.Envelope
+	var err error
+	candidateRetriever, hasCandidates := c.retriever.(VSACandidateRetriever)
+	if verifySignature && hasCandidates {
+		envelope, err = retrieveVerifiedVSA(ctx, candidateRetriever, imageRef, publicKeyPath)
+	} else {
+		envelope, err = c.retriever.RetrieveVSA(ctx, imageRef)
+		if err != nil {
+			err = fmt.Errorf("failed to retrieve VSA envelope: %w", err)
+		}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve VSA envelope: %w", err)
+		return nil, err
 	}
 
 	if envelope == nil {
@@ -476,12 +565,10 @@ func (c *VSAChecker) CheckExistingVSAWithVerification(ctx context.Context, image
 
 	// 2. OPTIONAL signature verification (if requested) - MUST happen before payload extraction
 	if verifySignature {
-		if publicKeyPath == "" {
-			return nil, fmt.Errorf("public key path required for signature verification")
-		}
-
-		if err := verifyVSASignatureFromEnvelope(ctx, envelope, publicKeyPath); err != nil {
-			return nil, fmt.Errorf("VSA signature verification failed: %w", err)
+		if !hasCandidates {
+			if err := verifyVSASignatureFromEnvelope(ctx, envelope, publicKeyPath); err != nil {
+				return nil, fmt.Errorf("VSA signature verification failed: %w", err)
+			}
 		}
 
 		result.SignatureVerified = true
@@ -500,6 +587,29 @@ func (c *VSAChecker) CheckExistingVSAWithVerification(ctx context.Context, image
 		return nil, fmt.Errorf("failed to parse VSA timestamp: %w", err)
 	}
 
+	// 5. A revoked VSA is treated as if there was no VSA, revocations are looked
+	// up if the retriever is able to list the records of the image. Only the
+	// revocations signed with the key that signed the VSA are honoured.
+	if lister, ok := c.retriever.(VSALister); ok {
+		revocationKey := ""
+		if verifySignature {
+			revocationKey = publicKeyPath
+		}
+
+		revocation, err := FindRevocation(ctx, lister, imageRef, envelope, recordTime, revocationKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check VSA revocation: %w", err)
+		}
+
+		if revocation != nil {
+			log.Debugf("VSA for image %s has been revoked", imageRef)
+			result.Revoked = true
+			result.Revocation = revocation
+			result.Timestamp = recordTime
+			return result, nil
+		}
+	}
+
 	result.Found = true
 	result.Timestamp = recordTime
 	result.Expired = IsVSAExpired(recordTime, expirationThreshold)
@@ -517,6 +627,29 @@ func (c *VSAChecker) CheckExistingVSAWithVerification(ctx context.Context, image
 	return result, nil
 }
 
+// retrieveVerifiedVSA returns the newest of the candidate VSAs with a signature
+// verified by the public key
+func retrieveVerifiedVSA(ctx context.Context, retriever VSACandidateRetriever, imageRef, publicKeyPath string) (*ssldsse.Envelope, error) {
+	candidates, err := retriever.RetrieveVSACandidates(ctx, imageRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve VSA envelope: %w", err)
+	}
+
+	var verifyErr error
+	for _, candidate := range candidates {
+		if verifyErr = verifyVSASignatureFromEnvelope(ctx, candidate, publicKeyPath); verifyErr == nil {
+			return candidate, nil
+		}
+		log.Debugf("Skipping VSA with an invalid signature for image %s: %v", imageRef, verifyErr)
+	}
+
+	if verifyErr != nil {
+		return nil, fmt.Errorf("VSA signature verification failed: %w", verifyErr)
+	}
+
+	return nil, nil
+}
+
 // CheckExistingVSA looks up existing VSAs for an image and determines if they're valid/expired
 // This method is kept for backward compatibility
 func (c *VSAChecker) CheckExistingVSA(ctx context.Context, imageRef string, expirationThreshold time.Duration) (*VSALookupResult, error) {
@@ -604,6 +737,29 @@ func CreateRetrieverFromUploadFlags(vsaUpload []string) VSARetriever {
 			retriever := NewFileVSARetrieverWithOSFs(basePath)
 			log.Debugf("Created File VSA retriever with base path: %s", basePath)
 			return retriever
+		case "oci":
+			retriever, err := NewOCIVSARetriever(config.BaseURL)
+			if err != nil {
+				log.Debugf("Failed to create OCI VSA retriever: %v", err)
+				continue
+			}
+
+			log.Debugf("Created OCI VSA retriever: %s", config.BaseURL)
+			return retriever
+		case "http", "s3":
+			var retriever *HTTPVSARetriever
+			if strings.ToLower(config.Backend) == "s3" {
+				retriever, err = NewS3VSARetriever(config)
+			} else {
+				retriever, err = NewHTTPVSARetriever(config)
+			}
+			if err != nil {
+				log.Debugf("Failed to create %s VSA retriever: %v", config.Backend, err)
+				continue
+			}
+
+			log.Debugf("Created %s VSA retriever: %s", config.Backend, config.BaseURL)
+			return retriever
 		default:
 			log.Debugf("No VSA retriever available for backend: %s", config.Backend)
 		}
@@ -19,6 +19,7 @@ package vsa
 import (
 	"context"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -528,6 +529,21 @@ func TestCreateRetrieverFromUploadFlags(t *testing.T) {
 			vsaUpload: []string{"local@/tmp/vsa", "rekor@https://test-rekor.dev"},
 			expectNil: false,
 		},
+		{
+			name:      "oci backend",
+			vsaUpload: []string{"local@/tmp/vsa", "oci@registry.io/org/vsas"},
+			expectNil: false,
+		},
+		{
+			name:      "s3 backend",
+			vsaUpload: []string{"s3@https://s3.example.com/bucket?prefix=release"},
+			expectNil: false,
+		},
+		{
+			name:      "invalid http backend is ignored",
+			vsaUpload: []string{"http@ftp://vsa.example.com"},
+			expectNil: true,
+		},
 		{
 			name:      "empty vsa upload flags",
 			vsaUpload: []string{},
@@ -1420,3 +1436,106 @@ func TestExtractDigestFromImageRef(t *testing.T) {
 		})
 	}
 }
+
+func TestParseVSAContent(t *testing.T) {
+	envelope := func(payload string) *ssldsse.Envelope {
+		return &ssldsse.Envelope{
+			PayloadType: "application/vnd.in-toto+json",
+			Payload:     base64.StdEncoding.EncodeToString([]byte(payload)),
+		}
+	}
+
+	validPredicate := `{
+		"policy": {"sources": [{"policy": ["oci::quay.io/policy"]}]},
+		"policySource": "policy.yaml",
+		"imageRefs": ["registry.io/repository/image@sha256:abc"],
+		"timestamp": "2024-03-21T12:00:00Z",
+		"status": "passed",
+		"verifier": "conforma",
+		"summary": {
+			"violations": 0,
+			"warnings": 1,
+			"successes": 10,
+			"Components": [{"Name": "image", "ImageRef": "registry.io/repository/image@sha256:abc", "Violations": 0, "Warnings": 1, "Successes": 10}],
+			"component": {"name": "image", "containerImage": "registry.io/repository/image@sha256:abc", "source": null}
+		},
+		"publicKey": ""
+	}`
+
+	expected := &Predicate{
+		Policy: ecapi.EnterpriseContractPolicySpec{
+			Sources: []ecapi.Source{{Policy: []string{"oci::quay.io/policy"}}},
+		},
+		PolicySource: "policy.yaml",
+		ImageRefs:    []string{"registry.io/repository/image@sha256:abc"},
+		Timestamp:    "2024-03-21T12:00:00Z",
+		Status:       "passed",
+		Verifier:     "conforma",
+		Summary: VSASummary{
+			Warnings:  1,
+			Successes: 10,
+			Components: []ComponentDetail{
+				{Name: "image", ImageRef: "registry.io/repository/image@sha256:abc", Warnings: 1, Successes: 10},
+			},
+			Component: ComponentSummary{Name: "image", ContainerImage: "registry.io/repository/image@sha256:abc"},
+		},
+	}
+
+	t.Run("in-toto statement", func(t *testing.T) {
+		predicate, err := ParseVSAContent(envelope(`{
+			"_type": "https://in-toto.io/Statement/v0.1",
+			"predicateType": "https://conforma.dev/verification_summary/v1",
+			"subject": [{"name": "registry.io/repository/image", "digest": {"sha256": "abc"}}],
+			"predicate": ` + validPredicate + `
+		}`))
+		require.NoError(t, err)
+		assert.Equal(t, expected, predicate)
+	})
+
+	t.Run("raw predicate", func(t *testing.T) {
+		predicate, err := ParseVSAContent(envelope(validPredicate))
+		require.NoError(t, err)
+		assert.Equal(t, expected, predicate)
+	})
+
+	cases := []struct {
+		name      string
+		predicate string
+		err       string
+	}{
+		{
+			name:      "missing required fields",
+			predicate: `{"status": "passed"}`,
+			err:       "VSA predicate does not conform to schema: /: missing properties: 'policy', 'timestamp'",
+		},
+		{
+			name: "invalid fields",
+			predicate: `{
+				"policy": {"sources": {}},
+				"timestamp": "yesterday",
+				"status": "unknown",
+				"imageRefs": [""],
+				"summary": {"violations": -1, "Components": [{"Successes": 1.5}]}
+			}`,
+			err: "VSA predicate does not conform to schema: " +
+				"/imageRefs/0: length must be >= 1, but got 0; " +
+				"/policy/sources: expected array or null, but got object; " +
+				`/status: value must be one of "passed", "failed"; ` +
+				"/summary/Components/0/Successes: expected integer, but got number; " +
+				"/summary/violations: must be >= 0 but found -1; " +
+				"/timestamp: 'yesterday' is not valid 'date-time'",
+		},
+		{
+			name:      "not an object",
+			predicate: `[]`,
+			err:       "VSA predicate does not conform to schema: /: expected object, but got array",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ParseVSAContent(envelope(`{"predicateType": "https://conforma.dev/verification_summary/v1", "predicate": ` + c.predicate + `}`))
+			assert.EqualError(t, err, c.err)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+//go:build unit
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/stretchr/testify/assert"
+)
+
+var validCycloneDX = []byte(`{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "serialNumber": "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79",
+  "version": 1,
+  "metadata": {
+    "timestamp": "2024-03-21T12:00:00Z",
+    "component": {
+      "type": "container",
+      "name": "registry.io/repository/image"
+    }
+  },
+  "components": [
+    {
+      "type": "library",
+      "name": "openssl",
+      "version": "3.0.7",
+      "purl": "pkg:rpm/redhat/openssl@3.0.7",
+      "licenses": [
+        {"license": {"id": "Apache-2.0"}}
+      ]
+    }
+  ]
+}`)
+
+func checkCycloneDX(t *testing.T, patches ...string) {
+	for i, patch := range patches {
+		t.Run(fmt.Sprintf("case_%d", i), func(t *testing.T) {
+			j, err := jsonpatch.MergePatch(validCycloneDX, []byte(patch))
+			assert.NoError(t, err)
+
+			var v any
+			err = json.Unmarshal(j, &v)
+			assert.NoError(t, err)
+
+			err = CycloneDX_1_5.Validate(v)
+			snaps.MatchSnapshot(t, err)
+		})
+	}
+}
+
+func TestCycloneDXRequiredFields(t *testing.T) {
+	checkCycloneDX(t,
+		`{"bomFormat": null}`,
+		`{"specVersion": null}`,
+		`{"version": null}`,
+		`{}`,
+	)
+}
+
+func TestCycloneDXBOMFormat(t *testing.T) {
+	checkCycloneDX(t,
+		`{"bomFormat": "SPDX"}`,
+		`{"bomFormat": "CycloneDX"}`,
+	)
+}
+
+func TestCycloneDXSerialNumber(t *testing.T) {
+	checkCycloneDX(t,
+		`{"serialNumber": "3e671687-395b-41f5-a30f-a58921a69b79"}`,
+		`{"serialNumber": "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79"}`,
+	)
+}
+
+func TestCycloneDXComponents(t *testing.T) {
+	checkCycloneDX(t,
+		`{"components": {}}`,
+		`{"components": [{}]}`,
+		`{"components": [{"type": "package", "name": "openssl"}]}`,
+		`{"components": [{"type": "library", "name": "openssl", "unknown": true}]}`,
+		`{"components": [{"type": "library", "name": "openssl", "licenses": [{"license": {"id": "Not-A-License"}}]}]}`,
+		`{"components": [{"type": "library", "name": "openssl", "licenses": [{"license": {"name": "Custom"}}]}]}`,
+		`{"components": [{"type": "library", "name": "openssl", "licenses": [{"expression": "MIT OR Apache-2.0"}]}]}`,
+	)
+}
+
+func TestCycloneDXAdditionalProperties(t *testing.T) {
+	checkCycloneDX(t,
+		`{"unknown": true}`,
+		`{"$schema": "http://cyclonedx.org/schema/bom-1.5.schema.json"}`,
+	)
+}
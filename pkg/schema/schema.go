@@ -29,14 +29,61 @@ var slsa_provenance_v0_2_json string
 //go:embed slsa_provenance_v1.json
 var slsa_provenance_v1_json string
 
+//go:embed spdx_2.3.json
+var spdx_2_3_json string
+
+//go:embed cyclonedx_bom_1.5.json
+var cyclonedx_bom_1_5_json string
+
+//go:embed cyclonedx_spdx.json
+var cyclonedx_spdx_json string
+
+//go:embed cyclonedx_jsf_0.82.json
+var cyclonedx_jsf_0_82_json string
+
+//go:embed vsa_predicate.json
+var vsa_predicate_json string
+
+//go:embed slsa_vsa_v1.json
+var slsa_vsa_v1_json string
+
 var SLSA_Provenance_v0_2 *jsonschema.Schema
 
 var SLSA_Provenance_v1 *jsonschema.Schema
 
+// SPDX_2_3 is the schema of the SPDX 2.3 JSON document, i.e. the predicate of
+// an SPDX SBOM attestation
+var SPDX_2_3 *jsonschema.Schema
+
+// CycloneDX_1_5 is the schema of the CycloneDX 1.5 JSON BOM, i.e. the predicate
+// of a CycloneDX SBOM attestation
+var CycloneDX_1_5 *jsonschema.Schema
+
+// VSA_Predicate is the schema of the predicate of the Verification Summary
+// Attestation produced by Conforma
+var VSA_Predicate *jsonschema.Schema
+
+// SLSA_VSA_v1 is the schema of the predicate of the SLSA Verification Summary
+// Attestation version 1.0
+var SLSA_VSA_v1 *jsonschema.Schema
+
 var SLSA_Provenance_v0_2_URI = "https://slsa.dev/provenance/v0.2"
 
 var SLSA_Provenance_v1_URI = "https://slsa.dev/provenance/v1"
 
+var SPDX_2_3_URI = "http://spdx.org/rdf/terms/2.3"
+
+var CycloneDX_1_5_URI = "http://cyclonedx.org/schema/bom-1.5.schema.json"
+
+// the CycloneDX BOM schema references these two by relative URIs
+var cycloneDX_SPDX_URI = "http://cyclonedx.org/schema/spdx.schema.json"
+
+var cycloneDX_JSF_0_82_URI = "http://cyclonedx.org/schema/jsf-0.82.schema.json"
+
+var VSA_Predicate_URI = "https://conforma.dev/verification_summary/v1"
+
+var SLSA_VSA_v1_URI = "https://slsa.dev/verification_summary/v1"
+
 func init() {
 	compiler := jsonschema.NewCompiler()
 	compiler.AssertFormat = true
@@ -50,4 +97,30 @@ func init() {
 		panic(err)
 	}
 	SLSA_Provenance_v1 = compiler.MustCompile(SLSA_Provenance_v1_URI)
+
+	if err := compiler.AddResource(SPDX_2_3_URI, strings.NewReader(spdx_2_3_json)); err != nil {
+		panic(err)
+	}
+	SPDX_2_3 = compiler.MustCompile(SPDX_2_3_URI)
+
+	if err := compiler.AddResource(cycloneDX_SPDX_URI, strings.NewReader(cyclonedx_spdx_json)); err != nil {
+		panic(err)
+	}
+	if err := compiler.AddResource(cycloneDX_JSF_0_82_URI, strings.NewReader(cyclonedx_jsf_0_82_json)); err != nil {
+		panic(err)
+	}
+	if err := compiler.AddResource(CycloneDX_1_5_URI, strings.NewReader(cyclonedx_bom_1_5_json)); err != nil {
+		panic(err)
+	}
+	CycloneDX_1_5 = compiler.MustCompile(CycloneDX_1_5_URI)
+
+	if err := compiler.AddResource(VSA_Predicate_URI, strings.NewReader(vsa_predicate_json)); err != nil {
+		panic(err)
+	}
+	VSA_Predicate = compiler.MustCompile(VSA_Predicate_URI)
+
+	if err := compiler.AddResource(SLSA_VSA_v1_URI, strings.NewReader(slsa_vsa_v1_json)); err != nil {
+		panic(err)
+	}
+	SLSA_VSA_v1 = compiler.MustCompile(SLSA_VSA_v1_URI)
 }
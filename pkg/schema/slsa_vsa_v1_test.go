@@ -0,0 +1,103 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/stretchr/testify/assert"
+)
+
+var validSLSAVSA = []byte(`{
+  "verifier": {
+    "id": "https://conforma.dev/cli",
+    "version": {"conforma": "v0.8.0"}
+  },
+  "timeVerified": "2024-03-21T12:00:00Z",
+  "resourceUri": "registry.io/repository/image@sha256:abcdef0123456789",
+  "policy": {
+    "uri": "git::https://github.com/org/policy.git",
+    "digest": {"sha256": "0123456789abcdef"}
+  },
+  "verificationResult": "PASSED",
+  "verifiedLevels": [],
+  "slsaVersion": "1.0"
+}`)
+
+func checkSLSAVSA(t *testing.T, patches ...string) {
+	for i, patch := range patches {
+		t.Run(fmt.Sprintf("case_%d", i), func(t *testing.T) {
+			j, err := jsonpatch.MergePatch(validSLSAVSA, []byte(patch))
+			assert.NoError(t, err)
+
+			var v any
+			err = json.Unmarshal(j, &v)
+			assert.NoError(t, err)
+
+			err = SLSA_VSA_v1.Validate(v)
+			snaps.MatchSnapshot(t, err)
+		})
+	}
+}
+
+func TestSLSAVSAVerifier(t *testing.T) {
+	checkSLSAVSA(t,
+		`{"verifier": null}`,
+		`{"verifier": {"id": ""}}`,
+		`{"verifier": {"version": {"conforma": 1}}}`,
+	)
+}
+
+func TestSLSAVSAPolicy(t *testing.T) {
+	checkSLSAVSA(t,
+		`{"policy": null}`,
+		`{"policy": {"uri": null, "digest": null}}`,
+		`{"policy": {"uri": null, "digest": {"sha256": 1}}}`,
+		`{"policy": {"digest": null}}`,
+	)
+}
+
+func TestSLSAVSAVerificationResult(t *testing.T) {
+	checkSLSAVSA(t,
+		`{"verificationResult": null}`,
+		`{"verificationResult": "passed"}`,
+		`{"verificationResult": "FAILED"}`,
+	)
+}
+
+func TestSLSAVSALevels(t *testing.T) {
+	checkSLSAVSA(t,
+		`{"verifiedLevels": null}`,
+		`{"verifiedLevels": "SLSA_BUILD_LEVEL_3"}`,
+		`{"verifiedLevels": ["SLSA_BUILD_LEVEL_3"]}`,
+		`{"dependencyLevels": {"SLSA_BUILD_LEVEL_3": -1}}`,
+		`{"slsaVersion": "1"}`,
+	)
+}
+
+func TestSLSAVSATimeVerified(t *testing.T) {
+	checkSLSAVSA(t,
+		`{"timeVerified": null}`,
+		`{"timeVerified": "2024-03-21"}`,
+	)
+}
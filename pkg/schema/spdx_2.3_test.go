@@ -0,0 +1,127 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+//go:build unit
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/stretchr/testify/assert"
+)
+
+var validSPDX = []byte(`{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "registry.io/repository/image",
+  "documentNamespace": "https://example.com/registry.io/repository/image",
+  "creationInfo": {
+    "created": "2024-03-21T12:00:00Z",
+    "creators": ["Tool: syft-1.0.0"]
+  },
+  "packages": [
+    {
+      "SPDXID": "SPDXRef-Package-openssl",
+      "name": "openssl",
+      "versionInfo": "3.0.7",
+      "downloadLocation": "NOASSERTION",
+      "externalRefs": [
+        {
+          "referenceCategory": "PACKAGE-MANAGER",
+          "referenceType": "purl",
+          "referenceLocator": "pkg:rpm/redhat/openssl@3.0.7"
+        }
+      ]
+    }
+  ],
+  "relationships": [
+    {
+      "spdxElementId": "SPDXRef-DOCUMENT",
+      "relationshipType": "DESCRIBES",
+      "relatedSpdxElement": "SPDXRef-Package-openssl"
+    }
+  ]
+}`)
+
+func checkSPDX(t *testing.T, patches ...string) {
+	for i, patch := range patches {
+		t.Run(fmt.Sprintf("case_%d", i), func(t *testing.T) {
+			j, err := jsonpatch.MergePatch(validSPDX, []byte(patch))
+			assert.NoError(t, err)
+
+			var v any
+			err = json.Unmarshal(j, &v)
+			assert.NoError(t, err)
+
+			err = SPDX_2_3.Validate(v)
+			snaps.MatchSnapshot(t, err)
+		})
+	}
+}
+
+func TestSPDXRequiredFields(t *testing.T) {
+	checkSPDX(t,
+		`{"spdxVersion": null}`,
+		`{"dataLicense": null}`,
+		`{"SPDXID": null}`,
+		`{"name": null}`,
+		`{"creationInfo": null}`,
+		`{}`,
+	)
+}
+
+func TestSPDXVersion(t *testing.T) {
+	checkSPDX(t,
+		`{"spdxVersion": "SPDX-2.2"}`,
+		`{"spdxVersion": 2.3}`,
+		`{"spdxVersion": "SPDX-2.3"}`,
+	)
+}
+
+func TestSPDXCreationInfo(t *testing.T) {
+	checkSPDX(t,
+		`{"creationInfo": {"created": null}}`,
+		`{"creationInfo": {"created": "yesterday"}}`,
+		`{"creationInfo": {"creators": []}}`,
+		`{"creationInfo": {"creators": ["syft"]}}`,
+		`{"creationInfo": {"creators": ["Organization: Conforma"]}}`,
+	)
+}
+
+func TestSPDXPackages(t *testing.T) {
+	checkSPDX(t,
+		`{"packages": {}}`,
+		`{"packages": [{}]}`,
+		`{"packages": [{"SPDXID": "Package", "name": "openssl", "downloadLocation": "NONE"}]}`,
+		`{"packages": [{"SPDXID": "SPDXRef-Package", "name": "openssl", "downloadLocation": "NONE", "primaryPackagePurpose": "IMAGE"}]}`,
+		`{"packages": [{"SPDXID": "SPDXRef-Package", "name": "openssl", "downloadLocation": "NONE", "checksums": [{"algorithm": "SHA256", "checksumValue": "ABC"}]}]}`,
+		`{"packages": [{"SPDXID": "SPDXRef-Package", "name": "openssl", "downloadLocation": "NONE", "externalRefs": [{"referenceCategory": "PACKAGE", "referenceType": "purl", "referenceLocator": "pkg:rpm/redhat/openssl"}]}]}`,
+		`{"packages": [{"SPDXID": "SPDXRef-Package", "name": "openssl", "downloadLocation": "NONE", "filesAnalyzed": false, "primaryPackagePurpose": "LIBRARY"}]}`,
+	)
+}
+
+func TestSPDXRelationships(t *testing.T) {
+	checkSPDX(t,
+		`{"relationships": [{}]}`,
+		`{"relationships": [{"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "REQUIRES", "relatedSpdxElement": "SPDXRef-Package-openssl"}]}`,
+		`{"relationships": [{"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "CONTAINS", "relatedSpdxElement": "SPDXRef-Package-openssl"}]}`,
+	)
+}
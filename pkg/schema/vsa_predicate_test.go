@@ -0,0 +1,129 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+//go:build unit
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/stretchr/testify/assert"
+)
+
+var validVSAPredicate = []byte(`{
+  "policy": {
+    "sources": [
+      {
+        "name": "default",
+        "policy": ["oci::quay.io/conforma/release-policy:latest"],
+        "data": ["oci::quay.io/konflux-ci/tekton-catalog/data-acceptable-bundles:latest"]
+      }
+    ],
+    "publicKey": "k8s://tekton-chains/public-key"
+  },
+  "policySource": "policy.yaml",
+  "imageRefs": ["registry.io/repository/image@sha256:abcdef0123456789"],
+  "timestamp": "2024-03-21T12:00:00Z",
+  "status": "passed",
+  "verifier": "conforma",
+  "summary": {
+    "violations": 0,
+    "warnings": 1,
+    "successes": 10,
+    "Components": [
+      {
+        "Name": "image",
+        "ImageRef": "registry.io/repository/image@sha256:abcdef0123456789",
+        "Violations": 0,
+        "Warnings": 1,
+        "Successes": 10
+      }
+    ],
+    "component": {
+      "name": "image",
+      "containerImage": "registry.io/repository/image@sha256:abcdef0123456789",
+      "source": {}
+    }
+  },
+  "publicKey": ""
+}`)
+
+func checkVSAPredicate(t *testing.T, patches ...string) {
+	for i, patch := range patches {
+		t.Run(fmt.Sprintf("case_%d", i), func(t *testing.T) {
+			j, err := jsonpatch.MergePatch(validVSAPredicate, []byte(patch))
+			assert.NoError(t, err)
+
+			var v any
+			err = json.Unmarshal(j, &v)
+			assert.NoError(t, err)
+
+			err = VSA_Predicate.Validate(v)
+			snaps.MatchSnapshot(t, err)
+		})
+	}
+}
+
+func TestVSAPredicatePolicy(t *testing.T) {
+	checkVSAPredicate(t,
+		`{"policy": null}`,
+		`{"policy": "policy.yaml"}`,
+		`{"policy": {"sources": {}}}`,
+		`{"policy": {"sources": ["policy"]}}`,
+		`{"policy": {"sources": null}}`,
+	)
+}
+
+func TestVSAPredicateTimestamp(t *testing.T) {
+	checkVSAPredicate(t,
+		`{"timestamp": null}`,
+		`{"timestamp": 1710979200}`,
+		`{"timestamp": "2024-03-21"}`,
+		`{"timestamp": "2024-03-21T12:00:00+01:00"}`,
+	)
+}
+
+func TestVSAPredicateStatus(t *testing.T) {
+	checkVSAPredicate(t,
+		`{"status": null}`,
+		`{"status": ""}`,
+		`{"status": "PASSED"}`,
+		`{"status": "failed"}`,
+	)
+}
+
+func TestVSAPredicateImageRefs(t *testing.T) {
+	checkVSAPredicate(t,
+		`{"imageRefs": "registry.io/repository/image"}`,
+		`{"imageRefs": [""]}`,
+		`{"imageRefs": null}`,
+	)
+}
+
+func TestVSAPredicateSummary(t *testing.T) {
+	checkVSAPredicate(t,
+		`{"summary": []}`,
+		`{"summary": {"violations": -1}}`,
+		`{"summary": {"warnings": 1.5}}`,
+		`{"summary": {"Components": [{"Successes": "10"}]}}`,
+		`{"summary": {"Components": null}}`,
+		`{"summary": {"component": {"name": 1}}}`,
+	)
+}